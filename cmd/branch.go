@@ -0,0 +1,84 @@
+/*
+Copyright © 2025 Arthur Mariano
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/arthvm/ditto/internal/conventional"
+	"github.com/arthvm/ditto/internal/vcs"
+	"github.com/arthvm/ditto/internal/workflow"
+)
+
+var branchCmd = &cobra.Command{
+	Use:   "branch [description]",
+	Short: "Create a branch named after a description or an issue",
+	Long: `Ask the model to classify the work described by the arguments, the
+issues given with --issues, or both, and create and check out a branch
+named after it.
+
+The name follows branch.pattern, "{type}/{issue}-{slug}" by default,
+where {type} is one of branch.types (the commit types by default),
+{issue} is the first issue and {slug} a few words summarizing the work.
+Placeholders without a value are left out along with their separators.
+With only --issues, the issue titles are read from GitHub or GitLab.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		additionalPrompt, err := cmd.Flags().GetString(promptFlagName)
+		if err != nil {
+			return fmt.Errorf("get prompt flag: %w", err)
+		}
+
+		issues, err := cmd.Flags().GetStringSlice(issuesFlagName)
+		if err != nil {
+			return fmt.Errorf("get issues flag: %w", err)
+		}
+
+		dryRun, err := isDryRun(cmd)
+		if err != nil {
+			return err
+		}
+
+		types := appConfig.Branch.Types
+		if len(types) == 0 {
+			types = appConfig.Commit.Lint.Types
+		}
+		if len(types) == 0 {
+			types = conventional.DefaultTypes
+		}
+
+		hosting, err := buildPlatform(cmd.Context(), streams.HumanOut())
+		if err != nil {
+			return err
+		}
+
+		started := time.Now()
+		res, err := workflow.Branch(cmd.Context(), workflow.BranchDeps{
+			VCS:             vcs.Git{},
+			Issues:          hosting,
+			Provider:        provider,
+			Progress:        streams,
+			GenerateTimeout: appConfig.LLM.Timeout,
+			Redactor:        redactor,
+		}, workflow.BranchParams{
+			Description:       strings.Join(args, " "),
+			Issues:            issues,
+			Pattern:           appConfig.Branch.Pattern,
+			Types:             types,
+			MaxLength:         appConfig.Branch.MaxLength,
+			AdditionalContext: additionalPrompt,
+			DryRun:            dryRun,
+		})
+		return writeBranchResult(res, dryRun, started, err)
+	},
+}
+
+func init() {
+	addDryRunFlags(branchCmd, "branch name")
+
+	rootCmd.AddCommand(branchCmd)
+}
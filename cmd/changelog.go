@@ -0,0 +1,130 @@
+/*
+Copyright © 2025 Arthur Mariano
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/arthvm/ditto/internal/changelog"
+	"github.com/arthvm/ditto/internal/vcs"
+	"github.com/arthvm/ditto/internal/workflow"
+)
+
+const (
+	fromFlagName    = "from"
+	toFlagName      = "to"
+	versionFlagName = "version"
+	writeFlagName   = "write"
+)
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Write release notes from the commits since the last tag",
+	Long: `Write user-facing release notes from the feat, fix, perf and breaking
+commits between two revisions, in the Keep a Changelog format.
+
+--from defaults to the latest release tag before --to, or to the first
+commit when there are no tags. Release tags are semantic versions
+prefixed by version.tag_prefix ("v" by default). The release is named
+after the version of the tag --to points at, or "Unreleased" when it is
+not tagged; --version overrides it.
+
+The notes are printed to stdout. With --write, they are added to the
+top of the changelog file (changelog.file, CHANGELOG.md by default)
+instead, replacing the section of the same version if there is one.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, err := cmd.Flags().GetString(fromFlagName)
+		if err != nil {
+			return fmt.Errorf("get from flag: %w", err)
+		}
+
+		to, err := cmd.Flags().GetString(toFlagName)
+		if err != nil {
+			return fmt.Errorf("get to flag: %w", err)
+		}
+
+		version, err := cmd.Flags().GetString(versionFlagName)
+		if err != nil {
+			return fmt.Errorf("get version flag: %w", err)
+		}
+
+		write, err := cmd.Flags().GetBool(writeFlagName)
+		if err != nil {
+			return fmt.Errorf("get write flag: %w", err)
+		}
+
+		additionalPrompt, err := cmd.Flags().GetString(promptFlagName)
+		if err != nil {
+			return fmt.Errorf("get prompt flag: %w", err)
+		}
+
+		started := time.Now()
+		git := vcs.Git{}
+		res, err := workflow.Changelog(cmd.Context(), workflow.ChangelogDeps{
+			VCS:             git,
+			Provider:        provider,
+			Progress:        streams,
+			GenerateTimeout: appConfig.LLM.Timeout,
+			Redactor:        redactor,
+		}, workflow.ChangelogParams{
+			From:              from,
+			To:                to,
+			Version:           version,
+			TagPrefix:         appConfig.Version.TagPrefix,
+			SystemPrompt:      appConfig.Changelog.Prompt,
+			AdditionalContext: additionalPrompt,
+		})
+
+		var path string
+		if err == nil && write {
+			path, err = writeChangelog(cmd, git, res.Release)
+		}
+		return writeChangelogResult(res, path, started, err)
+	},
+}
+
+// writeChangelog adds release to the configured changelog file and returns
+// its path.
+func writeChangelog(cmd *cobra.Command, git vcs.Git, release changelog.Release) (string, error) {
+	path := appConfig.Changelog.File
+	if !filepath.IsAbs(path) {
+		root, err := git.Root(cmd.Context())
+		if err != nil {
+			return "", fmt.Errorf("get repository root: %w", err)
+		}
+		path = filepath.Join(root, path)
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("read changelog: %w", err)
+	}
+
+	if err := os.WriteFile(path, []byte(changelog.Insert(string(existing), release)), 0o644); err != nil {
+		return "", fmt.Errorf("write changelog: %w", err)
+	}
+	return path, nil
+}
+
+func init() {
+	changelogCmd.Flags().
+		String(fromFlagName, "", "The last revision of the previous release (default: the latest tag)")
+
+	changelogCmd.Flags().
+		String(toFlagName, "HEAD", "The last revision of the release")
+
+	changelogCmd.Flags().
+		String(versionFlagName, "", "The version to name the release after (default: the tag of --to)")
+
+	changelogCmd.Flags().
+		Bool(writeFlagName, false, "Add the notes to the changelog file instead of printing them")
+
+	rootCmd.AddCommand(changelogCmd)
+}
@@ -5,17 +5,20 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
-	"github.com/arthvm/ditto/internal/ui"
+	"github.com/arthvm/ditto/internal/conventional"
 	"github.com/arthvm/ditto/internal/vcs"
 	"github.com/arthvm/ditto/internal/workflow"
 )
 
 const (
-	amendFlagName = "amend"
-	allFlagName   = "all"
+	amendFlagName       = "amend"
+	allFlagName         = "all"
+	interactiveFlagName = "interactive"
+	candidatesFlagName  = "candidates"
 )
 
 var commitCmd = &cobra.Command{
@@ -42,11 +45,39 @@ var commitCmd = &cobra.Command{
 			return fmt.Errorf("get issues flag: %w", err)
 		}
 
-		return workflow.Commit(cmd.Context(), workflow.CommitDeps{
-			VCS:             vcs.Git{},
+		candidates, err := cmd.Flags().GetInt(candidatesFlagName)
+		if err != nil {
+			return fmt.Errorf("get candidates flag: %w", err)
+		}
+
+		dryRun, err := isDryRun(cmd)
+		if err != nil {
+			return err
+		}
+
+		interactive := appConfig.Commit.Interactive
+		if cmd.Flags().Changed(interactiveFlagName) {
+			interactive, err = cmd.Flags().GetBool(interactiveFlagName)
+			if err != nil {
+				return fmt.Errorf("get interactive flag: %w", err)
+			}
+		}
+		if interactive && !streams.CanPrompt() {
+			if cmd.Flags().Changed(interactiveFlagName) {
+				return fmt.Errorf("--%s requires an interactive terminal", interactiveFlagName)
+			}
+			interactive = false
+		}
+
+		started := time.Now()
+		res, err := workflow.Commit(cmd.Context(), workflow.CommitDeps{
+			VCS:             vcs.Git{Ignore: appConfig.Ignore, Out: streams.HumanOut()},
 			Provider:        provider,
-			Progress:        ui.Default(),
+			Progress:        streams,
 			GenerateTimeout: appConfig.LLM.Timeout,
+			Stream:          streamOutput(streams) && !dryRun,
+			Redactor:        redactor,
+			Prompter:        prompter(streams),
 		}, workflow.CommitParams{
 			Amend:             amend,
 			All:               all,
@@ -54,10 +85,40 @@ var commitCmd = &cobra.Command{
 			SystemPrompt:      appConfig.Commit.Prompt,
 			AdditionalContext: additionalPrompt,
 			Issues:            issues,
+			IssuePatterns:     appConfig.Issues.BranchPatterns,
+			MaxPromptTokens:   promptBudget(),
+			GeneratedFiles:    appConfig.Budget.Generated,
+			MapReduce: workflow.MapReduceParams{
+				Enabled:     appConfig.Budget.MapReduce.Enabled,
+				Concurrency: appConfig.Budget.MapReduce.Concurrency,
+				ChunkTokens: appConfig.Budget.MapReduce.ChunkTokens,
+			},
+			Interactive: interactive,
+			Candidates:  candidates,
+			DryRun:      dryRun,
+			Lint:        commitLint(),
 		})
+		return writeCommitResult(res, dryRun, started, err)
 	},
 }
 
+// commitLint returns how generated commit messages are checked against
+// the convention.
+func commitLint() workflow.LintParams {
+	return workflow.LintParams{
+		Enabled: appConfig.Commit.LintEnabled(),
+		Retries: appConfig.Commit.Lint.Retries,
+		Rules:   lintRules(),
+	}
+}
+
+func lintRules() conventional.Rules {
+	return conventional.Rules{
+		Types:            appConfig.Commit.Lint.Types,
+		MaxSubjectLength: appConfig.Commit.Lint.MaxSubjectLength,
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(commitCmd)
 
@@ -66,4 +127,12 @@ func init() {
 
 	commitCmd.Flags().
 		BoolP(allFlagName, "a", false, "Used to commit all tracked files")
+
+	commitCmd.Flags().
+		BoolP(interactiveFlagName, "i", false, "Review the generated message before committing")
+
+	commitCmd.Flags().
+		Int(candidatesFlagName, 1, "Generate up to this many alternative messages and pick one (duplicates are dropped)")
+
+	addDryRunFlags(commitCmd, "commit message")
 }
@@ -0,0 +1,142 @@
+/*
+Copyright © 2025 Arthur Mariano
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/arthvm/ditto/internal/hook"
+	"github.com/arthvm/ditto/internal/vcs"
+	"github.com/arthvm/ditto/internal/workflow"
+)
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage the git hook that writes commit messages for plain git commit",
+}
+
+var hookInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the prepare-commit-msg hook in the current repository",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := vcs.Git{}.HooksDir(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("get hooks dir: %w", err)
+		}
+
+		executable, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("get executable path: %w", err)
+		}
+
+		chained, err := hook.Install(dir, executable)
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dir, hook.PrepareCommitMsg)
+		fmt.Fprintf(streams.HumanOut(), "Installed %s\n", path)
+		if chained {
+			fmt.Fprintf(streams.HumanOut(), "The existing hook still runs after ditto's, it was moved next to it.\n")
+		}
+		return nil
+	},
+}
+
+var hookUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the prepare-commit-msg hook and restore the one it replaced",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := vcs.Git{}.HooksDir(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("get hooks dir: %w", err)
+		}
+
+		restored, err := hook.Uninstall(dir)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(streams.HumanOut(), "Removed %s\n", filepath.Join(dir, hook.PrepareCommitMsg))
+		if restored {
+			fmt.Fprintf(streams.HumanOut(), "Restored the hook that was installed before.\n")
+		}
+		return nil
+	},
+}
+
+var hookRunCmd = &cobra.Command{
+	Use:   "run prepare-commit-msg <file> [source] [sha]",
+	Short: "Entry point called by the installed hook",
+	Long: `Entry point called by the installed hook. It fills the commit message
+file with a generated message for plain "git commit" invocations and leaves
+messages given with -m/-F, templates, merges, squashes and amends alone.
+
+Failures are reported as warnings and never block the commit.`,
+	Args:   cobra.RangeArgs(2, 4),
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if args[0] != hook.PrepareCommitMsg {
+			return fmt.Errorf("unsupported hook %q", args[0])
+		}
+
+		file := args[1]
+		var source string
+		if len(args) > 2 {
+			source = args[2]
+		}
+		if !hook.ShouldGenerate(source) {
+			return nil
+		}
+
+		if err := prepareCommitMsg(cmd, file); err != nil {
+			streams.Warnf("ditto: %v; write the commit message yourself", err)
+		}
+		return nil
+	},
+}
+
+// prepareCommitMsg generates a message for the staged changes, which under
+// git commit -a already include the tracked files, and writes it to file.
+func prepareCommitMsg(cmd *cobra.Command, file string) error {
+	res, err := workflow.Commit(cmd.Context(), workflow.CommitDeps{
+		VCS:             vcs.Git{Ignore: appConfig.Ignore},
+		Provider:        provider,
+		Progress:        streams,
+		GenerateTimeout: appConfig.LLM.Timeout,
+		Redactor:        redactor,
+	}, workflow.CommitParams{
+		SystemPrompt:    appConfig.Commit.Prompt,
+		IssuePatterns:   appConfig.Issues.BranchPatterns,
+		MaxPromptTokens: promptBudget(),
+		GeneratedFiles:  appConfig.Budget.Generated,
+		MapReduce: workflow.MapReduceParams{
+			Enabled:     appConfig.Budget.MapReduce.Enabled,
+			Concurrency: appConfig.Budget.MapReduce.Concurrency,
+			ChunkTokens: appConfig.Budget.MapReduce.ChunkTokens,
+		},
+		DryRun: true,
+		Lint:   commitLint(),
+	})
+	if err != nil {
+		return err
+	}
+	if res.Message == "" {
+		return errors.New("empty message generated")
+	}
+
+	return hook.WriteMessage(file, res.Message)
+}
+
+func init() {
+	hookCmd.AddCommand(hookInstallCmd, hookUninstallCmd, hookRunCmd)
+	rootCmd.AddCommand(hookCmd)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2025 Arthur Mariano
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/arthvm/ditto/internal/conventional"
+	"github.com/arthvm/ditto/internal/vcs"
+)
+
+// lintReport is the document printed by ditto lint --output json.
+type lintReport struct {
+	Command string             `json:"command"`
+	Range   string             `json:"range"`
+	Valid   bool               `json:"valid"`
+	Commits []lintCommitReport `json:"commits"`
+}
+
+type lintCommitReport struct {
+	Hash       string                   `json:"hash"`
+	Subject    string                   `json:"subject"`
+	Violations []conventional.Violation `json:"violations"`
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [rev-range]",
+	Short: "Check commit messages against the Conventional Commits rules",
+	Long: `Check the messages of the commits in rev-range against the same
+Conventional Commits rules applied to generated messages. The range
+defaults to the commits on top of the base branch (<base>..HEAD).
+Merge commits and fixup!/squash!/amend! commits are skipped.
+
+The command exits with a non-zero status when a message breaks a rule,
+which makes it suitable for CI.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		revRange := appConfig.BaseBranch + "..HEAD"
+		if len(args) > 0 {
+			revRange = args[0]
+		}
+
+		commits, err := vcs.Git{}.Messages(cmd.Context(), revRange)
+		if err != nil {
+			return fmt.Errorf("get commits: %w", err)
+		}
+
+		rules := lintRules()
+		report := lintReport{Command: "lint", Range: revRange, Valid: true, Commits: []lintCommitReport{}}
+		for _, c := range commits {
+			if isAutosquash(c.Message) {
+				continue
+			}
+
+			violations := conventional.Lint(c.Message, rules)
+			if violations == nil {
+				violations = []conventional.Violation{}
+			}
+			report.Valid = report.Valid && len(violations) == 0
+			report.Commits = append(report.Commits, lintCommitReport{
+				Hash:       c.Hash,
+				Subject:    conventional.Parse(c.Message).Subject,
+				Violations: violations,
+			})
+		}
+
+		if streams.JSON() {
+			if err := writeJSON(report); err != nil {
+				return err
+			}
+		} else {
+			writeLintReport(report)
+		}
+
+		if !report.Valid {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("commit messages in %s break the convention", revRange)
+		}
+		return nil
+	},
+}
+
+func writeLintReport(report lintReport) {
+	failed := 0
+	for _, c := range report.Commits {
+		if len(c.Violations) == 0 {
+			continue
+		}
+		failed++
+
+		fmt.Fprintf(streams.Out, "%s %s\n", c.Hash[:min(len(c.Hash), 7)], c.Subject)
+		for _, v := range c.Violations {
+			fmt.Fprintf(streams.Out, "  - %s\n", v.Message)
+		}
+	}
+
+	fmt.Fprintf(streams.Out, "%d of %d commits follow the convention\n", len(report.Commits)-failed, len(report.Commits))
+}
+
+// isAutosquash reports whether msg belongs to a commit meant to be folded
+// into another one by git rebase --autosquash.
+func isAutosquash(msg string) bool {
+	for _, prefix := range []string{"fixup! ", "squash! ", "amend! "} {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+}
@@ -0,0 +1,410 @@
+/*
+Copyright © 2025 Arthur Mariano
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/arthvm/ditto/internal/conventional"
+	"github.com/arthvm/ditto/internal/llm"
+	"github.com/arthvm/ditto/internal/llm/fallback"
+	"github.com/arthvm/ditto/internal/workflow"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// report is the document printed by --output json.
+type report struct {
+	Command   string           `json:"command"`
+	Provider  string           `json:"provider"`
+	Model     string           `json:"model"`
+	DryRun    bool             `json:"dry_run"`
+	Commit    *commitReport    `json:"commit,omitempty"`
+	PR        *prReport        `json:"pr,omitempty"`
+	Split     *splitReport     `json:"split,omitempty"`
+	Reword    *rewordReport    `json:"reword,omitempty"`
+	Squash    *squashReport    `json:"squash,omitempty"`
+	Changelog *changelogReport `json:"changelog,omitempty"`
+	Branch    *branchReport    `json:"branch,omitempty"`
+	Usage     usageReport      `json:"usage"`
+	Timings   timingsReport    `json:"timings"`
+	Warnings  []string         `json:"warnings"`
+	Error     string           `json:"error,omitempty"`
+}
+
+type commitReport struct {
+	Committed      bool            `json:"committed"`
+	Message        *messageReport  `json:"message,omitempty"`
+	Candidates     []messageReport `json:"candidates,omitempty"`
+	InferredIssues []string        `json:"inferred_issues,omitempty"`
+}
+
+type messageReport struct {
+	Subject string                `json:"subject"`
+	Body    string                `json:"body"`
+	Footers []conventional.Footer `json:"footers"`
+}
+
+type prReport struct {
+	Opened         bool              `json:"opened"`
+	Head           string            `json:"head"`
+	Base           string            `json:"base"`
+	Title          string            `json:"title,omitempty"`
+	Body           string            `json:"body,omitempty"`
+	Labels         []string          `json:"labels,omitempty"`
+	Breaking       bool              `json:"breaking"`
+	Candidates     []prMessageReport `json:"candidates,omitempty"`
+	InferredIssues []string          `json:"inferred_issues,omitempty"`
+}
+
+type prMessageReport struct {
+	Title    string   `json:"title"`
+	Body     string   `json:"body"`
+	Labels   []string `json:"labels,omitempty"`
+	Breaking bool     `json:"breaking"`
+}
+
+type splitReport struct {
+	Committed bool                `json:"committed"`
+	Worktree  bool                `json:"worktree"`
+	Commits   []splitCommitReport `json:"commits"`
+}
+
+type splitCommitReport struct {
+	Message messageReport     `json:"message"`
+	Hunks   []splitHunkReport `json:"hunks"`
+}
+
+type splitHunkReport struct {
+	Path    string `json:"path"`
+	Header  string `json:"header,omitempty"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+}
+
+type rewordReport struct {
+	Rewritten bool                 `json:"rewritten"`
+	Head      string               `json:"head,omitempty"`
+	Commits   []rewordCommitReport `json:"commits"`
+}
+
+type rewordCommitReport struct {
+	Hash   string        `json:"hash"`
+	Before string        `json:"before"`
+	After  messageReport `json:"after"`
+}
+
+type squashReport struct {
+	Applied bool           `json:"applied"`
+	Base    string         `json:"base"`
+	Commits int            `json:"commits"`
+	Message *messageReport `json:"message,omitempty"`
+}
+
+type changelogReport struct {
+	From    string `json:"from,omitempty"`
+	To      string `json:"to"`
+	Version string `json:"version,omitempty"`
+	Date    string `json:"date,omitempty"`
+	Commits int    `json:"commits"`
+	// Markdown is the release section, empty when nothing was generated.
+	Markdown string `json:"markdown,omitempty"`
+	// File is the changelog that was updated, if any.
+	File string `json:"file,omitempty"`
+}
+
+type branchReport struct {
+	Created bool   `json:"created"`
+	Name    string `json:"name,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Slug    string `json:"slug,omitempty"`
+}
+
+type usageReport struct {
+	llm.Usage
+	// Requests is the number of requests that reported usage. Providers
+	// that do not report it are not counted.
+	Requests int `json:"requests"`
+}
+
+type timingsReport struct {
+	CollectMS  int64 `json:"collect_ms"`
+	GenerateMS int64 `json:"generate_ms"`
+	TotalMS    int64 `json:"total_ms"`
+}
+
+// newReport fills the fields shared by every command.
+func newReport(command string, dryRun bool, timings workflow.Timings, started time.Time, runErr error) report {
+	name := providerName()
+	tokens, requests := usage.Usage()
+
+	r := report{
+		Command:  command,
+		Provider: name,
+		Model:    appConfig.ModelForProvider(name),
+		DryRun:   dryRun,
+		Usage:    usageReport{Usage: tokens, Requests: requests},
+		Timings: timingsReport{
+			CollectMS:  timings.Collect.Milliseconds(),
+			GenerateMS: timings.Generate.Milliseconds(),
+			TotalMS:    time.Since(started).Milliseconds(),
+		},
+		Warnings: streams.Warnings(),
+	}
+	if r.Warnings == nil {
+		r.Warnings = []string{}
+	}
+	if runErr != nil {
+		r.Error = runErr.Error()
+	}
+	return r
+}
+
+// providerName returns the provider that generated the last response, or
+// the primary provider when nothing was generated.
+func providerName() string {
+	if fb, ok := provider.(*fallback.Provider); ok && fb.Last() != "" {
+		return fb.Last()
+	}
+	return appConfig.Provider.Primary()
+}
+
+func newMessageReport(msg string) messageReport {
+	m := conventional.Parse(msg)
+	footers := m.Footers
+	if footers == nil {
+		footers = []conventional.Footer{}
+	}
+	return messageReport{Subject: m.Subject, Body: m.Body, Footers: footers}
+}
+
+// writeCommitResult prints the outcome of ditto commit: a report in JSON
+// mode, otherwise the message in dry-run mode or the candidates left for
+// the caller to choose from.
+func writeCommitResult(res workflow.CommitResult, dryRun bool, started time.Time, runErr error) error {
+	candidates := make([]messageReport, len(res.Candidates))
+	for i, c := range res.Candidates {
+		candidates[i] = newMessageReport(c)
+	}
+
+	if streams.JSON() {
+		r := newReport("commit", dryRun, res.Timings, started, runErr)
+		r.Commit = &commitReport{Committed: res.Committed, Candidates: candidates, InferredIssues: res.InferredIssues}
+		if res.Message != "" {
+			msg := newMessageReport(res.Message)
+			r.Commit.Message = &msg
+		}
+		return writeReport(r, runErr)
+	}
+
+	if runErr != nil {
+		return runErr
+	}
+
+	switch {
+	case len(candidates) > 0:
+		return writeJSON(candidates)
+	case dryRun:
+		fmt.Fprintln(streams.Out, res.Message)
+	}
+	return nil
+}
+
+// writePRResult is the ditto pr counterpart of writeCommitResult.
+func writePRResult(res workflow.PRResult, dryRun bool, started time.Time, runErr error) error {
+	candidates := make([]prMessageReport, len(res.Candidates))
+	for i, c := range res.Candidates {
+		candidates[i] = prMessageReport{Title: c.Title, Body: c.Body, Labels: c.Labels, Breaking: c.Breaking}
+	}
+
+	if streams.JSON() {
+		r := newReport("pr", dryRun, res.Timings, started, runErr)
+		r.PR = &prReport{
+			Opened:         res.Opened,
+			Head:           res.Head,
+			Base:           res.Base,
+			Title:          res.Title,
+			Body:           res.Body,
+			Labels:         res.Labels,
+			Breaking:       res.Breaking,
+			Candidates:     candidates,
+			InferredIssues: res.InferredIssues,
+		}
+		return writeReport(r, runErr)
+	}
+
+	if runErr != nil {
+		return runErr
+	}
+
+	switch {
+	case len(candidates) > 0:
+		return writeJSON(candidates)
+	case dryRun:
+		fmt.Fprintf(streams.Out, "%s\n\n%s\n", res.Title, res.Body)
+	}
+	return nil
+}
+
+// writeSplitResult prints the outcome of ditto split: a report in JSON
+// mode, otherwise the plan in dry-run mode.
+func writeSplitResult(res workflow.SplitResult, dryRun bool, started time.Time, runErr error) error {
+	if streams.JSON() {
+		r := newReport("split", dryRun, res.Timings, started, runErr)
+		r.Split = &splitReport{Committed: res.Committed, Worktree: res.Worktree, Commits: []splitCommitReport{}}
+		for _, c := range res.Commits {
+			commit := splitCommitReport{Message: newMessageReport(c.Message)}
+			for _, h := range c.Hunks {
+				commit.Hunks = append(commit.Hunks, splitHunkReport(h))
+			}
+			r.Split.Commits = append(r.Split.Commits, commit)
+		}
+		return writeReport(r, runErr)
+	}
+
+	if runErr != nil {
+		return runErr
+	}
+
+	if dryRun {
+		for i, c := range res.Commits {
+			if i > 0 {
+				fmt.Fprintln(streams.Out)
+			}
+			fmt.Fprintf(streams.Out, "# Commit %d of %d\n%s\n", i+1, len(res.Commits), c)
+		}
+	}
+	return nil
+}
+
+// writeRewordResult prints the outcome of ditto reword: a report in JSON
+// mode, otherwise the table of old and new subjects in dry-run mode.
+func writeRewordResult(res workflow.RewordResult, dryRun bool, started time.Time, runErr error) error {
+	if streams.JSON() {
+		r := newReport("reword", dryRun, res.Timings, started, runErr)
+		r.Reword = &rewordReport{Rewritten: res.Rewritten, Head: res.Head, Commits: []rewordCommitReport{}}
+		for _, c := range res.Commits {
+			r.Reword.Commits = append(r.Reword.Commits, rewordCommitReport{
+				Hash:   c.Hash,
+				Before: c.Before,
+				After:  newMessageReport(c.After),
+			})
+		}
+		return writeReport(r, runErr)
+	}
+
+	if runErr != nil {
+		return runErr
+	}
+
+	switch {
+	case dryRun && len(res.Commits) > 0:
+		fmt.Fprintln(streams.Out, res.Table())
+	case res.Rewritten:
+		fmt.Fprintf(streams.HumanOut(), "Reworded %d commits, HEAD is now %s\n", len(res.Commits), res.Head)
+	}
+	return nil
+}
+
+// writeSquashResult prints the outcome of ditto squash: a report in JSON
+// mode, otherwise the message unless it was already committed.
+func writeSquashResult(res workflow.SquashResult, apply bool, started time.Time, runErr error) error {
+	if streams.JSON() {
+		r := newReport("squash", !apply, res.Timings, started, runErr)
+		r.Squash = &squashReport{Applied: res.Applied, Base: res.Base, Commits: res.Commits}
+		if res.Message != "" {
+			msg := newMessageReport(res.Message)
+			r.Squash.Message = &msg
+		}
+		return writeReport(r, runErr)
+	}
+
+	if runErr != nil {
+		return runErr
+	}
+
+	if !res.Applied {
+		fmt.Fprintln(streams.Out, res.Message)
+	}
+	return nil
+}
+
+// writeChangelogResult prints the outcome of ditto changelog: a report in
+// JSON mode, otherwise the release notes unless they were written to path.
+func writeChangelogResult(res workflow.ChangelogResult, path string, started time.Time, runErr error) error {
+	var markdown string
+	if !res.Release.Empty() {
+		markdown = res.Release.Markdown()
+	}
+
+	if streams.JSON() {
+		r := newReport("changelog", path == "", res.Timings, started, runErr)
+		r.Changelog = &changelogReport{
+			From:     res.From,
+			To:       res.To,
+			Version:  res.Release.Version,
+			Date:     res.Release.Date,
+			Commits:  res.Commits,
+			Markdown: markdown,
+			File:     path,
+		}
+		return writeReport(r, runErr)
+	}
+
+	if runErr != nil {
+		return runErr
+	}
+
+	if path != "" {
+		name := res.Release.Version
+		if name == "" {
+			name = "the unreleased changes"
+		}
+		fmt.Fprintf(streams.HumanOut(), "Added %s to %s\n", name, path)
+		return nil
+	}
+	fmt.Fprintln(streams.Out, markdown)
+	return nil
+}
+
+// writeBranchResult prints the outcome of ditto branch: a report in JSON
+// mode, otherwise the name in dry-run mode or the branch that was created.
+func writeBranchResult(res workflow.BranchResult, dryRun bool, started time.Time, runErr error) error {
+	if streams.JSON() {
+		r := newReport("branch", dryRun, res.Timings, started, runErr)
+		r.Branch = &branchReport{Created: res.Created, Name: res.Name, Type: res.Type, Slug: res.Slug}
+		return writeReport(r, runErr)
+	}
+
+	if runErr != nil {
+		return runErr
+	}
+
+	if dryRun {
+		fmt.Fprintln(streams.Out, res.Name)
+		return nil
+	}
+	fmt.Fprintf(streams.HumanOut(), "Switched to a new branch '%s'\n", res.Name)
+	return nil
+}
+
+// writeReport prints r and passes runErr through so the exit status still
+// reflects failures.
+func writeReport(r report, runErr error) error {
+	if err := writeJSON(r); err != nil {
+		return err
+	}
+	return runErr
+}
+
+func writeJSON(v any) error {
+	if err := streams.WriteJSON(v); err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+	return nil
+}
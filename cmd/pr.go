@@ -5,11 +5,10 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
-	"github.com/arthvm/ditto/internal/platform"
-	"github.com/arthvm/ditto/internal/ui"
 	"github.com/arthvm/ditto/internal/vcs"
 	"github.com/arthvm/ditto/internal/workflow"
 )
@@ -55,12 +54,31 @@ var prCmd = &cobra.Command{
 			return fmt.Errorf("get issues flag: %w", err)
 		}
 
-		return workflow.CreatePR(cmd.Context(), workflow.PRDeps{
-			VCS:             vcs.Git{},
-			Platform:        platform.GitHub{},
+		candidates, err := cmd.Flags().GetInt(candidatesFlagName)
+		if err != nil {
+			return fmt.Errorf("get candidates flag: %w", err)
+		}
+
+		dryRun, err := isDryRun(cmd)
+		if err != nil {
+			return err
+		}
+
+		hosting, err := buildPlatform(cmd.Context(), streams.HumanOut())
+		if err != nil {
+			return err
+		}
+
+		started := time.Now()
+		res, err := workflow.CreatePR(cmd.Context(), workflow.PRDeps{
+			VCS:             vcs.Git{Ignore: appConfig.Ignore, Out: streams.HumanOut()},
+			Platform:        hosting,
 			Provider:        provider,
-			Progress:        ui.Default(),
+			Progress:        streams,
 			GenerateTimeout: appConfig.LLM.Timeout,
+			Stream:          streamOutput(streams) && !dryRun,
+			Redactor:        redactor,
+			Prompter:        prompter(streams),
 		}, workflow.PRParams{
 			BaseBranch:        baseBranch,
 			HeadBranch:        headBranch,
@@ -69,9 +87,14 @@ var prCmd = &cobra.Command{
 			AdditionalContext: additionalPrompt,
 			TemplatePath:      appConfig.PR.TemplatePath,
 			Issues:            issues,
+			IssuePatterns:     appConfig.Issues.BranchPatterns,
 			IgnoreTemplate:    ignoreTemplate,
 			Draft:             draft,
+			Candidates:        candidates,
+			DryRun:            dryRun,
+			Labels:            appConfig.PR.Labels,
 		})
+		return writePRResult(res, dryRun, started, err)
 	},
 }
 
@@ -88,5 +111,10 @@ func init() {
 	prCmd.Flags().
 		Bool(draftFlagName, false, "Set this flag to create the PR as a draft")
 
+	prCmd.Flags().
+		Int(candidatesFlagName, 1, "Generate up to this many alternative PRs and pick one (duplicates are dropped)")
+
+	addDryRunFlags(prCmd, "PR title and body")
+
 	rootCmd.AddCommand(prCmd)
 }
@@ -0,0 +1,102 @@
+/*
+Copyright © 2025 Arthur Mariano
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/arthvm/ditto/internal/vcs"
+	"github.com/arthvm/ditto/internal/workflow"
+)
+
+const invalidFlagName = "invalid"
+
+var rewordCmd = &cobra.Command{
+	Use:   "reword [rev-range]",
+	Short: "Regenerate the messages of existing commits",
+	Long: `Regenerate the messages of the commits in rev-range from their own
+diffs and rewrite them in place. The range defaults to the commits on top
+of the base branch (<base>..HEAD).
+
+The old and new subjects are shown side by side before anything is
+rewritten. Merge commits, commits that are already on the base branch and
+commits that are not on the current branch are refused. Trees, authors
+and author dates are kept; signatures are not.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		baseBranch := appConfig.BaseBranch
+		if cmd.Flags().Changed(baseBranchFlag) {
+			baseBranch, _ = cmd.Flags().GetString(baseBranchFlag)
+		}
+
+		revRange := baseBranch + "..HEAD"
+		if len(args) > 0 {
+			revRange = args[0]
+		}
+
+		additionalPrompt, err := cmd.Flags().GetString(promptFlagName)
+		if err != nil {
+			return fmt.Errorf("get prompt flag: %w", err)
+		}
+
+		onlyInvalid, err := cmd.Flags().GetBool(invalidFlagName)
+		if err != nil {
+			return fmt.Errorf("get invalid flag: %w", err)
+		}
+
+		yes, err := cmd.Flags().GetBool(yesFlagName)
+		if err != nil {
+			return fmt.Errorf("get yes flag: %w", err)
+		}
+
+		dryRun, err := isDryRun(cmd)
+		if err != nil {
+			return err
+		}
+
+		if !yes && !dryRun && prompter(streams) == nil {
+			return fmt.Errorf("confirming the new messages requires an interactive terminal; pass --%s or --%s", yesFlagName, dryRunFlagName)
+		}
+
+		started := time.Now()
+		res, err := workflow.Reword(cmd.Context(), workflow.RewordDeps{
+			VCS:             vcs.Git{Ignore: appConfig.Ignore},
+			Provider:        provider,
+			Progress:        streams,
+			GenerateTimeout: appConfig.LLM.Timeout,
+			Redactor:        redactor,
+			Prompter:        prompter(streams),
+		}, workflow.RewordParams{
+			Range:             revRange,
+			BaseBranch:        baseBranch,
+			SystemPrompt:      appConfig.Commit.Prompt,
+			AdditionalContext: additionalPrompt,
+			MaxPromptTokens:   promptBudget(),
+			GeneratedFiles:    appConfig.Budget.Generated,
+			Lint:              commitLint(),
+			OnlyInvalid:       onlyInvalid,
+			Yes:               yes,
+			DryRun:            dryRun,
+		})
+		return writeRewordResult(res, dryRun, started, err)
+	},
+}
+
+func init() {
+	rewordCmd.Flags().
+		String(baseBranchFlag, "", "The branch whose commits must not be rewritten")
+
+	rewordCmd.Flags().
+		Bool(invalidFlagName, false, "Only reword commits whose message breaks the convention")
+
+	rewordCmd.Flags().
+		BoolP(yesFlagName, "y", false, "Rewrite the commits without asking for confirmation")
+
+	addDryRunFlags(rewordCmd, "message table")
+
+	rootCmd.AddCommand(rewordCmd)
+}
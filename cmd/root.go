@@ -4,7 +4,9 @@ Copyright © 2025 Arthur Mariano
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
@@ -12,10 +14,19 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/arthvm/ditto/internal/config"
+	"github.com/arthvm/ditto/internal/git"
 	"github.com/arthvm/ditto/internal/llm"
+	"github.com/arthvm/ditto/internal/llm/anthropic"
 	"github.com/arthvm/ditto/internal/llm/copilot"
+	"github.com/arthvm/ditto/internal/llm/fallback"
 	"github.com/arthvm/ditto/internal/llm/gemini"
 	"github.com/arthvm/ditto/internal/llm/ollama"
+	"github.com/arthvm/ditto/internal/llm/openai"
+	"github.com/arthvm/ditto/internal/llm/retry"
+	"github.com/arthvm/ditto/internal/platform"
+	"github.com/arthvm/ditto/internal/redact"
+	"github.com/arthvm/ditto/internal/ui"
+	"github.com/arthvm/ditto/internal/workflow"
 )
 
 const (
@@ -23,12 +34,19 @@ const (
 	providerFlagName = "provider"
 	modelFlagName    = "model"
 	issuesFlagName   = "issues"
+	dryRunFlagName   = "dry-run"
+	printFlagName    = "print"
+	outputFlagName   = "output"
 )
 
 // Resolved at startup by PersistentPreRunE, available to all subcommands.
 var (
 	appConfig config.Config
 	provider  llm.Provider
+	streams   *ui.IOStreams
+	redactor  workflow.Redactor
+	// usage accumulates the tokens reported by providers for the run.
+	usage *llm.UsageTracker
 )
 
 var rootCmd = &cobra.Command{
@@ -51,13 +69,16 @@ func init() {
 		String(promptFlagName, "", "Used to provide additional context to the model")
 
 	rootCmd.PersistentFlags().
-		String(providerFlagName, "", "LLM provider to use (gemini, ollama, copilot)")
+		String(providerFlagName, "", "LLM provider to use (gemini, ollama, copilot, openai, anthropic); a comma-separated list falls back in order")
 
 	rootCmd.PersistentFlags().
 		String(modelFlagName, "", "Model name to use with the selected provider")
 
 	rootCmd.PersistentFlags().
 		StringSlice(issuesFlagName, nil, "Specifies the issues that are addressed by the operation.")
+
+	rootCmd.PersistentFlags().
+		StringP(outputFlagName, "o", outputText, "Output format (text, json)")
 }
 
 func setup(cmd *cobra.Command, _ []string) error {
@@ -69,41 +90,235 @@ func setup(cmd *cobra.Command, _ []string) error {
 	}
 
 	if cmd.Flags().Changed(providerFlagName) {
-		cfg.Provider, _ = cmd.Flags().GetString(providerFlagName)
+		name, _ := cmd.Flags().GetString(providerFlagName)
+		cfg.Provider = config.ParseProviderList(name)
 	}
 	if cmd.Flags().Changed(modelFlagName) {
 		model, _ := cmd.Flags().GetString(modelFlagName)
 		cfg.SetModelForProvider(model)
 	}
 
+	output, _ := cmd.Flags().GetString(outputFlagName)
+	if output != outputText && output != outputJSON {
+		return fmt.Errorf("invalid --%s %q: must be %s or %s", outputFlagName, output, outputText, outputJSON)
+	}
+
 	appConfig = cfg
-	provider, err = buildProvider(cfg)
+	streams = ui.Default()
+	streams.SetJSON(output == outputJSON)
+
+	usage = &llm.UsageTracker{}
+	cmd.SetContext(llm.WithUsageTracker(cmd.Context(), usage))
+
+	provider, err = buildProvider(cfg, streams.Warnf)
 	if err != nil {
 		return err
 	}
 
+	redactor, err = buildRedactor(cfg.Redact)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
 	return nil
 }
 
-func buildProvider(cfg config.Config) (llm.Provider, error) {
+// buildProvider builds the configured providers with transient failures
+// retried, wrapped in a fallback chain that reports through report.
+func buildProvider(cfg config.Config, report func(format string, args ...any)) (llm.Provider, error) {
+	if len(cfg.Provider) == 0 {
+		return nil, fmt.Errorf("no provider configured")
+	}
+
+	policy := retry.Policy{
+		MaxAttempts:    cfg.LLM.Retry.MaxAttempts,
+		InitialBackoff: cfg.LLM.Retry.InitialBackoff,
+		MaxBackoff:     cfg.LLM.Retry.MaxBackoff,
+	}
+
+	entries := make([]*fallback.Entry, len(cfg.Provider))
+	for i, name := range cfg.Provider {
+		factory, err := providerFactory(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = &fallback.Entry{Name: name, New: func() (llm.Provider, error) {
+			p, err := factory()
+			if err != nil {
+				return nil, err
+			}
+			return retry.New(p, policy), nil
+		}}
+	}
+
+	// Providers are built on first use, so commands that never generate
+	// anything do not trigger authentication.
+	return fallback.New(entries, cfg.LLM.AttemptTimeout, report), nil
+}
+
+func providerFactory(cfg config.Config, name string) (func() (llm.Provider, error), error) {
 	switch {
-	case cfg.Provider == "ollama":
-		return ollama.New(cfg.Ollama.Host, cfg.Ollama.Model, cfg.LLM.Temperature), nil
+	case name == "ollama":
+		return func() (llm.Provider, error) {
+			return ollama.New(cfg.Ollama.Host, cfg.Ollama.Model, cfg.LLM.Temperature), nil
+		}, nil
+
+	case name == "copilot":
+		return func() (llm.Provider, error) {
+			return copilot.New(cfg.Copilot.Model, cfg.LLM.Temperature, cfg.Copilot.APIKey, cfg.Copilot.ClientID)
+		}, nil
+
+	case name == "openai":
+		return func() (llm.Provider, error) {
+			return openai.New(openai.Options{
+				BaseURL:      cfg.OpenAI.BaseURL,
+				APIKey:       cfg.OpenAI.APIKey,
+				Organization: cfg.OpenAI.Organization,
+				Model:        cfg.OpenAI.Model,
+				Temperature:  cfg.LLM.Temperature,
+				Headers:      cfg.OpenAI.Headers,
+			}), nil
+		}, nil
 
-	case cfg.Provider == "copilot":
-		return copilot.New(cfg.Copilot.Model, cfg.LLM.Temperature, cfg.Copilot.APIKey, cfg.Copilot.ClientID)
+	case name == "anthropic":
+		return func() (llm.Provider, error) {
+			return anthropic.New(anthropic.Options{
+				BaseURL:     cfg.Anthropic.BaseURL,
+				APIKey:      cfg.Anthropic.APIKey,
+				Model:       cfg.Anthropic.Model,
+				MaxTokens:   cfg.Anthropic.MaxTokens,
+				Temperature: cfg.LLM.Temperature,
+			}), nil
+		}, nil
 
-	case strings.HasPrefix(cfg.Provider, "gemini"):
-		if cfg.Gemini.APIKey != "" {
-			if err := os.Setenv("GEMINI_API_KEY", cfg.Gemini.APIKey); err != nil {
-				return nil, fmt.Errorf("set GEMINI_API_KEY env var: %w", err)
+	case strings.HasPrefix(name, "gemini"):
+		return func() (llm.Provider, error) {
+			if cfg.Gemini.APIKey != "" {
+				if err := os.Setenv("GEMINI_API_KEY", cfg.Gemini.APIKey); err != nil {
+					return nil, fmt.Errorf("set GEMINI_API_KEY env var: %w", err)
+				}
 			}
+			return gemini.New(cfg.Gemini.Model, cfg.LLM.Temperature), nil
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown provider: %q", name)
+	}
+}
+
+// promptBudget returns the prompt token budget for the configured providers.
+// With a fallback chain the smallest budget wins so that any provider of the
+// chain can accept the prompt.
+func promptBudget() int {
+	budget := 0
+	for _, name := range appConfig.Provider {
+		n := appConfig.Budget.MaxTokensFor(name, appConfig.ModelForProvider(name))
+		if budget == 0 || (n > 0 && n < budget) {
+			budget = n
 		}
-		return gemini.New(cfg.Gemini.Model, cfg.LLM.Temperature), nil
+	}
+	return budget
+}
 
+// buildRedactor returns the configured secret redactor, or nil when
+// redaction is disabled.
+func buildRedactor(cfg config.RedactConfig) (workflow.Redactor, error) {
+	if cfg.Enabled == nil || !*cfg.Enabled {
+		return nil, nil
+	}
+
+	opts := redact.Options{Entropy: cfg.Entropy != nil && *cfg.Entropy}
+	for _, p := range cfg.Patterns {
+		pattern, err := redact.CompilePattern(p.Name, p.Regex)
+		if err != nil {
+			return nil, err
+		}
+		opts.Patterns = append(opts.Patterns, pattern)
+	}
+
+	return redact.New(opts), nil
+}
+
+// hostingPlatform opens pull requests and reads issues.
+type hostingPlatform interface {
+	workflow.Platform
+	workflow.IssueTracker
+}
+
+// buildPlatform returns the platform hosting the repository: the one named
+// by the platform setting, or else the one detected from the origin remote.
+// GitHub is the default when there is no origin.
+func buildPlatform(ctx context.Context, out io.Writer) (hostingPlatform, error) {
+	var remote platform.Remote
+	remoteURL, err := git.RemoteURL(ctx, "origin")
+	if err == nil {
+		remote, err = platform.ParseRemote(remoteURL)
+	}
+	hasRemote := err == nil
+
+	name := appConfig.Platform
+	if name == "" && hasRemote {
+		name = platform.Detect(remote, appConfig.GitLab.BaseURL)
+	}
+
+	switch name {
+	case "", platform.GitHubName:
+		return platform.GitHub{Out: out}, nil
+	case platform.GitLabName:
+		if !hasRemote {
+			return nil, fmt.Errorf("gitlab project: %w", err)
+		}
+		baseURL := appConfig.GitLab.BaseURL
+		if baseURL == "" {
+			baseURL = remote.WebURL()
+		}
+		return platform.GitLab{
+			Out:     out,
+			BaseURL: baseURL,
+			Project: remote.Path,
+			Token:   appConfig.GitLab.Token,
+		}, nil
 	default:
-		return nil, fmt.Errorf("unknown provider: %q", cfg.Provider)
+		return nil, fmt.Errorf("unknown platform %q (expected %q or %q)", name, platform.GitHubName, platform.GitLabName)
+	}
+}
+
+// streamOutput reports whether generated text should be rendered as it
+// arrives instead of behind a spinner.
+func streamOutput(streams *ui.IOStreams) bool {
+	return appConfig.LLM.Stream != nil && *appConfig.LLM.Stream && streams.IsTerminal() && !streams.JSON()
+}
+
+// prompter returns streams when the user can answer questions, and nil
+// otherwise so workflows fall back to non-interactive behavior.
+func prompter(streams *ui.IOStreams) workflow.Prompter {
+	if !streams.CanPrompt() {
+		return nil
+	}
+	return streams
+}
+
+// addDryRunFlags registers --dry-run and its --print alias on cmd.
+func addDryRunFlags(cmd *cobra.Command, what string) {
+	cmd.Flags().
+		Bool(dryRunFlagName, false, "Print the generated "+what+" to stdout instead of applying it")
+
+	cmd.Flags().
+		Bool(printFlagName, false, "Alias for --"+dryRunFlagName)
+}
+
+// isDryRun reports whether either --dry-run or --print is set.
+func isDryRun(cmd *cobra.Command) (bool, error) {
+	for _, name := range []string{dryRunFlagName, printFlagName} {
+		v, err := cmd.Flags().GetBool(name)
+		if err != nil {
+			return false, fmt.Errorf("get %s flag: %w", name, err)
+		}
+		if v {
+			return true, nil
+		}
 	}
+	return false, nil
 }
 
 func repoRootDir() (string, error) {
@@ -0,0 +1,82 @@
+/*
+Copyright © 2025 Arthur Mariano
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/arthvm/ditto/internal/vcs"
+	"github.com/arthvm/ditto/internal/workflow"
+)
+
+const yesFlagName = "yes"
+
+var splitCmd = &cobra.Command{
+	Use:   "split",
+	Short: "Split staged changes into several commits with generated messages",
+	Long: `Ask the model to group the hunks of the staged changes into logical
+commits, show the plan, and create the commits one after the other. When
+nothing is staged, the unstaged changes to tracked files are split.
+
+The commits are built by resetting the index to HEAD and staging each
+group in turn. If anything fails, HEAD and the index are put back as they
+were; the working tree is never modified.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		additionalPrompt, err := cmd.Flags().GetString(promptFlagName)
+		if err != nil {
+			return fmt.Errorf("get prompt flag: %w", err)
+		}
+
+		issues, err := cmd.Flags().GetStringSlice(issuesFlagName)
+		if err != nil {
+			return fmt.Errorf("get issues flag: %w", err)
+		}
+
+		yes, err := cmd.Flags().GetBool(yesFlagName)
+		if err != nil {
+			return fmt.Errorf("get yes flag: %w", err)
+		}
+
+		dryRun, err := isDryRun(cmd)
+		if err != nil {
+			return err
+		}
+
+		if !yes && !dryRun && prompter(streams) == nil {
+			return fmt.Errorf("confirming the plan requires an interactive terminal; pass --%s or --%s", yesFlagName, dryRunFlagName)
+		}
+
+		started := time.Now()
+		res, err := workflow.Split(cmd.Context(), workflow.SplitDeps{
+			VCS:             vcs.Git{Ignore: appConfig.Ignore, Out: streams.HumanOut()},
+			Provider:        provider,
+			Progress:        streams,
+			GenerateTimeout: appConfig.LLM.Timeout,
+			Redactor:        redactor,
+			Prompter:        prompter(streams),
+		}, workflow.SplitParams{
+			SystemPrompt:      appConfig.Commit.Prompt,
+			AdditionalContext: additionalPrompt,
+			Issues:            issues,
+			MaxPromptTokens:   promptBudget(),
+			Lint:              commitLint(),
+			Yes:               yes,
+			DryRun:            dryRun,
+		})
+		return writeSplitResult(res, dryRun, started, err)
+	},
+}
+
+func init() {
+	splitCmd.Flags().
+		BoolP(yesFlagName, "y", false, "Create the commits without asking for confirmation")
+
+	addDryRunFlags(splitCmd, "commit plan")
+
+	rootCmd.AddCommand(splitCmd)
+}
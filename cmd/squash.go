@@ -0,0 +1,88 @@
+/*
+Copyright © 2025 Arthur Mariano
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/arthvm/ditto/internal/vcs"
+	"github.com/arthvm/ditto/internal/workflow"
+)
+
+const applyFlagName = "apply"
+
+var squashCmd = &cobra.Command{
+	Use:   "squash",
+	Short: "Generate one commit message for all the commits of a branch",
+	Long: `Generate a single commit message summarizing every commit between the
+base branch and HEAD, from their messages and the combined diff. The
+message follows the commit convention (commit.prompt) and is printed to
+stdout, ready to paste into a squash merge.
+
+With --apply, the branch is soft-reset to where it forked from the base
+branch and the changes are committed again as one commit with the
+generated message.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		baseBranch := appConfig.BaseBranch
+		if cmd.Flags().Changed(baseBranchFlag) {
+			baseBranch, _ = cmd.Flags().GetString(baseBranchFlag)
+		}
+
+		additionalPrompt, err := cmd.Flags().GetString(promptFlagName)
+		if err != nil {
+			return fmt.Errorf("get prompt flag: %w", err)
+		}
+
+		issues, err := cmd.Flags().GetStringSlice(issuesFlagName)
+		if err != nil {
+			return fmt.Errorf("get issues flag: %w", err)
+		}
+
+		apply, err := cmd.Flags().GetBool(applyFlagName)
+		if err != nil {
+			return fmt.Errorf("get apply flag: %w", err)
+		}
+
+		started := time.Now()
+		res, err := workflow.Squash(cmd.Context(), workflow.SquashDeps{
+			VCS:             vcs.Git{Ignore: appConfig.Ignore, Out: streams.HumanOut()},
+			Provider:        provider,
+			Progress:        streams,
+			GenerateTimeout: appConfig.LLM.Timeout,
+			// Without --apply the message is printed once it is complete.
+			Stream:   streamOutput(streams) && apply,
+			Redactor: redactor,
+		}, workflow.SquashParams{
+			BaseBranch:        baseBranch,
+			Edit:              appConfig.Commit.Edit != nil && *appConfig.Commit.Edit,
+			SystemPrompt:      appConfig.Commit.Prompt,
+			AdditionalContext: additionalPrompt,
+			Issues:            issues,
+			MaxPromptTokens:   promptBudget(),
+			GeneratedFiles:    appConfig.Budget.Generated,
+			MapReduce: workflow.MapReduceParams{
+				Enabled:     appConfig.Budget.MapReduce.Enabled,
+				Concurrency: appConfig.Budget.MapReduce.Concurrency,
+				ChunkTokens: appConfig.Budget.MapReduce.ChunkTokens,
+			},
+			Lint:  commitLint(),
+			Apply: apply,
+		})
+		return writeSquashResult(res, apply, started, err)
+	},
+}
+
+func init() {
+	squashCmd.Flags().
+		String(baseBranchFlag, "", "The branch the commits will be squashed into")
+
+	squashCmd.Flags().
+		Bool(applyFlagName, false, "Replace the commits with a single commit using the generated message")
+
+	rootCmd.AddCommand(squashCmd)
+}
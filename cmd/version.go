@@ -0,0 +1,160 @@
+/*
+Copyright © 2025 Arthur Mariano
+*/
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/arthvm/ditto/internal/changelog"
+	"github.com/arthvm/ditto/internal/semver"
+	"github.com/arthvm/ditto/internal/vcs"
+)
+
+const (
+	prefixFlagName = "prefix"
+	preFlagName    = "pre"
+	tagFlagName    = "tag"
+)
+
+// versionReport is the document printed by ditto version next --output json.
+type versionReport struct {
+	Command string `json:"command"`
+	// Current is the latest release tag, empty when there is none.
+	Current string `json:"current,omitempty"`
+	Next    string `json:"next"`
+	Tag     string `json:"tag"`
+	Bump    string `json:"bump"`
+	Commits int    `json:"commits"`
+	Tagged  bool   `json:"tagged"`
+}
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Work with the release versions of the repository",
+}
+
+var versionNextCmd = &cobra.Command{
+	Use:   "next",
+	Short: "Compute the next version from the commits since the latest release",
+	Long: `Compute the next semantic version from the Conventional Commits since
+the latest release tag reachable from HEAD, without calling a model.
+Breaking changes bump the major version, feat commits the minor version
+and any other commit the patch version. Without a release tag, the bump
+starts from 0.0.0.
+
+Tags are the version prefixed by version.tag_prefix ("v" by default),
+which --prefix overrides, such as "svc-a/v" for svc-a/v1.2.3 in a
+monorepo. Pre-release tags are not releases: the bump starts from the
+latest tag without a pre-release part.
+
+--pre rc makes a pre-release of the next version, numbered after the
+existing ones: v1.3.0-rc.1, then v1.3.0-rc.2.
+
+The tag name is printed to stdout. With --tag, an annotated tag listing
+the notable changes is also created on HEAD.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prefix := appConfig.Version.TagPrefix
+		if cmd.Flags().Changed(prefixFlagName) {
+			prefix, _ = cmd.Flags().GetString(prefixFlagName)
+		}
+
+		pre, err := cmd.Flags().GetString(preFlagName)
+		if err != nil {
+			return fmt.Errorf("get pre flag: %w", err)
+		}
+
+		tag, err := cmd.Flags().GetBool(tagFlagName)
+		if err != nil {
+			return fmt.Errorf("get tag flag: %w", err)
+		}
+
+		git := vcs.Git{}
+		names, err := git.Tags(cmd.Context(), "HEAD")
+		if err != nil {
+			return fmt.Errorf("list tags: %w", err)
+		}
+		tags := semver.Tags(names, prefix)
+
+		report := versionReport{Command: "version next"}
+		var current semver.Version
+		revRange := "HEAD"
+		for _, t := range tags {
+			if t.Version.Pre == "" {
+				report.Current, current = t.Name, t.Version
+				revRange = t.Name + "..HEAD"
+				break
+			}
+		}
+
+		logged, err := git.Messages(cmd.Context(), revRange)
+		if err != nil {
+			return fmt.Errorf("get commits: %w", err)
+		}
+		if len(logged) == 0 {
+			return fmt.Errorf("no commits since %s", report.Current)
+		}
+		report.Commits = len(logged)
+
+		commits := make([]changelog.Commit, len(logged))
+		for i, c := range logged {
+			commits[i] = changelog.Commit{Hash: c.Hash, Message: c.Message}
+		}
+		groups := changelog.Group(commits)
+
+		level := groups.Bump()
+		next := current.Bump(level)
+		if pre != "" {
+			existing := make([]semver.Version, len(tags))
+			for i, t := range tags {
+				existing[i] = t.Version
+			}
+			next = semver.NextPre(next, pre, existing)
+			if _, err := semver.Parse(next.String()); err != nil {
+				return fmt.Errorf("invalid --%s %q: %w", preFlagName, pre, err)
+			}
+		}
+
+		report.Next = next.String()
+		report.Tag = prefix + report.Next
+		report.Bump = level.String()
+
+		if tag {
+			msg := "Release " + report.Tag + "\n"
+			if groups.Len() > 0 {
+				msg += "\n" + groups.String()
+			}
+			if err := git.CreateTag(cmd.Context(), report.Tag, msg); err != nil {
+				return fmt.Errorf("create tag: %w", err)
+			}
+			report.Tagged = true
+		}
+
+		if streams.JSON() {
+			return writeJSON(report)
+		}
+		if report.Tagged {
+			fmt.Fprintf(streams.HumanOut(), "Created tag %s (%s bump from %s)\n", report.Tag, report.Bump, cmp.Or(report.Current, "0.0.0"))
+		}
+		fmt.Fprintln(streams.Out, report.Tag)
+		return nil
+	},
+}
+
+func init() {
+	versionNextCmd.Flags().
+		String(prefixFlagName, "", `The prefix of version tags (default: version.tag_prefix, "v")`)
+
+	versionNextCmd.Flags().
+		String(preFlagName, "", "Make a pre-release with this identifier, such as rc or beta")
+
+	versionNextCmd.Flags().
+		Bool(tagFlagName, false, "Create an annotated tag for the version on HEAD")
+
+	versionCmd.AddCommand(versionNextCmd)
+	rootCmd.AddCommand(versionCmd)
+}
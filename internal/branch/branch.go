@@ -0,0 +1,126 @@
+// Package branch names branches after a pattern such as
+// "{type}/{issue}-{slug}".
+package branch
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Fields are the values of the placeholders of a pattern.
+type Fields struct {
+	Type  string
+	Issue string
+	Slug  string
+}
+
+var (
+	placeholderRE = regexp.MustCompile(`\{([^{}]*)\}`)
+	separatorsRE  = regexp.MustCompile(`[-_.]*([-_.])[-_.]*`)
+	nonSlugRE     = regexp.MustCompile(`[^a-z0-9]+`)
+	nonIssueRE    = regexp.MustCompile(`[^A-Za-z0-9-]+`)
+)
+
+var placeholders = []string{"type", "issue", "slug"}
+
+// Validate checks that pattern only uses known placeholders and contains
+// {slug}.
+func Validate(pattern string) error {
+	for _, match := range placeholderRE.FindAllStringSubmatch(pattern, -1) {
+		if !slices.Contains(placeholders, match[1]) {
+			return fmt.Errorf("unknown placeholder %s in %q, use {%s}", match[0], pattern, strings.Join(placeholders, "}, {"))
+		}
+	}
+	if !strings.Contains(pattern, "{slug}") {
+		return fmt.Errorf("pattern %q must contain {slug}", pattern)
+	}
+	return nil
+}
+
+// Name renders pattern with f. Placeholders without a value are dropped
+// along with the separators around them, so "{type}/{issue}-{slug}" gives
+// "feat/add-login" without an issue. When the name is longer than
+// maxLength, words are removed from the end of the slug; zero disables the
+// limit.
+func Name(pattern string, f Fields, maxLength int) string {
+	words := strings.Split(Slugify(f.Slug), "-")
+	for {
+		name := render(pattern, f, strings.Join(words, "-"))
+		if maxLength <= 0 || len(name) <= maxLength || len(words) == 1 {
+			return name
+		}
+		words = words[:len(words)-1]
+	}
+}
+
+func render(pattern string, f Fields, slug string) string {
+	values := map[string]string{
+		"type":  Slugify(f.Type),
+		"issue": issue(f.Issue),
+		"slug":  slug,
+	}
+	name := placeholderRE.ReplaceAllStringFunc(pattern, func(p string) string {
+		return values[p[1:len(p)-1]]
+	})
+
+	var segments []string
+	for segment := range strings.SplitSeq(name, "/") {
+		segment = separatorsRE.ReplaceAllString(segment, "$1")
+		if segment = strings.Trim(segment, "-_."); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Slugify lowercases s and turns it into words of letters and digits
+// joined by dashes.
+func Slugify(s string) string {
+	return strings.Trim(nonSlugRE.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+// issue drops the '#' of GitHub references and anything else that does not
+// belong in a branch name. Keys such as PROJ-42 keep their case.
+func issue(s string) string {
+	return strings.Trim(nonIssueRE.ReplaceAllString(s, ""), "-")
+}
+
+// Issues returns the issue IDs patterns find in name, in the order they
+// appear, without duplicates. A pattern with a capture group contributes
+// its first group, others their whole match.
+func Issues(name string, patterns []string) ([]string, error) {
+	type found struct {
+		pos int
+		id  string
+	}
+
+	var all []found
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("issue pattern %q: %w", p, err)
+		}
+
+		group := 0
+		if re.NumSubexp() > 0 {
+			group = 1
+		}
+		for _, loc := range re.FindAllStringSubmatchIndex(name, -1) {
+			if start, end := loc[2*group], loc[2*group+1]; start >= 0 && end > start {
+				all = append(all, found{pos: start, id: name[start:end]})
+			}
+		}
+	}
+	slices.SortStableFunc(all, func(a, b found) int { return cmp.Compare(a.pos, b.pos) })
+
+	var issues []string
+	for _, f := range all {
+		if !slices.Contains(issues, f.id) {
+			issues = append(issues, f.id)
+		}
+	}
+	return issues, nil
+}
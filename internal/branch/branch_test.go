@@ -0,0 +1,98 @@
+package branch_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/arthvm/ditto/internal/branch"
+)
+
+const pattern = "{type}/{issue}-{slug}"
+
+// issuePatterns find tracker keys such as PROJ-123 and numbers that start
+// a segment of the name, as in feature/456-foo.
+var issuePatterns = []string{
+	`[A-Z][A-Z0-9]+-\d+`,
+	`(?:^|/)(\d+)(?:[-_/]|$)`,
+}
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		name      string
+		pattern   string
+		fields    branch.Fields
+		maxLength int
+		want      string
+	}{
+		{
+			name:    "default pattern",
+			pattern: pattern,
+			fields:  branch.Fields{Type: "feat", Issue: "#123", Slug: "Add login page"},
+			want:    "feat/123-add-login-page",
+		},
+		{
+			name:    "without issue",
+			pattern: pattern,
+			fields:  branch.Fields{Type: "fix", Slug: "crash-on-empty-diff"},
+			want:    "fix/crash-on-empty-diff",
+		},
+		{
+			name:    "without type",
+			pattern: "{type}/{issue}_{slug}",
+			fields:  branch.Fields{Issue: "PROJ-42", Slug: "rename keys"},
+			want:    "PROJ-42_rename-keys",
+		},
+		{
+			name:    "custom pattern",
+			pattern: "users/jane/{slug}.{issue}",
+			fields:  branch.Fields{Issue: "7", Slug: "Tidy up  README!"},
+			want:    "users/jane/tidy-up-readme.7",
+		},
+		{
+			name:      "shortened slug",
+			pattern:   pattern,
+			fields:    branch.Fields{Type: "feat", Issue: "12", Slug: "refresh tokens before they expire to avoid logins"},
+			maxLength: 30,
+			want:      "feat/12-refresh-tokens-before",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, branch.Name(tt.pattern, tt.fields, tt.maxLength))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, branch.Validate(pattern))
+	assert.ErrorContains(t, branch.Validate("{type}/{ticket}-{slug}"), "unknown placeholder {ticket}")
+	assert.ErrorContains(t, branch.Validate("{type}/{issue}"), "must contain {slug}")
+}
+
+func TestIssues(t *testing.T) {
+	tests := []struct {
+		name     string
+		branch   string
+		patterns []string
+		want     []string
+	}{
+		{name: "number", branch: "feature/456-foo", patterns: issuePatterns, want: []string{"456"}},
+		{name: "key", branch: "PROJ-123-add-login", patterns: issuePatterns, want: []string{"PROJ-123"}},
+		{name: "several", branch: "fix/12-ABC-7-and-12", patterns: issuePatterns, want: []string{"12", "ABC-7"}},
+		{name: "none", branch: "release/1.2", patterns: issuePatterns},
+		{name: "custom pattern", branch: "gh-99/tidy", patterns: []string{`gh-(\d+)`}, want: []string{"99"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := branch.Issues(tt.branch, tt.patterns)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, issues)
+		})
+	}
+
+	_, err := branch.Issues("main", []string{"("})
+	assert.Error(t, err)
+}
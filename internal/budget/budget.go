@@ -0,0 +1,322 @@
+// Package budget keeps prompts within a model's context window by
+// shrinking diffs in increasingly lossy steps.
+package budget
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/arthvm/ditto/internal/git"
+)
+
+// charsPerToken is a deliberately conservative average for code: real
+// tokenizers produce fewer tokens for English prose and more for dense
+// symbols, so estimates err on the side of a smaller prompt.
+const charsPerToken = 3
+
+const (
+	// Hunks longer than this are collapsed to their edges in the second step.
+	maxHunkLines = 60
+	// Lines kept at the start and end of a collapsed hunk.
+	collapsedHead = 20
+	collapsedTail = 10
+)
+
+// DefaultGenerated lists lockfiles and generated files that carry little
+// meaning for a commit message. Patterns are matched against the base name
+// and the full path.
+var DefaultGenerated = []string{
+	"go.sum",
+	"package-lock.json",
+	"npm-shrinkwrap.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"bun.lockb",
+	"Cargo.lock",
+	"Gemfile.lock",
+	"composer.lock",
+	"poetry.lock",
+	"Pipfile.lock",
+	"uv.lock",
+	"*.min.js",
+	"*.min.css",
+	"*.map",
+	"*.pb.go",
+	"*_pb2.py",
+	"*.generated.*",
+}
+
+// EstimateTokens approximates the number of tokens in s.
+func EstimateTokens(s string) int {
+	return (len(s) + charsPerToken - 1) / charsPerToken
+}
+
+// Options configures Fit.
+type Options struct {
+	// MaxTokens is the budget for the diff alone. Zero disables budgeting.
+	MaxTokens int
+	// Generated are extra patterns for files dropped in the first step, on
+	// top of DefaultGenerated.
+	Generated []string
+}
+
+// Result is the outcome of Fit.
+type Result struct {
+	Diff string
+	// Steps describes every reduction applied, in order. It is empty when the
+	// diff fit as-is.
+	Steps []string
+	// Fits reports whether Diff is within the budget. It can be false when
+	// even the file summary alone is too large.
+	Fits bool
+	// Summarized reports whether the last, lossy step was needed: Diff then
+	// only contains a selection of the hunks.
+	Summarized bool
+}
+
+// Fit returns diff unchanged when it fits within opts.MaxTokens. Otherwise it
+// degrades it step by step until it does:
+//  1. drop lockfiles and generated files, keeping a one-line note for each;
+//  2. collapse long hunks to their first and last lines;
+//  3. replace the diff with a per-file summary plus the hunks with the most
+//     changed lines that still fit.
+func Fit(diff string, opts Options) Result {
+	if opts.MaxTokens <= 0 || EstimateTokens(diff) <= opts.MaxTokens {
+		return Result{Diff: diff, Fits: true}
+	}
+
+	var steps []string
+	files := git.ParsePatch(diff)
+	patterns := append(append([]string{}, DefaultGenerated...), opts.Generated...)
+
+	var dropped []string
+	for i, f := range files {
+		if isGenerated(f, patterns) {
+			files[i] = omitFile(f)
+			dropped = append(dropped, f.Path())
+		}
+	}
+	if len(dropped) > 0 {
+		steps = append(steps, fmt.Sprintf("omitted generated files: %s", strings.Join(dropped, ", ")))
+		if out := render(files); EstimateTokens(out) <= opts.MaxTokens {
+			return Result{Diff: out, Steps: steps, Fits: true}
+		}
+	}
+
+	collapsed := 0
+	for i := range files {
+		for j, h := range files[i].Hunks {
+			if len(h.Lines) > maxHunkLines {
+				files[i].Hunks[j] = collapseHunk(h)
+				collapsed++
+			}
+		}
+	}
+	if collapsed > 0 {
+		steps = append(steps, fmt.Sprintf("collapsed %d long hunks", collapsed))
+		if out := render(files); EstimateTokens(out) <= opts.MaxTokens {
+			return Result{Diff: out, Steps: steps, Fits: true}
+		}
+	}
+
+	out, kept, total := summarize(files, opts.MaxTokens)
+	steps = append(steps, fmt.Sprintf("kept %d of %d hunks with a per-file summary", kept, total))
+
+	return Result{
+		Diff:       out,
+		Steps:      steps,
+		Fits:       EstimateTokens(out) <= opts.MaxTokens,
+		Summarized: true,
+	}
+}
+
+// Split groups the files of diff into chunks of at most opts.MaxTokens so
+// that each can be processed separately. Small files are packed together,
+// files larger than the budget are split between hunks, and hunks that are
+// too large on their own are collapsed. Generated files are reduced to a
+// one-line note as in Fit.
+func Split(diff string, opts Options) []string {
+	files := git.ParsePatch(diff)
+	patterns := append(append([]string{}, DefaultGenerated...), opts.Generated...)
+
+	var chunks []string
+	var cur strings.Builder
+	add := func(part string) {
+		if cur.Len() > 0 && EstimateTokens(cur.String()+part) > opts.MaxTokens {
+			chunks = append(chunks, cur.String())
+			cur.Reset()
+		}
+		cur.WriteString(part)
+	}
+
+	for _, f := range files {
+		if isGenerated(f, patterns) {
+			f = omitFile(f)
+		}
+
+		if out := f.String(); EstimateTokens(out) <= opts.MaxTokens {
+			add(out)
+			continue
+		}
+
+		header := git.FileDiff{OldPath: f.OldPath, NewPath: f.NewPath, Header: f.Header}
+		part := header
+		for _, h := range f.Hunks {
+			if EstimateTokens(header.String()+h.String()) > opts.MaxTokens &&
+				len(h.Lines) > collapsedHead+collapsedTail {
+				h = collapseHunk(h)
+			}
+
+			next := part
+			next.Hunks = append(append([]git.Hunk{}, part.Hunks...), h)
+			if len(part.Hunks) > 0 && EstimateTokens(next.String()) > opts.MaxTokens {
+				add(part.String())
+				next = header
+				next.Hunks = []git.Hunk{h}
+			}
+			part = next
+		}
+		add(part.String())
+	}
+
+	if cur.Len() > 0 {
+		chunks = append(chunks, cur.String())
+	}
+
+	return chunks
+}
+
+// Stat renders a summary similar to git diff --stat for the given files.
+func Stat(files []git.FileDiff) string {
+	var b strings.Builder
+	for _, f := range files {
+		added, removed := f.Stats()
+		switch {
+		case f.Binary:
+			fmt.Fprintf(&b, " %s | binary\n", f.Path())
+		case f.IsNew():
+			fmt.Fprintf(&b, " %s | +%d (new file)\n", f.Path(), added)
+		case f.IsDeleted():
+			fmt.Fprintf(&b, " %s | -%d (deleted)\n", f.Path(), removed)
+		default:
+			fmt.Fprintf(&b, " %s | +%d -%d\n", f.Path(), added, removed)
+		}
+	}
+	return b.String()
+}
+
+func isGenerated(f git.FileDiff, patterns []string) bool {
+	p := f.Path()
+	base := path.Base(p)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+
+	// Honor the Go convention for generated sources.
+	for _, h := range f.Hunks {
+		for _, line := range h.Lines {
+			if strings.HasPrefix(line, "+// Code generated ") && strings.HasSuffix(line, "DO NOT EDIT.") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// omitFile keeps the header of f and replaces its hunks with a note.
+func omitFile(f git.FileDiff) git.FileDiff {
+	added, removed := f.Stats()
+	header := append(append([]string{}, f.Header...),
+		fmt.Sprintf("[generated file omitted: +%d -%d lines]", added, removed))
+	return git.FileDiff{
+		OldPath: f.OldPath,
+		NewPath: f.NewPath,
+		Header:  header,
+		Binary:  f.Binary,
+	}
+}
+
+func collapseHunk(h git.Hunk) git.Hunk {
+	omitted := len(h.Lines) - collapsedHead - collapsedTail
+	lines := make([]string, 0, collapsedHead+collapsedTail+1)
+	lines = append(lines, h.Lines[:collapsedHead]...)
+	lines = append(lines, fmt.Sprintf(" [... %d lines omitted ...]", omitted))
+	lines = append(lines, h.Lines[len(h.Lines)-collapsedTail:]...)
+	return git.Hunk{Header: h.Header, Lines: lines}
+}
+
+func render(files []git.FileDiff) string {
+	var b strings.Builder
+	for _, f := range files {
+		b.WriteString(f.String())
+	}
+	return b.String()
+}
+
+type hunkRef struct {
+	file, hunk int
+	changed    int
+	tokens     int
+}
+
+// summarize renders the stat of every file followed by as many hunks as fit,
+// preferring hunks with more changed lines, in their original order.
+func summarize(files []git.FileDiff, maxTokens int) (string, int, int) {
+	stat := "--- CHANGED FILES ---\n" + Stat(files) + "--- SELECTED HUNKS ---\n"
+	remaining := maxTokens - EstimateTokens(stat)
+
+	var refs []hunkRef
+	for i, f := range files {
+		// File headers are accounted for with their first hunk.
+		headerTokens := EstimateTokens(strings.Join(f.Header, "\n"))
+		for j, h := range f.Hunks {
+			added, removed := h.Stats()
+			refs = append(refs, hunkRef{
+				file:    i,
+				hunk:    j,
+				changed: added + removed,
+				tokens:  EstimateTokens(h.String()) + headerTokens,
+			})
+		}
+	}
+
+	ranked := append([]hunkRef{}, refs...)
+	sort.SliceStable(ranked, func(a, b int) bool { return ranked[a].changed > ranked[b].changed })
+
+	selected := make(map[[2]int]bool)
+	for _, r := range ranked {
+		if r.tokens <= remaining {
+			selected[[2]int{r.file, r.hunk}] = true
+			remaining -= r.tokens
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(stat)
+	for i, f := range files {
+		wroteHeader := false
+		for j, h := range f.Hunks {
+			if !selected[[2]int{i, j}] {
+				continue
+			}
+			if !wroteHeader {
+				for _, line := range f.Header {
+					b.WriteString(line)
+					b.WriteByte('\n')
+				}
+				wroteHeader = true
+			}
+			b.WriteString(h.String())
+		}
+	}
+
+	return b.String(), len(selected), len(refs)
+}
@@ -0,0 +1,105 @@
+package budget_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/budget"
+)
+
+func fileDiff(path string, lines int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\nindex 1111111..2222222 100644\n--- a/%s\n+++ b/%s\n", path, path, path, path)
+	fmt.Fprintf(&b, "@@ -1,%d +1,%d @@\n", lines, lines)
+	for i := range lines {
+		fmt.Fprintf(&b, "+line %d of %s\n", i, path)
+	}
+	return b.String()
+}
+
+func TestFitUnchangedWhenWithinBudget(t *testing.T) {
+	diff := fileDiff("main.go", 5)
+
+	res := budget.Fit(diff, budget.Options{MaxTokens: 10000})
+	assert.True(t, res.Fits)
+	assert.Empty(t, res.Steps)
+	assert.Equal(t, diff, res.Diff)
+}
+
+func TestFitDropsGeneratedFiles(t *testing.T) {
+	diff := fileDiff("main.go", 5) + fileDiff("go.sum", 2000) + fileDiff("web/package-lock.json", 2000)
+
+	res := budget.Fit(diff, budget.Options{MaxTokens: 1000})
+	require.True(t, res.Fits)
+	require.Len(t, res.Steps, 1)
+	assert.Contains(t, res.Steps[0], "go.sum, web/package-lock.json")
+	assert.Contains(t, res.Diff, "+line 4 of main.go")
+	assert.Contains(t, res.Diff, "[generated file omitted: +2000 -0 lines]")
+	assert.NotContains(t, res.Diff, "of go.sum")
+}
+
+func TestFitHonorsExtraGeneratedPatterns(t *testing.T) {
+	diff := fileDiff("main.go", 5) + fileDiff("__snapshots__/app.snap", 2000)
+
+	res := budget.Fit(diff, budget.Options{MaxTokens: 1000, Generated: []string{"*.snap"}})
+	assert.True(t, res.Fits)
+	assert.NotContains(t, res.Diff, "of __snapshots__/app.snap")
+}
+
+func TestFitCollapsesLongHunks(t *testing.T) {
+	diff := fileDiff("main.go", 300)
+
+	res := budget.Fit(diff, budget.Options{MaxTokens: 1000})
+	require.True(t, res.Fits)
+	assert.Equal(t, []string{"collapsed 1 long hunks"}, res.Steps)
+	assert.Contains(t, res.Diff, "[... 270 lines omitted ...]")
+	assert.Contains(t, res.Diff, "+line 0 of main.go")
+	assert.Contains(t, res.Diff, "+line 299 of main.go")
+}
+
+func TestFitFallsBackToSummary(t *testing.T) {
+	var diff string
+	for i := range 40 {
+		diff += fileDiff(fmt.Sprintf("pkg/file%02d.go", i), 50)
+	}
+	diff += fileDiff("pkg/important.go", 55)
+
+	res := budget.Fit(diff, budget.Options{MaxTokens: 1500})
+	require.True(t, res.Fits)
+	assert.Contains(t, res.Steps[len(res.Steps)-1], "with a per-file summary")
+	assert.Contains(t, res.Diff, " pkg/file39.go | +50 -0\n")
+	assert.Contains(t, res.Diff, "+line 0 of pkg/important.go")
+}
+
+func TestSplit(t *testing.T) {
+	diff := fileDiff("a.go", 10) + fileDiff("b.go", 10) + fileDiff("go.sum", 500)
+
+	big := "diff --git a/big.go b/big.go\nindex 1111111..2222222 100644\n--- a/big.go\n+++ b/big.go\n"
+	for i := range 4 {
+		big += fmt.Sprintf("@@ -%d,20 +%d,20 @@\n", i*100+1, i*100+1)
+		for j := range 20 {
+			big += fmt.Sprintf("+hunk %d line %d of big.go\n", i, j)
+		}
+	}
+	diff += big
+
+	chunks := budget.Split(diff, budget.Options{MaxTokens: 400})
+	require.Len(t, chunks, 3)
+
+	// Small files and the generated file note are packed together.
+	assert.Contains(t, chunks[0], "+line 9 of a.go")
+	assert.Contains(t, chunks[0], "+line 9 of b.go")
+	assert.Contains(t, chunks[0], "[generated file omitted: +500 -0 lines]")
+
+	// The large file is split between hunks, repeating its header.
+	for _, c := range chunks[1:] {
+		assert.True(t, strings.HasPrefix(c, "diff --git a/big.go b/big.go\n"))
+		assert.LessOrEqual(t, budget.EstimateTokens(c), 400)
+	}
+	assert.Contains(t, chunks[1], "+hunk 0 line 0")
+	assert.Contains(t, chunks[2], "+hunk 3 line 19")
+}
@@ -0,0 +1,316 @@
+// Package changelog groups commits into release notes and writes them in
+// the Keep a Changelog format (https://keepachangelog.com).
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/arthvm/ditto/internal/conventional"
+	"github.com/arthvm/ditto/internal/semver"
+)
+
+// Preamble starts a new changelog file.
+const Preamble = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),
+and this project adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).
+`
+
+// Commit is a commit and its full message.
+type Commit struct {
+	Hash    string
+	Message string
+}
+
+// Change is a commit worth telling users about.
+type Change struct {
+	Hash        string
+	Scope       string
+	Description string
+	Body        string
+	// Breaking describes the breaking change the commit introduces, if any.
+	Breaking string
+}
+
+// Groups are the notable changes of a release by Conventional Commit type.
+type Groups struct {
+	Breaking    []Change
+	Features    []Change
+	Fixes       []Change
+	Performance []Change
+}
+
+// Group sorts commits by type. Breaking changes are grouped together
+// whatever their type; commits of other types than feat, fix and perf, and
+// messages that do not follow the convention, are left out.
+func Group(commits []Commit) Groups {
+	var g Groups
+	for _, c := range commits {
+		m := conventional.Parse(c.Message)
+		h, ok := m.Header()
+		if !ok {
+			continue
+		}
+
+		change := Change{Hash: c.Hash, Scope: h.Scope, Description: h.Description, Body: m.Body}
+		if desc, ok := m.BreakingChange(); ok {
+			change.Breaking = desc
+			g.Breaking = append(g.Breaking, change)
+			continue
+		}
+
+		switch h.Type {
+		case "feat":
+			g.Features = append(g.Features, change)
+		case "fix":
+			g.Fixes = append(g.Fixes, change)
+		case "perf":
+			g.Performance = append(g.Performance, change)
+		}
+	}
+	return g
+}
+
+// Len returns the number of changes in every group.
+func (g Groups) Len() int {
+	return len(g.Breaking) + len(g.Features) + len(g.Fixes) + len(g.Performance)
+}
+
+// Bump returns the part of the version the changes call for: major for
+// breaking changes, minor for features and patch otherwise.
+func (g Groups) Bump() semver.Level {
+	switch {
+	case len(g.Breaking) > 0:
+		return semver.Major
+	case len(g.Features) > 0:
+		return semver.Minor
+	default:
+		return semver.Patch
+	}
+}
+
+// String renders the groups as Markdown lists, one per non-empty group.
+func (g Groups) String() string {
+	var b strings.Builder
+	for _, group := range []struct {
+		title   string
+		changes []Change
+	}{
+		{"Breaking changes", g.Breaking},
+		{"Features", g.Features},
+		{"Bug fixes", g.Fixes},
+		{"Performance", g.Performance},
+	} {
+		if len(group.changes) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "## %s\n", group.title)
+		for _, c := range group.changes {
+			b.WriteString(c.String())
+		}
+	}
+	return b.String()
+}
+
+// String renders c as a list item, followed by the breaking change and the
+// body indented below it.
+func (c Change) String() string {
+	var b strings.Builder
+	b.WriteString("- ")
+	if c.Scope != "" {
+		fmt.Fprintf(&b, "%s: ", c.Scope)
+	}
+	fmt.Fprintf(&b, "%s (%s)\n", c.Description, shortHash(c.Hash))
+	if c.Breaking != "" {
+		b.WriteString(indent("BREAKING CHANGE: " + c.Breaking))
+	}
+	if c.Body != "" {
+		b.WriteString(indent(c.Body))
+	}
+	return b.String()
+}
+
+// Release holds the notes of one version, by Keep a Changelog section.
+type Release struct {
+	// Version is empty for unreleased changes.
+	Version string `json:"-"`
+	// Date is the release date as YYYY-MM-DD, empty for unreleased changes.
+	Date string `json:"-"`
+	// Breaking entries are listed first under Changed.
+	Breaking   []string `json:"breaking"`
+	Added      []string `json:"added"`
+	Changed    []string `json:"changed"`
+	Deprecated []string `json:"deprecated"`
+	Removed    []string `json:"removed"`
+	Fixed      []string `json:"fixed"`
+	Security   []string `json:"security"`
+}
+
+// Heading returns the second-level heading of the release.
+func (r Release) Heading() string {
+	switch {
+	case r.Version == "":
+		return "## [Unreleased]"
+	case r.Date == "":
+		return fmt.Sprintf("## [%s]", r.Version)
+	default:
+		return fmt.Sprintf("## [%s] - %s", r.Version, r.Date)
+	}
+}
+
+// Empty reports whether the release has no entries.
+func (r Release) Empty() bool {
+	for _, s := range r.sections() {
+		if len(s.entries) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Markdown renders the release as a changelog section.
+func (r Release) Markdown() string {
+	var b strings.Builder
+	b.WriteString(r.Heading())
+	b.WriteString("\n")
+	for _, s := range r.sections() {
+		if len(s.entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", s.title)
+		for _, e := range s.entries {
+			fmt.Fprintf(&b, "- %s\n", e)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+type section struct {
+	title   string
+	entries []string
+}
+
+func (r Release) sections() []section {
+	var changed []string
+	for _, e := range entries(r.Breaking) {
+		changed = append(changed, "**Breaking:** "+e)
+	}
+	changed = append(changed, entries(r.Changed)...)
+
+	return []section{
+		{"Added", entries(r.Added)},
+		{"Changed", changed},
+		{"Deprecated", entries(r.Deprecated)},
+		{"Removed", entries(r.Removed)},
+		{"Fixed", entries(r.Fixed)},
+		{"Security", entries(r.Security)},
+	}
+}
+
+// entries trims list markers and blank entries left by the model.
+func entries(list []string) []string {
+	var out []string
+	for _, e := range list {
+		e = strings.TrimSpace(e)
+		e = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(e, "- "), "* "))
+		if e != "" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+var (
+	headingRE = regexp.MustCompile(`^##\s+\[?([^\]\s]+)\]?`)
+	// linkRE matches the link definitions of the version headings that
+	// usually end a changelog.
+	linkRE = regexp.MustCompile(`^\[[^\]]+\]:\s`)
+)
+
+// Insert adds r to changelog, newest release first. A section for the same
+// version is replaced. Unreleased changes go first; a version goes after
+// them, above the previous versions. An empty changelog gets the Preamble.
+func Insert(changelog string, r Release) string {
+	if strings.TrimSpace(changelog) == "" {
+		return Preamble + "\n" + r.Markdown() + "\n"
+	}
+
+	lines := strings.Split(strings.TrimRight(changelog, "\n"), "\n")
+
+	tail := len(lines)
+	for tail > 0 && (strings.TrimSpace(lines[tail-1]) == "" || linkRE.MatchString(lines[tail-1])) {
+		tail--
+	}
+
+	start, end := -1, -1
+	insert := tail
+	for i := 0; i < tail; i++ {
+		version, ok := headingVersion(lines[i])
+		if !ok {
+			continue
+		}
+		if start >= 0 && end < 0 {
+			end = i
+		}
+		if version == r.Version && start < 0 {
+			start = i
+		}
+		if insert == tail && (r.Version == "" || version != "") {
+			insert = i
+		}
+	}
+	if start < 0 {
+		start, end = insert, insert
+	} else if end < 0 {
+		end = tail
+	}
+
+	var parts []string
+	if before := strings.TrimSpace(strings.Join(lines[:start], "\n")); before != "" {
+		parts = append(parts, before)
+	}
+	parts = append(parts, r.Markdown())
+	if after := strings.TrimSpace(strings.Join(lines[end:], "\n")); after != "" {
+		parts = append(parts, after)
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+// headingVersion returns the version of a release heading, empty for the
+// Unreleased section. ok is false for other lines.
+func headingVersion(line string) (version string, ok bool) {
+	match := headingRE.FindStringSubmatch(line)
+	if match == nil {
+		return "", false
+	}
+	if strings.EqualFold(match[1], "unreleased") {
+		return "", true
+	}
+	return match[1], true
+}
+
+func indent(s string) string {
+	var b strings.Builder
+	for line := range strings.SplitSeq(strings.TrimSpace(s), "\n") {
+		if strings.TrimSpace(line) == "" {
+			b.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+	return b.String()
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
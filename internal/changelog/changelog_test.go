@@ -0,0 +1,200 @@
+package changelog_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/arthvm/ditto/internal/changelog"
+	"github.com/arthvm/ditto/internal/semver"
+)
+
+func TestGroup(t *testing.T) {
+	g := changelog.Group([]changelog.Commit{
+		{Hash: "aaaaaaa1", Message: "feat(auth): add token refresh\n\nTokens are refreshed early."},
+		{Hash: "bbbbbbb2", Message: "fix: handle empty diff"},
+		{Hash: "ccccccc3", Message: "chore: bump deps"},
+		{Hash: "ddddddd4", Message: "perf: cache templates"},
+		{Hash: "eeeeeee5", Message: "refactor(api)!: drop v1\n\nBREAKING CHANGE: clients must use /v2"},
+		{Hash: "fffffff6", Message: "Update README"},
+	})
+
+	assert.Equal(t, 4, g.Len())
+	assert.Equal(t, semver.Major, g.Bump())
+	assert.Equal(t, []changelog.Change{{Hash: "eeeeeee5", Scope: "api", Description: "drop v1", Breaking: "clients must use /v2"}}, g.Breaking)
+	assert.Equal(t, []changelog.Change{{Hash: "aaaaaaa1", Scope: "auth", Description: "add token refresh", Body: "Tokens are refreshed early."}}, g.Features)
+	assert.Len(t, g.Fixes, 1)
+	assert.Len(t, g.Performance, 1)
+
+	assert.Equal(t, `## Breaking changes
+- api: drop v1 (eeeeeee)
+  BREAKING CHANGE: clients must use /v2
+
+## Features
+- auth: add token refresh (aaaaaaa)
+  Tokens are refreshed early.
+
+## Bug fixes
+- handle empty diff (bbbbbbb)
+
+## Performance
+- cache templates (ddddddd)
+`, g.String())
+}
+
+func TestGroupsBump(t *testing.T) {
+	assert.Equal(t, semver.Minor, changelog.Group([]changelog.Commit{{Message: "feat: a"}, {Message: "fix: b"}}).Bump())
+	assert.Equal(t, semver.Patch, changelog.Group([]changelog.Commit{{Message: "docs: a"}}).Bump())
+}
+
+func TestReleaseMarkdown(t *testing.T) {
+	r := changelog.Release{
+		Version:  "1.2.0",
+		Date:     "2024-03-05",
+		Breaking: []string{"The v1 API was removed."},
+		Added:    []string{"- Token refresh.", " "},
+		Fixed:    []string{"Empty diffs no longer crash."},
+	}
+
+	assert.False(t, r.Empty())
+	assert.Equal(t, `## [1.2.0] - 2024-03-05
+
+### Added
+
+- Token refresh.
+
+### Changed
+
+- **Breaking:** The v1 API was removed.
+
+### Fixed
+
+- Empty diffs no longer crash.`, r.Markdown())
+
+	assert.True(t, changelog.Release{Added: []string{""}}.Empty())
+	assert.Equal(t, "## [Unreleased]", changelog.Release{}.Heading())
+}
+
+func TestInsert(t *testing.T) {
+	release := changelog.Release{Version: "1.1.0", Date: "2024-03-05", Fixed: []string{"New fix."}}
+	unreleased := changelog.Release{Added: []string{"Next thing."}}
+
+	existing := `# Changelog
+
+Intro.
+
+## [Unreleased]
+
+### Added
+
+- Old unreleased.
+
+## [1.0.0] - 2024-01-01
+
+### Added
+
+- First release.
+
+[1.0.0]: https://example.com/v1.0.0
+`
+
+	tests := []struct {
+		name    string
+		file    string
+		release changelog.Release
+		want    string
+	}{
+		{
+			name:    "new file",
+			release: release,
+			want:    changelog.Preamble + "\n## [1.1.0] - 2024-03-05\n\n### Fixed\n\n- New fix.\n",
+		},
+		{
+			name:    "version below unreleased",
+			file:    existing,
+			release: release,
+			want: `# Changelog
+
+Intro.
+
+## [Unreleased]
+
+### Added
+
+- Old unreleased.
+
+## [1.1.0] - 2024-03-05
+
+### Fixed
+
+- New fix.
+
+## [1.0.0] - 2024-01-01
+
+### Added
+
+- First release.
+
+[1.0.0]: https://example.com/v1.0.0
+`,
+		},
+		{
+			name:    "replace unreleased",
+			file:    existing,
+			release: unreleased,
+			want: `# Changelog
+
+Intro.
+
+## [Unreleased]
+
+### Added
+
+- Next thing.
+
+## [1.0.0] - 2024-01-01
+
+### Added
+
+- First release.
+
+[1.0.0]: https://example.com/v1.0.0
+`,
+		},
+		{
+			name:    "replace last version before links",
+			file:    existing,
+			release: changelog.Release{Version: "1.0.0", Date: "2024-01-01", Added: []string{"Rewritten."}},
+			want: `# Changelog
+
+Intro.
+
+## [Unreleased]
+
+### Added
+
+- Old unreleased.
+
+## [1.0.0] - 2024-01-01
+
+### Added
+
+- Rewritten.
+
+[1.0.0]: https://example.com/v1.0.0
+`,
+		},
+		{
+			name:    "no releases yet",
+			file:    "# Changelog\n",
+			release: release,
+			want:    "# Changelog\n\n## [1.1.0] - 2024-03-05\n\n### Fixed\n\n- New fix.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, changelog.Insert(tt.file, tt.release))
+		})
+	}
+}
@@ -4,39 +4,112 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Provider   string        `yaml:"provider"`
-	BaseBranch string        `yaml:"base_branch"`
-	LLM        LLMConfig     `yaml:"llm"`
-	Commit     CommitConfig  `yaml:"commit"`
-	PR         PRConfig      `yaml:"pr"`
-	Gemini     GeminiConfig  `yaml:"gemini"`
-	Ollama     OllamaConfig  `yaml:"ollama"`
-	Copilot    CopilotConfig `yaml:"copilot"`
+	Provider   ProviderList    `yaml:"provider"`
+	BaseBranch string          `yaml:"base_branch"`
+	LLM        LLMConfig       `yaml:"llm"`
+	Commit     CommitConfig    `yaml:"commit"`
+	PR         PRConfig        `yaml:"pr"`
+	Changelog  ChangelogConfig `yaml:"changelog"`
+	Version    VersionConfig   `yaml:"version"`
+	Branch     BranchConfig    `yaml:"branch"`
+	Issues     IssuesConfig    `yaml:"issues"`
+	Platform   string          `yaml:"platform"`
+	GitLab     GitLabConfig    `yaml:"gitlab"`
+	Gemini     GeminiConfig    `yaml:"gemini"`
+	Ollama     OllamaConfig    `yaml:"ollama"`
+	Copilot    CopilotConfig   `yaml:"copilot"`
+	OpenAI     OpenAIConfig    `yaml:"openai"`
+	Anthropic  AnthropicConfig `yaml:"anthropic"`
+	Budget     BudgetConfig    `yaml:"budget"`
+	Redact     RedactConfig    `yaml:"redact"`
+	// Ignore lists gitignore-style patterns of paths that are never sent to
+	// the model. Patterns from the repository's .dittoignore are appended.
+	Ignore []string `yaml:"ignore"`
+}
+
+// ProviderList is an ordered list of provider names. The first entry is the
+// primary provider and the rest are tried in turn when it fails. In YAML it
+// may be written as a single name, a comma-separated string or a sequence.
+type ProviderList []string
+
+// ParseProviderList splits a comma-separated list of provider names.
+func ParseProviderList(s string) ProviderList {
+	var list ProviderList
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			list = append(list, name)
+		}
+	}
+	return list
+}
+
+// Primary returns the first provider of the list.
+func (l ProviderList) Primary() string {
+	if len(l) == 0 {
+		return ""
+	}
+	return l[0]
+}
+
+func (l ProviderList) String() string {
+	return strings.Join(l, ",")
+}
+
+func (l *ProviderList) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		*l = ParseProviderList(value.Value)
+		return nil
+	case yaml.SequenceNode:
+		var names []string
+		if err := value.Decode(&names); err != nil {
+			return fmt.Errorf("provider: %w", err)
+		}
+		*l = ParseProviderList(strings.Join(names, ","))
+		return nil
+	default:
+		return fmt.Errorf("provider: expected a name or a list of names")
+	}
 }
 
 type LLMConfig struct {
 	Timeout     time.Duration `yaml:"timeout"`
 	Temperature float32       `yaml:"temperature"`
+	Stream      *bool         `yaml:"stream"`
+	// AttemptTimeout bounds each provider of a fallback chain so that a
+	// hanging provider leaves time for the next one. Zero disables it.
+	AttemptTimeout time.Duration `yaml:"attempt_timeout"`
+	Retry          RetryConfig   `yaml:"retry"`
 }
 
 func (l *LLMConfig) UnmarshalYAML(value *yaml.Node) error {
 	type plain struct {
-		Timeout     string  `yaml:"timeout"`
-		Temperature float32 `yaml:"temperature"`
+		Timeout        string      `yaml:"timeout"`
+		Temperature    float32     `yaml:"temperature"`
+		Stream         *bool       `yaml:"stream"`
+		AttemptTimeout string      `yaml:"attempt_timeout"`
+		Retry          RetryConfig `yaml:"retry"`
 	}
 
-	var p plain
+	// Start from the current retry settings so that keys missing from the
+	// file keep their previous value.
+	p := plain{Retry: l.Retry}
 	if err := value.Decode(&p); err != nil {
 		return err
 	}
 
 	l.Temperature = p.Temperature
+	l.Retry = p.Retry
+	if p.Stream != nil {
+		l.Stream = p.Stream
+	}
 	if p.Timeout != "" {
 		d, err := time.ParseDuration(p.Timeout)
 		if err != nil {
@@ -44,6 +117,54 @@ func (l *LLMConfig) UnmarshalYAML(value *yaml.Node) error {
 		}
 		l.Timeout = d
 	}
+	if p.AttemptTimeout != "" {
+		d, err := time.ParseDuration(p.AttemptTimeout)
+		if err != nil {
+			return fmt.Errorf("llm.attempt_timeout: %w", err)
+		}
+		l.AttemptTimeout = d
+	}
+
+	return nil
+}
+
+// RetryConfig controls how transient provider failures (rate limits, 5xx,
+// dropped connections) are retried within llm.timeout.
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+}
+
+func (r *RetryConfig) UnmarshalYAML(value *yaml.Node) error {
+	type plain struct {
+		MaxAttempts    *int   `yaml:"max_attempts"`
+		InitialBackoff string `yaml:"initial_backoff"`
+		MaxBackoff     string `yaml:"max_backoff"`
+	}
+
+	var p plain
+	if err := value.Decode(&p); err != nil {
+		return err
+	}
+
+	if p.MaxAttempts != nil {
+		r.MaxAttempts = *p.MaxAttempts
+	}
+	if p.InitialBackoff != "" {
+		d, err := time.ParseDuration(p.InitialBackoff)
+		if err != nil {
+			return fmt.Errorf("llm.retry.initial_backoff: %w", err)
+		}
+		r.InitialBackoff = d
+	}
+	if p.MaxBackoff != "" {
+		d, err := time.ParseDuration(p.MaxBackoff)
+		if err != nil {
+			return fmt.Errorf("llm.retry.max_backoff: %w", err)
+		}
+		r.MaxBackoff = d
+	}
 
 	return nil
 }
@@ -51,12 +172,84 @@ func (l *LLMConfig) UnmarshalYAML(value *yaml.Node) error {
 type CommitConfig struct {
 	Prompt string `yaml:"prompt"`
 	Edit   *bool  `yaml:"edit"`
+	// Interactive reviews each generated message before committing.
+	Interactive bool       `yaml:"interactive"`
+	Lint        LintConfig `yaml:"lint"`
+}
+
+// LintConfig controls the Conventional Commits check of generated messages
+// and of ditto lint.
+type LintConfig struct {
+	// Enabled defaults to true unless a custom commit prompt replaces the
+	// default convention, see LintEnabled.
+	Enabled *bool `yaml:"enabled"`
+	// Retries is how many times the provider may fix a broken message.
+	Retries int      `yaml:"retries"`
+	Types   []string `yaml:"types"`
+	// MaxSubjectLength bounds the whole subject line, type and scope
+	// included.
+	MaxSubjectLength int `yaml:"max_subject_length"`
+}
+
+// LintEnabled reports whether generated commit messages are linted.
+func (c CommitConfig) LintEnabled() bool {
+	if c.Lint.Enabled != nil {
+		return *c.Lint.Enabled
+	}
+	return c.Prompt == ""
 }
 
 type PRConfig struct {
 	Prompt       string `yaml:"prompt"`
 	TemplatePath string `yaml:"template_path"`
 	Edit         *bool  `yaml:"edit"`
+	// Labels lists the repository labels the model may apply to a PR.
+	Labels []string `yaml:"labels"`
+}
+
+// ChangelogConfig controls ditto changelog.
+type ChangelogConfig struct {
+	// Prompt replaces the default writing guidelines of the release notes.
+	Prompt string `yaml:"prompt"`
+	// File is the changelog --write updates, relative to the repository
+	// root.
+	File string `yaml:"file"`
+}
+
+// VersionConfig controls ditto version.
+type VersionConfig struct {
+	// TagPrefix comes before the version in tag names, such as "v" or
+	// "svc-a/v" in a monorepo.
+	TagPrefix string `yaml:"tag_prefix"`
+}
+
+// BranchConfig controls the names ditto branch generates.
+type BranchConfig struct {
+	// Pattern shapes the name with the {type}, {issue} and {slug}
+	// placeholders.
+	Pattern string `yaml:"pattern"`
+	// Types are the change types the model may choose from. Empty means the
+	// types of the commit convention.
+	Types     []string `yaml:"types"`
+	MaxLength int      `yaml:"max_length"`
+}
+
+// IssuesConfig controls how issues are found when --issues is not given.
+type IssuesConfig struct {
+	// BranchPatterns are regular expressions that find issue IDs in the
+	// branch name. The first capture group is the ID, or the whole match
+	// without groups. An empty list turns the inference off.
+	BranchPatterns []string `yaml:"branch_patterns"`
+}
+
+// GitLabConfig controls how merge requests are opened on GitLab.
+type GitLabConfig struct {
+	// BaseURL is the address of the GitLab instance. Empty means the host of
+	// the origin remote, over HTTPS unless the remote is an http:// URL.
+	BaseURL string `yaml:"base_url"`
+	// Token is a personal access token with the api scope. Without it, the
+	// glab CLI is used.
+	Token string `yaml:"token"`
 }
 
 type GeminiConfig struct {
@@ -75,12 +268,97 @@ type CopilotConfig struct {
 	Model    string `yaml:"model"`
 }
 
+type OpenAIConfig struct {
+	BaseURL      string            `yaml:"base_url"`
+	APIKey       string            `yaml:"api_key"`
+	Organization string            `yaml:"organization"`
+	Model        string            `yaml:"model"`
+	Headers      map[string]string `yaml:"headers"`
+}
+
+type AnthropicConfig struct {
+	BaseURL   string `yaml:"base_url"`
+	APIKey    string `yaml:"api_key"`
+	Model     string `yaml:"model"`
+	MaxTokens int    `yaml:"max_tokens"`
+}
+
+// BudgetConfig bounds the size of prompts. Diffs that do not fit are
+// shrunk before being sent to the model.
+type BudgetConfig struct {
+	// MaxTokens is the prompt budget used when no entry of Models matches.
+	MaxTokens int `yaml:"max_tokens"`
+	// Models overrides MaxTokens, keyed by "provider/model", model or provider.
+	Models map[string]int `yaml:"models"`
+	// Generated lists extra lockfile/generated file patterns that are the
+	// first to be dropped from an oversized diff.
+	Generated []string        `yaml:"generated"`
+	MapReduce MapReduceConfig `yaml:"map_reduce"`
+}
+
+// MapReduceConfig controls the summarization of diffs that are too large
+// even after shrinking: each part is summarized separately and the
+// summaries are used to write the final message.
+type MapReduceConfig struct {
+	Enabled     bool `yaml:"enabled"`
+	Concurrency int  `yaml:"concurrency"`
+	ChunkTokens int  `yaml:"chunk_tokens"`
+}
+
+// MaxTokensFor returns the prompt budget for a provider and model pair.
+func (b BudgetConfig) MaxTokensFor(provider, model string) int {
+	for _, key := range []string{provider + "/" + model, model, provider} {
+		if n, ok := b.Models[key]; ok {
+			return n
+		}
+	}
+	return b.MaxTokens
+}
+
+// RedactConfig controls the masking of secrets in diffs, logs and other
+// repository content before it is sent to a provider.
+type RedactConfig struct {
+	Enabled *bool `yaml:"enabled"`
+	// Entropy also masks long random-looking tokens that no pattern matched.
+	// It is off by default: checksums, hashes and encoded test fixtures look
+	// just as random and would be masked too.
+	Entropy *bool `yaml:"entropy"`
+	// Patterns are added to the built-in detectors.
+	Patterns []RedactPattern `yaml:"patterns"`
+}
+
+type RedactPattern struct {
+	Name  string `yaml:"name"`
+	Regex string `yaml:"regex"`
+}
+
+// ModelForProvider returns the configured model of the named provider.
+func (c *Config) ModelForProvider(name string) string {
+	switch name {
+	case "ollama":
+		return c.Ollama.Model
+	case "copilot":
+		return c.Copilot.Model
+	case "openai":
+		return c.OpenAI.Model
+	case "anthropic":
+		return c.Anthropic.Model
+	default:
+		return c.Gemini.Model
+	}
+}
+
+// SetModelForProvider overrides the model of the primary provider.
 func (c *Config) SetModelForProvider(model string) {
-	switch c.Provider {
+	switch c.Provider.Primary() {
 	case "ollama":
 		c.Ollama.Model = model
 	case "copilot":
 		c.Copilot.Model = model
+	case "openai":
+		c.OpenAI.Model = model
+	case "anthropic":
+		c.Anthropic.Model = model
 	default:
 		c.Gemini.Model = model
 	}
@@ -88,18 +366,44 @@ func (c *Config) SetModelForProvider(model string) {
 
 func defaults() Config {
 	editTrue := true
+	streamTrue := true
+	redactTrue := true
 	return Config{
-		Provider:   "copilot",
+		Provider:   ProviderList{"copilot"},
 		BaseBranch: "main",
 		LLM: LLMConfig{
 			Timeout: 2 * time.Minute,
+			Stream:  &streamTrue,
+			Retry: RetryConfig{
+				MaxAttempts:    3,
+				InitialBackoff: time.Second,
+				MaxBackoff:     20 * time.Second,
+			},
 		},
 		Commit: CommitConfig{
 			Edit: &editTrue,
+			Lint: LintConfig{
+				Retries: 2,
+			},
 		},
 		PR: PRConfig{
 			Edit: &editTrue,
 		},
+		Changelog: ChangelogConfig{
+			File: "CHANGELOG.md",
+		},
+		Version: VersionConfig{
+			TagPrefix: "v",
+		},
+		Branch: BranchConfig{
+			Pattern:   "{type}/{issue}-{slug}",
+			MaxLength: 60,
+		},
+		Issues: IssuesConfig{
+			// Tracker keys such as PROJ-123. Bare numbers are opt-in since
+			// names like hotfix/2024-10-outage are full of them.
+			BranchPatterns: []string{`[A-Z][A-Z0-9]+-\d+`},
+		},
 		Gemini: GeminiConfig{
 			Model: "gemini-2.5-flash",
 		},
@@ -110,6 +414,28 @@ func defaults() Config {
 		Copilot: CopilotConfig{
 			Model: "gpt-4o",
 		},
+		OpenAI: OpenAIConfig{
+			BaseURL: "https://api.openai.com/v1",
+			Model:   "gpt-4o-mini",
+		},
+		Anthropic: AnthropicConfig{
+			BaseURL:   "https://api.anthropic.com",
+			Model:     "claude-sonnet-4-5",
+			MaxTokens: 1024,
+		},
+		Budget: BudgetConfig{
+			MaxTokens: 32000,
+			// Ollama serves models with a small context window unless
+			// num_ctx is raised on the server.
+			Models: map[string]int{"ollama": 8000},
+			MapReduce: MapReduceConfig{
+				Concurrency: 4,
+				ChunkTokens: 8000,
+			},
+		},
+		Redact: RedactConfig{
+			Enabled: &redactTrue,
+		},
 	}
 }
 
@@ -129,6 +455,12 @@ func Load(repoRoot string) (Config, error) {
 		if err := mergeFromFile(&cfg, projectPath); err != nil {
 			return cfg, fmt.Errorf("project config: %w", err)
 		}
+
+		ignore, err := readIgnoreFile(filepath.Join(repoRoot, ".dittoignore"))
+		if err != nil {
+			return cfg, fmt.Errorf("dittoignore: %w", err)
+		}
+		cfg.Ignore = append(cfg.Ignore, ignore...)
 	}
 
 	mergeFromEnv(&cfg)
@@ -148,13 +480,41 @@ func mergeFromFile(cfg *Config, path string) error {
 	return yaml.Unmarshal(data, cfg)
 }
 
+// readIgnoreFile returns the patterns of a gitignore-style file, skipping
+// blank lines and comments. A missing file yields no patterns.
+func readIgnoreFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var patterns []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, nil
+}
+
 func mergeFromEnv(cfg *Config) {
 	if v, ok := os.LookupEnv("DITTO_PROVIDER"); ok {
-		cfg.Provider = v
+		cfg.Provider = ParseProviderList(v)
 	}
 	if v, ok := os.LookupEnv("DITTO_BASE_BRANCH"); ok {
 		cfg.BaseBranch = v
 	}
+	if v, ok := os.LookupEnv("DITTO_PLATFORM"); ok {
+		cfg.Platform = v
+	}
+	if v, ok := os.LookupEnv("GITLAB_TOKEN"); ok {
+		cfg.GitLab.Token = v
+	}
 	if v, ok := os.LookupEnv("DITTO_LLM_TIMEOUT"); ok {
 		if d, err := time.ParseDuration(v); err == nil {
 			cfg.LLM.Timeout = d
@@ -166,6 +526,16 @@ func mergeFromEnv(cfg *Config) {
 			cfg.LLM.Temperature = t
 		}
 	}
+	if v, ok := os.LookupEnv("DITTO_LLM_MAX_ATTEMPTS"); ok {
+		var n int
+		if _, err := fmt.Sscanf(v, "%d", &n); err == nil {
+			cfg.LLM.Retry.MaxAttempts = n
+		}
+	}
+	if v, ok := os.LookupEnv("DITTO_LLM_STREAM"); ok {
+		b := v != "false" && v != "0"
+		cfg.LLM.Stream = &b
+	}
 	if v, ok := os.LookupEnv("GEMINI_API_KEY"); ok {
 		cfg.Gemini.APIKey = v
 	}
@@ -175,10 +545,39 @@ func mergeFromEnv(cfg *Config) {
 	if v, ok := os.LookupEnv("OLLAMA_MODEL"); ok {
 		cfg.Ollama.Model = v
 	}
+	if v, ok := os.LookupEnv("OPENAI_API_KEY"); ok {
+		cfg.OpenAI.APIKey = v
+	}
+	if v, ok := os.LookupEnv("OPENAI_BASE_URL"); ok {
+		cfg.OpenAI.BaseURL = v
+	}
+	if v, ok := os.LookupEnv("OPENAI_MODEL"); ok {
+		cfg.OpenAI.Model = v
+	}
+	if v, ok := os.LookupEnv("OPENAI_ORG_ID"); ok {
+		cfg.OpenAI.Organization = v
+	}
+	if v, ok := os.LookupEnv("ANTHROPIC_API_KEY"); ok {
+		cfg.Anthropic.APIKey = v
+	}
+	if v, ok := os.LookupEnv("ANTHROPIC_BASE_URL"); ok {
+		cfg.Anthropic.BaseURL = v
+	}
+	if v, ok := os.LookupEnv("DITTO_REDACT"); ok {
+		b := v != "false" && v != "0"
+		cfg.Redact.Enabled = &b
+	}
 	if v, ok := os.LookupEnv("DITTO_COMMIT_EDIT"); ok {
 		b := v != "false" && v != "0"
 		cfg.Commit.Edit = &b
 	}
+	if v, ok := os.LookupEnv("DITTO_COMMIT_INTERACTIVE"); ok {
+		cfg.Commit.Interactive = v != "false" && v != "0"
+	}
+	if v, ok := os.LookupEnv("DITTO_COMMIT_LINT"); ok {
+		b := v != "false" && v != "0"
+		cfg.Commit.Lint.Enabled = &b
+	}
 	if v, ok := os.LookupEnv("DITTO_PR_EDIT"); ok {
 		b := v != "false" && v != "0"
 		cfg.PR.Edit = &b
@@ -0,0 +1,99 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/config"
+)
+
+// load writes yaml as the project config of a temporary repository and
+// loads it, without a user config or environment overrides.
+func load(t *testing.T, yaml string) (config.Config, error) {
+	t.Helper()
+	return config.Load(project(t, yaml))
+}
+
+// project isolates the test from the user config and the environment, and
+// returns a temporary repository with yaml as its project config.
+func project(t *testing.T, yaml string) string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	for _, name := range []string{"DITTO_PROVIDER", "DITTO_BASE_BRANCH", "DITTO_PLATFORM", "GITLAB_TOKEN"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+
+	repo := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(repo, ".ditto.yaml"), []byte(yaml), 0o644))
+	return repo
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := load(t, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, config.ProviderList{"copilot"}, cfg.Provider)
+	assert.Equal(t, "main", cfg.BaseBranch)
+	assert.Equal(t, 2*time.Minute, cfg.LLM.Timeout)
+	assert.Equal(t, "{type}/{issue}-{slug}", cfg.Branch.Pattern)
+	assert.Equal(t, 60, cfg.Branch.MaxLength)
+	assert.Equal(t, []string{`[A-Z][A-Z0-9]+-\d+`}, cfg.Issues.BranchPatterns)
+	assert.Empty(t, cfg.GitLab.Token)
+	require.NotNil(t, cfg.Redact.Enabled)
+	assert.True(t, *cfg.Redact.Enabled)
+	assert.Nil(t, cfg.Redact.Entropy, "entropy redaction is opt-in")
+}
+
+func TestLoadProviderList(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		want    config.ProviderList
+		wantErr string
+	}{
+		{name: "single name", yaml: "provider: openai", want: config.ProviderList{"openai"}},
+		{name: "comma-separated", yaml: "provider: openai, ollama", want: config.ProviderList{"openai", "ollama"}},
+		{name: "sequence", yaml: "provider: [anthropic, copilot]", want: config.ProviderList{"anthropic", "copilot"}},
+		{name: "sequence with blanks", yaml: "provider:\n  - gemini\n  - ' '\n", want: config.ProviderList{"gemini"}},
+		{name: "mapping", yaml: "provider: {name: openai}", wantErr: "expected a name or a list of names"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load(t, tt.yaml)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.Provider)
+			assert.Equal(t, tt.want[0], cfg.Provider.Primary())
+		})
+	}
+}
+
+func TestLoadEnvOverrides(t *testing.T) {
+	repo := project(t, "provider: gemini\ngitlab:\n  token: from-file\n")
+	cfg, err := config.Load(repo)
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", cfg.GitLab.Token)
+
+	t.Setenv("GITLAB_TOKEN", "from-env")
+	t.Setenv("DITTO_PROVIDER", "ollama,copilot")
+	cfg, err = config.Load(repo)
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", cfg.GitLab.Token)
+	assert.Equal(t, config.ProviderList{"ollama", "copilot"}, cfg.Provider)
+}
+
+func TestLoadDisablesIssueInference(t *testing.T) {
+	cfg, err := load(t, "issues:\n  branch_patterns: []\n")
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Issues.BranchPatterns)
+}
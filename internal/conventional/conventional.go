@@ -0,0 +1,121 @@
+// Package conventional parses commit messages that follow the Conventional
+// Commits specification (https://www.conventionalcommits.org).
+package conventional
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Footer is a git trailer such as "Closes #12" or "BREAKING CHANGE: ...".
+type Footer struct {
+	Token string `json:"token"`
+	Value string `json:"value"`
+}
+
+// Message is a commit message split into its parts.
+type Message struct {
+	// Subject is the first line of the message.
+	Subject string
+	// Body is everything between the subject and the footers.
+	Body    string
+	Footers []Footer
+}
+
+var (
+	footerRE = regexp.MustCompile(`^(BREAKING CHANGE|BREAKING-CHANGE|[A-Za-z][\w-]*)(: | #)(.*)$`)
+	// issueFooterRE accepts issue keywords followed by tracker keys, as in
+	// "Refs PROJ-42", which are not git trailers.
+	issueFooterRE = regexp.MustCompile(`^(Closes|Fixes|Resolves|Refs)( )([A-Z][A-Z0-9]*-\d+.*)$`)
+)
+
+// Parse splits msg into subject, body and footers. The footers are the
+// trailing paragraphs of the message that start with a trailer; lines that
+// do not start a new trailer continue the previous one.
+func Parse(msg string) Message {
+	msg = strings.TrimSpace(strings.ReplaceAll(msg, "\r\n", "\n"))
+
+	subject, rest, _ := strings.Cut(msg, "\n")
+	m := Message{Subject: strings.TrimSpace(subject)}
+
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		return m
+	}
+
+	paragraphs := strings.Split(rest, "\n\n")
+	for len(paragraphs) > 0 {
+		footers, ok := parseFooters(paragraphs[len(paragraphs)-1])
+		if !ok {
+			break
+		}
+		m.Footers = append(footers, m.Footers...)
+		paragraphs = paragraphs[:len(paragraphs)-1]
+	}
+
+	m.Body = strings.TrimSpace(strings.Join(paragraphs, "\n\n"))
+	return m
+}
+
+func parseFooters(paragraph string) ([]Footer, bool) {
+	var footers []Footer
+	for line := range strings.SplitSeq(paragraph, "\n") {
+		match := footerRE.FindStringSubmatch(line)
+		if match == nil {
+			match = issueFooterRE.FindStringSubmatch(line)
+		}
+		switch {
+		case match != nil:
+			value := match[3]
+			if match[2] == " #" {
+				value = "#" + value
+			}
+			footers = append(footers, Footer{Token: match[1], Value: value})
+		case len(footers) == 0:
+			return nil, false
+		default:
+			f := &footers[len(footers)-1]
+			f.Value += "\n" + line
+		}
+	}
+	return footers, true
+}
+
+// Header is the subject of a Conventional Commit split into its parts.
+type Header struct {
+	Type  string
+	Scope string
+	// Bang is true when the type or scope is followed by '!'.
+	Bang        bool
+	Description string
+}
+
+// Header parses the subject of m. ok is false when the subject does not
+// have the form '<type>(<scope>): <description>'.
+func (m Message) Header() (h Header, ok bool) {
+	match := headerRE.FindStringSubmatch(m.Subject)
+	if match == nil {
+		return Header{}, false
+	}
+	return Header{
+		Type:        match[headerRE.SubexpIndex("type")],
+		Scope:       match[headerRE.SubexpIndex("scope")],
+		Bang:        match[headerRE.SubexpIndex("bang")] != "",
+		Description: match[headerRE.SubexpIndex("desc")],
+	}, true
+}
+
+// BreakingChange returns the description of the breaking change m
+// announces, from its 'BREAKING CHANGE' footer or, failing that, its
+// subject when it is marked with '!'. ok is false for other messages.
+func (m Message) BreakingChange() (desc string, ok bool) {
+	for _, f := range m.Footers {
+		if f.Token == "BREAKING CHANGE" || f.Token == "BREAKING-CHANGE" {
+			return f.Value, true
+		}
+	}
+	if h, ok := m.Header(); ok && h.Bang {
+		return h.Description, true
+	}
+	return "", false
+}
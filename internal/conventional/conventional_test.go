@@ -0,0 +1,96 @@
+package conventional_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/arthvm/ditto/internal/conventional"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want conventional.Message
+	}{
+		{
+			name: "subject only",
+			msg:  "fix: handle empty diff\n",
+			want: conventional.Message{Subject: "fix: handle empty diff"},
+		},
+		{
+			name: "body without footers",
+			msg:  "feat(auth): add token refresh\n\nTokens are refreshed before they expire.\n\nThis avoids re-login prompts.",
+			want: conventional.Message{
+				Subject: "feat(auth): add token refresh",
+				Body:    "Tokens are refreshed before they expire.\n\nThis avoids re-login prompts.",
+			},
+		},
+		{
+			name: "body and footers",
+			msg:  "feat!: drop v1 api\n\nRemove the deprecated endpoints.\n\nBREAKING CHANGE: clients must use /v2\nand update their tokens\nCloses #12\nReviewed-by: Jane",
+			want: conventional.Message{
+				Subject: "feat!: drop v1 api",
+				Body:    "Remove the deprecated endpoints.",
+				Footers: []conventional.Footer{
+					{Token: "BREAKING CHANGE", Value: "clients must use /v2\nand update their tokens"},
+					{Token: "Closes", Value: "#12"},
+					{Token: "Reviewed-by", Value: "Jane"},
+				},
+			},
+		},
+		{
+			name: "footers without body",
+			msg:  "chore: bump deps\r\n\r\nRefs #3",
+			want: conventional.Message{
+				Subject: "chore: bump deps",
+				Footers: []conventional.Footer{{Token: "Refs", Value: "#3"}},
+			},
+		},
+		{
+			name: "last paragraph that is not a trailer",
+			msg:  "docs: explain setup\n\nNote: this only covers Linux.\nmacOS docs come later.\n\nSee the wiki for details.",
+			want: conventional.Message{
+				Subject: "docs: explain setup",
+				Body:    "Note: this only covers Linux.\nmacOS docs come later.\n\nSee the wiki for details.",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, conventional.Parse(tt.msg))
+		})
+	}
+}
+
+func TestHeader(t *testing.T) {
+	h, ok := conventional.Parse("feat(api)!: drop v1 endpoints").Header()
+	assert.True(t, ok)
+	assert.Equal(t, conventional.Header{Type: "feat", Scope: "api", Bang: true, Description: "drop v1 endpoints"}, h)
+
+	_, ok = conventional.Parse("Update README").Header()
+	assert.False(t, ok)
+}
+
+func TestBreakingChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      string
+		want     string
+		breaking bool
+	}{
+		{name: "footer", msg: "feat!: drop v1\n\nBREAKING CHANGE: clients must use /v2", want: "clients must use /v2", breaking: true},
+		{name: "bang only", msg: "refactor(config)!: rename keys", want: "rename keys", breaking: true},
+		{name: "not breaking", msg: "fix: handle empty diff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desc, ok := conventional.Parse(tt.msg).BreakingChange()
+			assert.Equal(t, tt.breaking, ok)
+			assert.Equal(t, tt.want, desc)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package conventional
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultTypes are the commit types allowed by the default convention.
+var DefaultTypes = []string{
+	"feat", "fix", "docs", "style", "refactor", "test", "chore", "perf", "ci", "revert",
+}
+
+// DefaultMaxSubjectLength is the default limit for the subject line, type
+// and scope included, as git tooling expects.
+const DefaultMaxSubjectLength = 50
+
+// Rules configures Lint. Zero values fall back to the defaults.
+type Rules struct {
+	Types []string
+	// MaxSubjectLength bounds the whole subject line, not only the
+	// description after the type.
+	MaxSubjectLength int
+	// Issues must each be referenced in a footer: with Closes for feat and
+	// fix commits and with Refs otherwise.
+	Issues []string
+}
+
+// Violation is a broken rule. Rule is a stable identifier suitable for
+// scripts, Message explains the problem to people and models alike.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return v.Message
+}
+
+var (
+	headerRE   = regexp.MustCompile(`^(?P<type>\w+)(?:\((?P<scope>[^()]*)\))?(?P<bang>!)?: (?P<desc>.*)$`)
+	scopeRE    = regexp.MustCompile(`^[a-z0-9][a-z0-9._/-]*(?:, ?[a-z0-9][a-z0-9._/-]*)*$`)
+	preambleRE = regexp.MustCompile(`(?i)^(?:here(?:'s| is| are)|sure\b|certainly\b|okay\b|below is|the following|based on the diff|commit message:?$)`)
+)
+
+// Lint checks msg against the Conventional Commits rules spelled out in the
+// default commit convention and returns every violation found.
+func Lint(msg string, rules Rules) []Violation {
+	if len(rules.Types) == 0 {
+		rules.Types = DefaultTypes
+	}
+	if rules.MaxSubjectLength <= 0 {
+		rules.MaxSubjectLength = DefaultMaxSubjectLength
+	}
+
+	msg = strings.TrimSpace(strings.ReplaceAll(msg, "\r\n", "\n"))
+	if msg == "" {
+		return []Violation{{Rule: "empty", Message: "the message is empty"}}
+	}
+
+	var violations []Violation
+	add := func(rule, format string, args ...any) {
+		violations = append(violations, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	lines := strings.Split(msg, "\n")
+	if slices.ContainsFunc(lines, func(l string) bool { return strings.HasPrefix(strings.TrimSpace(l), "```") }) {
+		add("code-fence", "the message must not be wrapped in a Markdown code fence")
+	}
+	if preambleRE.MatchString(lines[0]) {
+		add("preamble", "the message must start with the subject line, not with an introduction such as %q", lines[0])
+	}
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		add("blank-line", "the subject line must be followed by a blank line")
+	}
+
+	m := Parse(msg)
+	match := headerRE.FindStringSubmatch(m.Subject)
+	if match == nil {
+		add("header-format", "the subject %q must have the form '<type>(<scope>): <description>'", m.Subject)
+		return violations
+	}
+
+	typ := match[headerRE.SubexpIndex("type")]
+	scope := match[headerRE.SubexpIndex("scope")]
+	bang := match[headerRE.SubexpIndex("bang")] != ""
+	desc := match[headerRE.SubexpIndex("desc")]
+
+	if !slices.Contains(rules.Types, typ) {
+		add("type", "the type %q is not one of: %s", typ, strings.Join(rules.Types, ", "))
+	}
+	hasScope := strings.HasPrefix(m.Subject[len(typ):], "(")
+	if hasScope && !scopeRE.MatchString(scope) {
+		add("scope", "the scope %q must be a lowercase noun such as 'auth' or 'api-client'", scope)
+	}
+	if strings.TrimSpace(desc) == "" {
+		add("description", "the description after the type must not be empty")
+	}
+	if n := utf8.RuneCountInString(m.Subject); n > rules.MaxSubjectLength {
+		add("subject-length", "the subject line is %d characters long, the maximum is %d", n, rules.MaxSubjectLength)
+	}
+
+	breakingFooter := slices.ContainsFunc(m.Footers, func(f Footer) bool {
+		return f.Token == "BREAKING CHANGE" || f.Token == "BREAKING-CHANGE"
+	})
+	switch {
+	case bang && !breakingFooter:
+		add("breaking-change", "a breaking change marked with '!' needs a 'BREAKING CHANGE: <description>' footer")
+	case breakingFooter && !bang:
+		add("breaking-change", "a 'BREAKING CHANGE' footer needs a '!' after the type or scope")
+	}
+
+	keyword := "Refs"
+	if typ == "feat" || typ == "fix" {
+		keyword = "Closes"
+	}
+	for _, issue := range rules.Issues {
+		ref := issueRef(issue)
+		footer, ok := findReference(m.Footers, ref)
+		switch {
+		case !ok:
+			add("issue-footer", "the footer must reference issue %s with '%s %s'", ref, keyword, ref)
+		case footer.Token != keyword:
+			add("issue-footer", "issue %s must be referenced with '%s', not '%s', for a %s commit", ref, keyword, footer.Token, typ)
+		}
+	}
+
+	return violations
+}
+
+// issueRef returns how issue is written in a footer: numeric IDs get a
+// leading '#', keys such as PROJ-42 are used as is.
+func issueRef(issue string) string {
+	issue = strings.TrimSpace(issue)
+	if strings.Trim(issue, "0123456789") == "" {
+		return "#" + issue
+	}
+	return issue
+}
+
+func findReference(footers []Footer, ref string) (Footer, bool) {
+	for _, f := range footers {
+		for field := range strings.FieldsFuncSeq(f.Value, func(r rune) bool { return r == ',' || r == ' ' }) {
+			if field == ref {
+				return f, true
+			}
+		}
+	}
+	return Footer{}, false
+}
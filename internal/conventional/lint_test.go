@@ -0,0 +1,98 @@
+package conventional_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/arthvm/ditto/internal/conventional"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name  string
+		msg   string
+		rules conventional.Rules
+		want  []string
+	}{
+		{
+			name: "valid",
+			msg:  "feat(auth): add token refresh\n\nRefresh tokens before they expire.\n\nCloses #12",
+			rules: conventional.Rules{
+				Issues: []string{"12"},
+			},
+		},
+		{
+			name: "valid breaking change",
+			msg:  "refactor(api)!: drop v1 endpoints\n\nBREAKING CHANGE: clients must use /v2\n\nRefs PROJ-42, #7",
+			rules: conventional.Rules{
+				Issues: []string{"PROJ-42", "7"},
+			},
+		},
+		{
+			name: "description with parentheses and no scope",
+			msg:  "fix: handle nil map (regression)",
+		},
+		{
+			name: "code fence and preamble",
+			msg:  "Here is your commit message:\n```\nfeat: add thing\n```",
+			want: []string{"code-fence", "preamble", "blank-line", "header-format"},
+		},
+		{
+			name: "unknown type and bad scope",
+			msg:  "feature(Auth Module): add login",
+			want: []string{"type", "scope"},
+		},
+		{
+			name: "long subject and missing blank line",
+			msg:  "fix: make sure that the retry loop stops once the deadline has passed\nIt used to spin.",
+			want: []string{"blank-line", "subject-length"},
+		},
+		{
+			name: "subject length includes type and scope",
+			msg:  "refactor(api-client): split request building helpers",
+			want: []string{"subject-length"},
+		},
+		{
+			name: "subject at the limit",
+			msg:  "refactor(api-client): split request building funcs",
+		},
+		{
+			name: "breaking marker without footer",
+			msg:  "feat!: remove legacy flag",
+			want: []string{"breaking-change"},
+		},
+		{
+			name: "breaking footer without marker",
+			msg:  "feat: remove legacy flag\n\nBREAKING CHANGE: --legacy is gone",
+			want: []string{"breaking-change"},
+		},
+		{
+			name:  "missing and wrong issue keywords",
+			msg:   "docs: explain setup\n\nCloses #3",
+			rules: conventional.Rules{Issues: []string{"3", "4"}},
+			want:  []string{"issue-footer", "issue-footer"},
+		},
+		{
+			name:  "custom types and length",
+			msg:   "build: bump go to 1.25",
+			rules: conventional.Rules{Types: []string{"build"}, MaxSubjectLength: 20},
+			want:  []string{"subject-length"},
+		},
+		{
+			name: "empty",
+			msg:  "  \n",
+			want: []string{"empty"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, v := range conventional.Lint(tt.msg, tt.rules) {
+				got = append(got, v.Rule)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
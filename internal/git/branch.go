@@ -2,14 +2,34 @@ package git
 
 import (
 	"context"
+	"errors"
+	"os/exec"
 	"strings"
 )
 
+// CurrentBranch returns the name of the checked-out branch, or an empty
+// string when HEAD is detached.
 func CurrentBranch(ctx context.Context) (string, error) {
-	res, err := run(ctx, "branch", "--show-current")
+	res, err := run(ctx, "symbolic-ref", "--quiet", "--short", "HEAD")
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return "", nil
+	}
 	if err != nil {
 		return "", err
 	}
 
 	return strings.TrimSpace(res), nil
 }
+
+// CheckBranchName returns an error when name is not a valid branch name.
+func CheckBranchName(ctx context.Context, name string) error {
+	_, err := run(ctx, "check-ref-format", "--branch", name)
+	return err
+}
+
+// CreateBranch creates a branch at HEAD and checks it out.
+func CreateBranch(ctx context.Context, name string) error {
+	_, err := run(ctx, "switch", "--create", name)
+	return err
+}
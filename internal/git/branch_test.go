@@ -0,0 +1,33 @@
+package git_test
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/git"
+)
+
+func TestCurrentBranch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Chdir(t.TempDir())
+	run := func(args ...string) {
+		require.NoError(t, exec.CommandContext(ctx, "git", args...).Run(), "git %v", args)
+	}
+	run("init", "--quiet", "--initial-branch=main")
+
+	name, err := git.CurrentBranch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "main", name, "a branch without commits is still checked out")
+
+	run("-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "--quiet", "--allow-empty", "-m", "chore: init")
+	run("checkout", "--quiet", "--detach")
+
+	name, err = git.CurrentBranch(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, name)
+}
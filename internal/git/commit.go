@@ -2,6 +2,7 @@ package git
 
 import (
 	"context"
+	"io"
 	"os"
 	"os/exec"
 )
@@ -14,7 +15,9 @@ const (
 	Edit  CommitOption = "--edit"
 )
 
-func CommitWithMsg(ctx context.Context, msg string, options ...CommitOption) error {
+// CommitWithMsg runs git commit with msg. git's own output is written to
+// stdout, or to os.Stdout when it is nil.
+func CommitWithMsg(ctx context.Context, msg string, stdout io.Writer, options ...CommitOption) error {
 	useEditor := false
 	var extraArgs []string
 	for _, opt := range options {
@@ -37,7 +40,10 @@ func CommitWithMsg(ctx context.Context, msg string, options ...CommitOption) err
 	cmd := exec.CommandContext(ctx, "git", gitArgs...)
 
 	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = stdout
+	if stdout == nil {
+		cmd.Stdout = os.Stdout
+	}
 	cmd.Stderr = os.Stderr
 
 	return cmd.Run()
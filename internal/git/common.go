@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"strings"
 )
 
 type gitArg interface {
@@ -24,7 +26,19 @@ func Branches(base string, head string) gitArg {
 }
 
 func run(ctx context.Context, args ...string) (string, error) {
+	return runWithInput(ctx, "", nil, args...)
+}
+
+// runWithInput is like run but feeds input to git's stdin and adds env to
+// its environment.
+func runWithInput(ctx context.Context, input string, env []string, args ...string) (string, error) {
 	cmd := exec.CommandContext(ctx, "git", args...)
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	res, err := cmd.Output()
 	if err != nil {
@@ -8,6 +8,10 @@ import (
 var (
 	Staged DiffOption = "--staged"
 	Stats  DiffOption = "--stat"
+	// Binary includes binary changes so that the diff can be applied.
+	Binary DiffOption = "--binary"
+	// NameOnly lists the changed paths instead of their changes.
+	NameOnly DiffOption = "--name-only"
 )
 
 type DiffArg interface {
@@ -24,14 +28,24 @@ func Target(target string) DiffOption {
 	return DiffOption(target)
 }
 
+// Diff runs git diff with options. External diff drivers, colors and
+// custom prefixes are turned off whatever the user's configuration, so
+// that the output can be parsed and applied.
 func Diff(ctx context.Context, options ...DiffArg) (string, error) {
-	var args []string
+	var args, paths []string
 
 	for _, opt := range options {
+		if spec, ok := opt.(Pathspec); ok {
+			paths = append(paths, spec.String())
+			continue
+		}
 		parts := strings.Fields(opt.String())
 		args = append(args, parts...)
 	}
-	gitArgs := append([]string{"diff"}, args...)
+	gitArgs := append([]string{"diff", "--no-ext-diff", "--no-color", "--src-prefix=a/", "--dst-prefix=b/"}, args...)
+	if len(paths) > 0 {
+		gitArgs = append(append(gitArgs, "--"), paths...)
+	}
 
 	return run(ctx, gitArgs...)
 }
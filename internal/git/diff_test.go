@@ -68,3 +68,29 @@ index 0000000..8ab686e
 +Hello, World!
 `, diff)
 }
+
+func TestDiffIgnoresUserConfig(t *testing.T) {
+	ctx := context.Background()
+
+	repo := t.TempDir()
+	t.Chdir(repo)
+	require.NoError(t, exec.CommandContext(ctx, "git", "init").Run())
+
+	for _, kv := range [][2]string{
+		{"diff.noprefix", "true"},
+		{"diff.mnemonicPrefix", "true"},
+		{"color.ui", "always"},
+		{"diff.external", "false"},
+	} {
+		require.NoError(t, exec.CommandContext(ctx, "git", "config", kv[0], kv[1]).Run())
+	}
+
+	require.NoError(t, os.WriteFile("hello.txt", []byte("Hello, World!\n"), 0o644))
+	require.NoError(t, exec.CommandContext(ctx, "git", "add", "hello.txt").Run())
+
+	diff, err := git.Diff(ctx, git.Staged)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "diff --git a/hello.txt b/hello.txt\n")
+	assert.Contains(t, diff, "+++ b/hello.txt\n")
+	assert.NotContains(t, diff, "\x1b[")
+}
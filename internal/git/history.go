@@ -0,0 +1,204 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// CommitObject is the content of a commit object.
+type CommitObject struct {
+	Tree    string
+	Parents []string
+	// Author is the raw author line: "Name <email> timestamp timezone".
+	Author  string
+	Message string
+}
+
+// ReadCommit reads the commit object rev points to.
+func ReadCommit(ctx context.Context, rev string) (CommitObject, error) {
+	res, err := run(ctx, "cat-file", "commit", rev)
+	if err != nil {
+		return CommitObject{}, err
+	}
+
+	headers, msg, _ := strings.Cut(res, "\n\n")
+	c := CommitObject{Message: msg}
+	for line := range strings.SplitSeq(headers, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "tree":
+			c.Tree = value
+		case "parent":
+			c.Parents = append(c.Parents, value)
+		case "author":
+			c.Author = value
+		}
+	}
+	return c, nil
+}
+
+// AuthorEnv turns a raw author line into the environment variables that
+// make git record the same author and date.
+func AuthorEnv(author string) ([]string, error) {
+	name, rest, ok := strings.Cut(author, " <")
+	if !ok {
+		return nil, fmt.Errorf("parse author %q", author)
+	}
+	email, date, ok := strings.Cut(rest, "> ")
+	if !ok {
+		return nil, fmt.Errorf("parse author %q", author)
+	}
+
+	return []string{
+		"GIT_AUTHOR_NAME=" + name,
+		"GIT_AUTHOR_EMAIL=" + email,
+		"GIT_AUTHOR_DATE=" + date,
+	}, nil
+}
+
+// CommitTree creates a commit object for tree with the given parents and
+// message, and returns its name. env is added to git's environment, for
+// instance to keep the original author.
+func CommitTree(ctx context.Context, tree string, parents []string, msg string, env []string) (string, error) {
+	args := []string{"commit-tree", tree}
+	for _, p := range parents {
+		args = append(args, "-p", p)
+	}
+	args = append(args, "-F", "-")
+
+	res, err := runWithInput(ctx, msg, env, args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(res), nil
+}
+
+// RevList returns the commits of revRange, oldest first, each followed by
+// its parents.
+func RevList(ctx context.Context, revRange string) ([][]string, error) {
+	res, err := run(ctx, "rev-list", "--reverse", "--parents", revRange, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	var commits [][]string
+	for line := range strings.SplitSeq(strings.TrimSpace(res), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			commits = append(commits, fields)
+		}
+	}
+	return commits, nil
+}
+
+// Reachable returns the commits of revRange that can also be reached from
+// rev.
+func Reachable(ctx context.Context, revRange, rev string) ([]string, error) {
+	all, err := run(ctx, "rev-list", revRange, "--")
+	if err != nil {
+		return nil, err
+	}
+	outside, err := run(ctx, "rev-list", revRange, "--not", rev, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[string]bool)
+	for _, hash := range strings.Fields(outside) {
+		excluded[hash] = true
+	}
+
+	var reachable []string
+	for _, hash := range strings.Fields(all) {
+		if !excluded[hash] {
+			reachable = append(reachable, hash)
+		}
+	}
+	return reachable, nil
+}
+
+// EmptyTree returns the name of the empty tree, which stands in for the
+// parent of a root commit in diffs.
+func EmptyTree(ctx context.Context) (string, error) {
+	res, err := run(ctx, "hash-object", "-t", "tree", "/dev/null")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(res), nil
+}
+
+// Reword recreates the commits after base up to HEAD, replacing the
+// messages of those listed in messages, and points HEAD at the result.
+// base is empty to start from the root commit. Trees, authors and author
+// dates are kept; the commits are not signed. The history from base to
+// HEAD must be linear. The new HEAD is returned.
+func Reword(ctx context.Context, base string, messages map[string]string) (string, error) {
+	head, err := RevParse(ctx, "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	revRange := "HEAD"
+	if base != "" {
+		revRange = base + "..HEAD"
+	}
+	chain, err := RevList(ctx, revRange)
+	if err != nil {
+		return "", err
+	}
+
+	found := 0
+	parent := base
+	rewritten := false
+	for _, entry := range chain {
+		hash, parents := entry[0], entry[1:]
+		if len(parents) > 1 {
+			return "", fmt.Errorf("cannot rewrite merge commit %s", hash)
+		}
+
+		msg, reword := messages[hash]
+		if reword {
+			found++
+		}
+		if !reword && !rewritten {
+			parent = hash
+			continue
+		}
+
+		obj, err := ReadCommit(ctx, hash)
+		if err != nil {
+			return "", err
+		}
+		if reword {
+			msg = strings.TrimSpace(msg) + "\n"
+		} else {
+			msg = obj.Message
+		}
+		env, err := AuthorEnv(obj.Author)
+		if err != nil {
+			return "", err
+		}
+
+		var newParents []string
+		if parent != "" {
+			newParents = []string{parent}
+		}
+		parent, err = CommitTree(ctx, obj.Tree, newParents, msg, env)
+		if err != nil {
+			return "", err
+		}
+		rewritten = true
+	}
+
+	if found != len(messages) {
+		return "", fmt.Errorf("%d of the commits to reword are not between %s and HEAD", len(messages)-found, base)
+	}
+	if !rewritten {
+		return head, nil
+	}
+
+	if err := UpdateRef(ctx, "HEAD", parent, head, "ditto reword"); err != nil {
+		return "", err
+	}
+	return parent, nil
+}
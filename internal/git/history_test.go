@@ -0,0 +1,60 @@
+package git_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/git"
+)
+
+func TestReword(t *testing.T) {
+	ctx := context.Background()
+
+	repo, err := SetupGitRepo(ctx)
+	require.NoError(t, err)
+	defer os.RemoveAll(repo)
+
+	t.Setenv("GIT_COMMITTER_NAME", "Committer")
+	t.Setenv("GIT_COMMITTER_EMAIL", "committer@example.com")
+
+	gitOut := func(args ...string) string {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = repo
+		out, err := cmd.Output()
+		require.NoError(t, err)
+		return strings.TrimSpace(string(out))
+	}
+
+	for i, msg := range []string{"chore: init", "wip", "asdf"} {
+		require.NoError(t, os.WriteFile(repo+"/file.txt", []byte(msg+"\n"), 0o644))
+		gitOut("add", "file.txt")
+		gitOut("-c", "user.name=Author", "-c", "user.email=author@example.com",
+			"commit", "-m", msg, "--date", fmt.Sprintf("2024-01-0%dT10:00:00+02:00", i+1))
+	}
+	oldTrees := gitOut("log", "--format=%T")
+	oldDates := gitOut("log", "--format=%an <%ae> %ad")
+	first := gitOut("rev-parse", "HEAD~2")
+	wip := gitOut("rev-parse", "HEAD~1")
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(wd)
+	require.NoError(t, os.Chdir(repo))
+
+	head, err := git.Reword(ctx, first, map[string]string{wip: "feat: add file\n\nWith a body."})
+	require.NoError(t, err)
+
+	assert.Equal(t, head, gitOut("rev-parse", "HEAD"))
+	assert.Equal(t, "asdf\n\nfeat: add file\n\nWith a body.\n\nchore: init", gitOut("log", "--format=%B"))
+	assert.Equal(t, oldTrees, gitOut("log", "--format=%T"))
+	assert.Equal(t, oldDates, gitOut("log", "--format=%an <%ae> %ad"))
+	assert.Equal(t, first, gitOut("rev-parse", "HEAD~2"))
+	assert.Contains(t, gitOut("reflog", "-1"), "ditto reword")
+}
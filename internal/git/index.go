@@ -0,0 +1,86 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// RevParse resolves rev to a full object name.
+func RevParse(ctx context.Context, rev string) (string, error) {
+	res, err := run(ctx, "rev-parse", "--verify", rev)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(res), nil
+}
+
+// HasHead reports whether HEAD points at a commit, which it does not on a
+// branch that has no commits yet.
+func HasHead(ctx context.Context) (bool, error) {
+	_, err := run(ctx, "rev-parse", "--verify", "--quiet", "HEAD")
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// WriteTree stores the index as a tree object and returns its name. The
+// index is left as is.
+func WriteTree(ctx context.Context) (string, error) {
+	res, err := run(ctx, "write-tree")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(res), nil
+}
+
+// ReadTree replaces the index with treeish. The working tree is not
+// touched.
+func ReadTree(ctx context.Context, treeish string) error {
+	_, err := run(ctx, "read-tree", treeish)
+	return err
+}
+
+// ApplyCached applies patch to the index only.
+func ApplyCached(ctx context.Context, patch string) error {
+	_, err := runWithInput(ctx, patch, nil, "apply", "--cached", "--whitespace=nowarn", "-")
+	return err
+}
+
+// UpdateRef points ref at newValue, recording reason in the reflog. ref
+// may be HEAD, in which case the branch it refers to is updated. When
+// oldValue is not empty, the update fails unless ref still points at it.
+func UpdateRef(ctx context.Context, ref, newValue, oldValue, reason string) error {
+	args := []string{"update-ref", "-m", reason, ref, newValue}
+	if oldValue != "" {
+		args = append(args, oldValue)
+	}
+	_, err := run(ctx, args...)
+	return err
+}
+
+// DeleteRef removes ref, recording reason in the reflog. ref may be HEAD,
+// in which case the branch it refers to is deleted.
+func DeleteRef(ctx context.Context, ref, reason string) error {
+	_, err := run(ctx, "update-ref", "-m", reason, "-d", ref)
+	return err
+}
+
+// ResetSoft points the current branch at rev without touching the index or
+// the working tree.
+func ResetSoft(ctx context.Context, rev string) error {
+	_, err := run(ctx, "reset", "--soft", rev)
+	return err
+}
+
+// MergeBase returns the best common ancestor of a and b.
+func MergeBase(ctx context.Context, a, b string) (string, error) {
+	res, err := run(ctx, "merge-base", a, b)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(res), nil
+}
@@ -0,0 +1,78 @@
+package git_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/git"
+)
+
+func TestApplyCachedSingleHunk(t *testing.T) {
+	ctx := context.Background()
+
+	repo, err := SetupGitRepo(ctx)
+	require.NoError(t, err)
+	defer os.RemoveAll(repo)
+
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	gitCmd := func(args ...string) {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = repo
+		require.NoError(t, cmd.Run())
+	}
+
+	var lines []string
+	for i := range 30 {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	write := func() {
+		require.NoError(t, os.WriteFile(repo+"/file.txt", []byte(strings.Join(lines, "\n")+"\n"), 0o644))
+	}
+	write()
+	gitCmd("add", "file.txt")
+	gitCmd("commit", "-m", "init")
+
+	lines[1] = "changed near the top"
+	lines[28] = "changed near the bottom"
+	write()
+	gitCmd("add", "file.txt")
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(wd)
+	require.NoError(t, os.Chdir(repo))
+
+	staged, err := git.WriteTree(ctx)
+	require.NoError(t, err)
+
+	diff, err := git.Diff(ctx, git.Staged, git.Binary)
+	require.NoError(t, err)
+	files := git.ParsePatch(diff)
+	require.Len(t, files, 1)
+	require.True(t, files[0].Splittable())
+	require.Len(t, files[0].Hunks, 2)
+
+	require.NoError(t, git.ReadTree(ctx, "HEAD"))
+	require.NoError(t, git.ApplyCached(ctx, files[0].WithHunks([]int{1}).String()))
+
+	cached, err := git.Diff(ctx, git.Staged)
+	require.NoError(t, err)
+	assert.Contains(t, cached, "+changed near the bottom")
+	assert.NotContains(t, cached, "+changed near the top")
+
+	require.NoError(t, git.ApplyCached(ctx, files[0].WithHunks([]int{0}).String()))
+	tree, err := git.WriteTree(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, staged, tree)
+}
@@ -2,6 +2,7 @@ package git
 
 import (
 	"context"
+	"strings"
 )
 
 type logArg interface {
@@ -23,3 +24,28 @@ func LogRange(ctx context.Context, options ...logArg) (string, error) {
 
 	return run(ctx, gitArgs...)
 }
+
+// Commit is a commit and its full message.
+type Commit struct {
+	Hash    string
+	Message string
+}
+
+// Messages returns the commits of revRange, newest first. Merge commits
+// are left out since their messages are written by git.
+func Messages(ctx context.Context, revRange string) ([]Commit, error) {
+	res, err := run(ctx, "log", "--no-merges", "--format=%H%x00%B%x1e", revRange, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for record := range strings.SplitSeq(res, "\x1e") {
+		hash, msg, ok := strings.Cut(strings.TrimLeft(record, "\n"), "\x00")
+		if !ok {
+			continue
+		}
+		commits = append(commits, Commit{Hash: hash, Message: strings.TrimSpace(msg)})
+	}
+	return commits, nil
+}
@@ -0,0 +1,45 @@
+package git_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/git"
+)
+
+func TestMessages(t *testing.T) {
+	ctx := context.Background()
+
+	repo, err := SetupGitRepo(ctx)
+	require.NoError(t, err)
+	defer os.RemoveAll(repo)
+
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	for _, msg := range []string{"chore: init", "feat: add a\n\nBody line.\n\nCloses #1", "fix: b"} {
+		cmd := exec.CommandContext(ctx, "git", "commit", "--allow-empty", "-m", msg)
+		cmd.Dir = repo
+		require.NoError(t, cmd.Run())
+	}
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(wd)
+	require.NoError(t, os.Chdir(repo))
+
+	commits, err := git.Messages(ctx, "HEAD~2..HEAD")
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+
+	assert.Equal(t, "fix: b", commits[0].Message)
+	assert.Equal(t, "feat: add a\n\nBody line.\n\nCloses #1", commits[1].Message)
+	assert.Len(t, commits[1].Hash, 40)
+}
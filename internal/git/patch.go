@@ -0,0 +1,228 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FileDiff is the part of a unified diff that concerns a single file.
+type FileDiff struct {
+	OldPath string
+	NewPath string
+	// Header holds every line before the first hunk: "diff --git", mode
+	// changes, index, and the ---/+++ lines.
+	Header []string
+	Hunks  []Hunk
+	Binary bool
+}
+
+// Path returns the path the change is best known by: the new path, or the
+// old one when the file was deleted.
+func (f FileDiff) Path() string {
+	if f.NewPath == "" || f.NewPath == "/dev/null" {
+		return f.OldPath
+	}
+	return f.NewPath
+}
+
+// IsNew reports whether the file is created by the diff.
+func (f FileDiff) IsNew() bool {
+	return f.OldPath == "/dev/null" || f.hasHeader("new file mode")
+}
+
+// IsDeleted reports whether the file is removed by the diff.
+func (f FileDiff) IsDeleted() bool {
+	return f.NewPath == "/dev/null" || f.hasHeader("deleted file mode")
+}
+
+// Stats returns the number of added and removed lines.
+func (f FileDiff) Stats() (added, removed int) {
+	for _, h := range f.Hunks {
+		a, r := h.Stats()
+		added += a
+		removed += r
+	}
+	return added, removed
+}
+
+// Splittable reports whether the hunks of f can be applied separately: f
+// only edits the text of a file without creating, deleting or renaming it
+// or changing its mode.
+func (f FileDiff) Splittable() bool {
+	return len(f.Hunks) > 0 && !f.Binary && !f.IsNew() && !f.IsDeleted() &&
+		!f.hasHeader("rename ") && !f.hasHeader("copy ") && !f.hasHeader("old mode")
+}
+
+// WithHunks returns f restricted to the hunks at the given indexes, kept in
+// the order they appear in f.
+func (f FileDiff) WithHunks(indexes []int) FileDiff {
+	keep := make(map[int]bool, len(indexes))
+	for _, i := range indexes {
+		keep[i] = true
+	}
+
+	out := f
+	out.Hunks = nil
+	for i, h := range f.Hunks {
+		if keep[i] {
+			out.Hunks = append(out.Hunks, h)
+		}
+	}
+	return out
+}
+
+func (f FileDiff) String() string {
+	var b strings.Builder
+	for _, line := range f.Header {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	for _, h := range f.Hunks {
+		b.WriteString(h.String())
+	}
+	return b.String()
+}
+
+func (f FileDiff) hasHeader(prefix string) bool {
+	for _, line := range f.Header {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Hunk is a single "@@ ... @@" section of a file diff.
+type Hunk struct {
+	// Header is the "@@ -a,b +c,d @@ context" line.
+	Header string
+	// Lines are the hunk body lines, each starting with ' ', '+', '-' or '\'.
+	Lines []string
+}
+
+// Stats returns the number of added and removed lines.
+func (h Hunk) Stats() (added, removed int) {
+	for _, line := range h.Lines {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return added, removed
+}
+
+func (h Hunk) String() string {
+	var b strings.Builder
+	b.WriteString(h.Header)
+	b.WriteByte('\n')
+	for _, line := range h.Lines {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// ParsePatch splits the output of git diff into per-file diffs.
+// Text that precedes the first "diff --git" line is ignored.
+func ParsePatch(diff string) []FileDiff {
+	var files []FileDiff
+	var cur *FileDiff
+	var hunk *Hunk
+
+	flushHunk := func() {
+		if cur != nil && hunk != nil {
+			cur.Hunks = append(cur.Hunks, *hunk)
+		}
+		hunk = nil
+	}
+	flushFile := func() {
+		flushHunk()
+		if cur != nil {
+			files = append(files, *cur)
+		}
+		cur = nil
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flushFile()
+			cur = &FileDiff{Header: []string{line}}
+			cur.OldPath, cur.NewPath = parseDiffGitLine(line)
+
+		case cur == nil:
+			continue
+
+		case strings.HasPrefix(line, "@@ "):
+			flushHunk()
+			hunk = &Hunk{Header: line}
+
+		case hunk != nil:
+			hunk.Lines = append(hunk.Lines, line)
+
+		default:
+			cur.Header = append(cur.Header, line)
+			switch {
+			case strings.HasPrefix(line, "--- "):
+				cur.OldPath = trimPathPrefix(strings.TrimPrefix(line, "--- "), "a/")
+			case strings.HasPrefix(line, "+++ "):
+				cur.NewPath = trimPathPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+			case strings.HasPrefix(line, "rename from "):
+				cur.OldPath = unquotePath(strings.TrimPrefix(line, "rename from "))
+			case strings.HasPrefix(line, "rename to "):
+				cur.NewPath = unquotePath(strings.TrimPrefix(line, "rename to "))
+			case strings.HasPrefix(line, "Binary files "), line == "GIT binary patch":
+				cur.Binary = true
+			}
+		}
+	}
+	flushFile()
+
+	return files
+}
+
+// parseDiffGitLine extracts paths from "diff --git a/x b/x", where either
+// path may be C-quoted. Unquoted paths that contain " b/" are ambiguous, so
+// this is only a best effort; ---/+++ and rename lines take precedence.
+func parseDiffGitLine(line string) (string, string) {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	if strings.HasPrefix(rest, `"`) {
+		old, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return "", ""
+		}
+		return trimPathPrefix(old, "a/"), trimPathPrefix(strings.TrimPrefix(rest[len(old):], " "), "b/")
+	}
+	if !strings.HasPrefix(rest, "a/") {
+		return "", ""
+	}
+	if i := strings.Index(rest, ` "b/`); i >= 0 {
+		return rest[2:i], trimPathPrefix(rest[i+1:], "b/")
+	}
+	if i := strings.Index(rest, " b/"); i >= 0 {
+		return rest[2:i], rest[i+3:]
+	}
+	return "", ""
+}
+
+func trimPathPrefix(path, prefix string) string {
+	path = unquotePath(strings.TrimSuffix(path, "\t"))
+	if path == "/dev/null" {
+		return path
+	}
+	return strings.TrimPrefix(path, prefix)
+}
+
+// unquotePath undoes the C-style quoting git applies to paths with special
+// characters, such as "dir/na\303\257ve.go". Other paths are returned as is.
+func unquotePath(path string) string {
+	if !strings.HasPrefix(path, `"`) {
+		return path
+	}
+	if unquoted, err := strconv.Unquote(path); err == nil {
+		return unquoted
+	}
+	return strings.Trim(path, `"`)
+}
@@ -0,0 +1,139 @@
+package git_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/git"
+)
+
+const samplePatch = `diff --git a/main.go b/main.go
+index 83db48f..bf269f4 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@
+ package main
++
++import "fmt"
+-// old
+@@ -10,2 +11,2 @@ func main() {
+-	println("hi")
++	fmt.Println("hi")
+diff --git a/docs/new.md b/docs/new.md
+new file mode 100644
+index 0000000..8ab686e
+--- /dev/null
++++ b/docs/new.md
+@@ -0,0 +1 @@
++Hello
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+index 8ab686e..0000000
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+diff --git a/logo.png b/logo.png
+index 1111111..2222222 100644
+Binary files a/logo.png and b/logo.png differ
+`
+
+func TestParsePatch(t *testing.T) {
+	files := git.ParsePatch(samplePatch)
+	require.Len(t, files, 4)
+
+	assert.Equal(t, "main.go", files[0].Path())
+	require.Len(t, files[0].Hunks, 2)
+	assert.Equal(t, "@@ -1,3 +1,4 @@", files[0].Hunks[0].Header)
+	assert.Equal(t, "@@ -10,2 +11,2 @@ func main() {", files[0].Hunks[1].Header)
+	added, removed := files[0].Stats()
+	assert.Equal(t, 3, added)
+	assert.Equal(t, 2, removed)
+
+	assert.Equal(t, "docs/new.md", files[1].Path())
+	assert.True(t, files[1].IsNew())
+
+	assert.Equal(t, "old.txt", files[2].Path())
+	assert.True(t, files[2].IsDeleted())
+
+	assert.Equal(t, "logo.png", files[3].Path())
+	assert.True(t, files[3].Binary)
+	assert.Empty(t, files[3].Hunks)
+
+	var rendered string
+	for _, f := range files {
+		rendered += f.String()
+	}
+	assert.Equal(t, samplePatch, rendered)
+}
+
+func TestParsePatchQuotedPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		oldPath string
+		newPath string
+	}{
+		{
+			name: "modified",
+			patch: `diff --git "a/dir/na\303\257ve.go" "b/dir/na\303\257ve.go"
+index 83db48f..bf269f4 100644
+--- "a/dir/na\303\257ve.go"
++++ "b/dir/na\303\257ve.go"
+@@ -1 +1 @@
+-package old
++package main
+`,
+			oldPath: "dir/naïve.go",
+			newPath: "dir/naïve.go",
+		},
+		{
+			name: "binary",
+			patch: `diff --git "a/logo \"v2\".png" "b/logo \"v2\".png"
+index 1111111..2222222 100644
+Binary files "a/logo \"v2\".png" and "b/logo \"v2\".png" differ
+`,
+			oldPath: `logo "v2".png`,
+			newPath: `logo "v2".png`,
+		},
+		{
+			name: "renamed",
+			patch: `diff --git a/plain.go "b/na\303\257ve.go"
+similarity index 100%
+rename from plain.go
+rename to "na\303\257ve.go"
+`,
+			oldPath: "plain.go",
+			newPath: "naïve.go",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := git.ParsePatch(tt.patch)
+			require.Len(t, files, 1)
+
+			assert.Equal(t, tt.oldPath, files[0].OldPath)
+			assert.Equal(t, tt.newPath, files[0].NewPath)
+			assert.Equal(t, tt.patch, files[0].String())
+		})
+	}
+}
+
+func TestSplittable(t *testing.T) {
+	files := git.ParsePatch(samplePatch)
+	require.Len(t, files, 4)
+
+	assert.True(t, files[0].Splittable())
+	assert.False(t, files[1].Splittable(), "new file")
+	assert.False(t, files[2].Splittable(), "deleted file")
+	assert.False(t, files[3].Splittable(), "binary file")
+
+	second := files[0].WithHunks([]int{1})
+	require.Len(t, second.Hunks, 1)
+	assert.Equal(t, files[0].Hunks[1], second.Hunks[0])
+	assert.Equal(t, files[0].Header, second.Header)
+	assert.Len(t, files[0].Hunks, 2, "original is left untouched")
+}
@@ -0,0 +1,54 @@
+package git
+
+import "strings"
+
+// Pathspec restricts a diff to (or excludes from it) the paths it matches.
+// Pathspecs are passed after "--" and, unlike other options, never split
+// on whitespace.
+type Pathspec string
+
+func (p Pathspec) String() string { return string(p) }
+func (p Pathspec) isDiffArg()     {}
+
+// Exclude returns pathspecs excluding the paths matched by a gitignore-style
+// pattern. Patterns are resolved from the repository root regardless of the
+// working directory. Negated patterns ("!foo") cannot be expressed as
+// pathspecs and yield nothing.
+func Exclude(pattern string) []Pathspec {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" || strings.HasPrefix(pattern, "#") || strings.HasPrefix(pattern, "!") {
+		return nil
+	}
+	pattern = strings.TrimPrefix(pattern, `\`)
+
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	// A slash anywhere but at the end anchors the pattern to the root;
+	// otherwise it matches at any depth.
+	if strings.Contains(pattern, "/") {
+		pattern = strings.TrimPrefix(pattern, "/")
+	} else {
+		pattern = "**/" + pattern
+	}
+
+	const magic = ":(top,exclude,glob)"
+	if dirOnly {
+		return []Pathspec{Pathspec(magic + pattern + "/**")}
+	}
+	return []Pathspec{
+		Pathspec(magic + pattern),
+		Pathspec(magic + pattern + "/**"),
+	}
+}
+
+// Excludes converts a list of gitignore-style patterns into diff arguments.
+func Excludes(patterns []string) []DiffArg {
+	var args []DiffArg
+	for _, p := range patterns {
+		for _, spec := range Exclude(p) {
+			args = append(args, spec)
+		}
+	}
+	return args
+}
@@ -0,0 +1,75 @@
+package git_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/git"
+)
+
+func TestExclude(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []git.Pathspec
+	}{
+		{"", nil},
+		{"# comment", nil},
+		{"!keep.go", nil},
+		{"*.pb.go", []git.Pathspec{":(top,exclude,glob)**/*.pb.go", ":(top,exclude,glob)**/*.pb.go/**"}},
+		{"vendor/", []git.Pathspec{":(top,exclude,glob)**/vendor/**"}},
+		{"/testdata/fixtures", []git.Pathspec{":(top,exclude,glob)testdata/fixtures", ":(top,exclude,glob)testdata/fixtures/**"}},
+		{"web/__snapshots__/", []git.Pathspec{":(top,exclude,glob)web/__snapshots__/**"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			assert.Equal(t, tt.want, git.Exclude(tt.pattern))
+		})
+	}
+}
+
+func TestStagedDiffExcludes(t *testing.T) {
+	ctx := context.Background()
+
+	repo, err := SetupGitRepo(ctx)
+	require.NoError(t, err)
+	defer os.RemoveAll(repo)
+
+	files := map[string]string{
+		"main.go":               "package main\n",
+		"api/service.pb.go":     "package api\n",
+		"vendor/lib/lib.go":     "package lib\n",
+		"pkg/vendor/note.md":    "nested\n",
+		"testdata/fixtures/a":   "fixture\n",
+		"cmd/testdata/fixtures": "kept: anchored pattern only matches at the root\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(repo, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "add", ".")
+	cmd.Dir = repo
+	require.NoError(t, cmd.Run())
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(wd)
+
+	// Run from a subdirectory: patterns must still apply from the root.
+	require.NoError(t, os.Chdir(filepath.Join(repo, "api")))
+
+	args := append([]git.DiffArg{git.Staged, git.Target("--name-only")},
+		git.Excludes([]string{"*.pb.go", "vendor/", "/testdata/fixtures"})...)
+	diff, err := git.Diff(ctx, args...)
+
+	require.NoError(t, err)
+	assert.Equal(t, "cmd/testdata/fixtures\nmain.go\n", diff)
+}
@@ -0,0 +1,15 @@
+package git
+
+import (
+	"context"
+	"strings"
+)
+
+// RemoteURL returns the URL of the named remote.
+func RemoteURL(ctx context.Context, name string) (string, error) {
+	res, err := run(ctx, "remote", "get-url", name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(res), nil
+}
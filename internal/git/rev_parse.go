@@ -2,6 +2,8 @@ package git
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
 	"strings"
 )
 
@@ -12,3 +14,22 @@ func Root(ctx context.Context) (string, error) {
 	}
 	return strings.TrimSpace(res), nil
 }
+
+// GitPath resolves name inside the git directory, honoring settings that
+// relocate it such as core.hooksPath. The result is absolute.
+//
+// git prints the path relative to the working directory. It is made
+// absolute here rather than with --path-format=absolute, which needs git
+// 2.31 and is echoed back as is by older versions.
+func GitPath(ctx context.Context, name string) (string, error) {
+	res, err := run(ctx, "rev-parse", "--git-path", name)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := filepath.Abs(strings.TrimSpace(res))
+	if err != nil {
+		return "", fmt.Errorf("resolve git path: %w", err)
+	}
+	return path, nil
+}
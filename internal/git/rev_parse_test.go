@@ -0,0 +1,42 @@
+package git_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/git"
+)
+
+func TestGitPath(t *testing.T) {
+	ctx := context.Background()
+
+	repo, err := SetupGitRepo(ctx)
+	require.NoError(t, err)
+	defer os.RemoveAll(repo)
+
+	// Resolve symlinks such as /tmp on macOS so paths compare equal.
+	repo, err = filepath.EvalSymlinks(repo)
+	require.NoError(t, err)
+
+	sub := filepath.Join(repo, "sub")
+	require.NoError(t, os.Mkdir(sub, 0o755))
+	t.Chdir(sub)
+
+	path, err := git.GitPath(ctx, "hooks")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(repo, ".git", "hooks"), path)
+
+	cmd := exec.CommandContext(ctx, "git", "config", "core.hooksPath", "githooks")
+	cmd.Dir = repo
+	require.NoError(t, cmd.Run())
+
+	path, err = git.GitPath(ctx, "hooks")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(repo, "githooks"), path, "a relative core.hooksPath is relative to the top level")
+}
@@ -0,0 +1,32 @@
+package git
+
+import (
+	"context"
+	"strings"
+)
+
+// Tags returns the tags that can be reached from rev, highest version
+// first.
+func Tags(ctx context.Context, rev string) ([]string, error) {
+	res, err := run(ctx, "tag", "--list", "--merged", rev, "--sort=-v:refname")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(res), nil
+}
+
+// CommitDate returns the date rev was committed on, as YYYY-MM-DD.
+func CommitDate(ctx context.Context, rev string) (string, error) {
+	res, err := run(ctx, "log", "-1", "--format=%cs", rev, "--")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(res), nil
+}
+
+// CreateTag creates an annotated tag on HEAD. Lines of msg that start with
+// '#' are kept, so it may hold Markdown headings.
+func CreateTag(ctx context.Context, name, msg string) error {
+	_, err := runWithInput(ctx, msg, nil, "tag", "--annotate", "--cleanup=whitespace", name, "--file", "-")
+	return err
+}
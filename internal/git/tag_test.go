@@ -0,0 +1,60 @@
+package git_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/git"
+)
+
+func TestTags(t *testing.T) {
+	ctx := context.Background()
+
+	repo, err := SetupGitRepo(ctx)
+	require.NoError(t, err)
+	defer os.RemoveAll(repo)
+
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_DATE", "2024-03-05T10:00:00+00:00")
+
+	gitRun := func(args ...string) {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = repo
+		require.NoError(t, cmd.Run())
+	}
+
+	gitRun("commit", "--allow-empty", "-m", "chore: init")
+	gitRun("tag", "v1.9.0")
+	gitRun("commit", "--allow-empty", "-m", "feat: a")
+	gitRun("tag", "v1.10.0")
+	gitRun("checkout", "-q", "-b", "other")
+	gitRun("commit", "--allow-empty", "-m", "fix: b")
+	gitRun("tag", "v2.0.0")
+	gitRun("checkout", "-q", "-")
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(wd)
+	require.NoError(t, os.Chdir(repo))
+
+	tags, err := git.Tags(ctx, "HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1.10.0", "v1.9.0"}, tags)
+
+	date, err := git.CommitDate(ctx, "v1.9.0")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-05", date)
+
+	require.NoError(t, git.CreateTag(ctx, "v1.11.0", "Release v1.11.0\n\n## Features\n"))
+	out, err := exec.CommandContext(ctx, "git", "cat-file", "tag", "v1.11.0").Output()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "\n\nRelease v1.11.0\n\n## Features\n")
+}
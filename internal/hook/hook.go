@@ -0,0 +1,143 @@
+// Package hook installs and runs the git hooks that let ditto write commit
+// messages for plain git commit invocations, e.g. from IDEs.
+package hook
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PrepareCommitMsg is the name of the hook ditto installs.
+const PrepareCommitMsg = "prepare-commit-msg"
+
+// chainedSuffix is appended to a hook that existed before ditto was
+// installed. The ditto hook runs it so both keep working.
+const chainedSuffix = ".pre-ditto"
+
+// marker identifies hooks written by ditto.
+const marker = "# Installed by ditto."
+
+// ErrNotInstalled is returned by Uninstall when the hook was not written by
+// ditto.
+var ErrNotInstalled = errors.New("ditto hook is not installed")
+
+// Script returns the prepare-commit-msg hook that runs executable. It runs
+// ditto first so a chained hook can still adjust the generated message,
+// and never lets a ditto failure block the commit.
+func Script(executable string) string {
+	return fmt.Sprintf(`#!/bin/sh
+%s Remove with: ditto hook uninstall
+ditto=%s
+command -v "$ditto" >/dev/null 2>&1 || ditto=ditto
+"$ditto" hook run %s "$@" || true
+
+chained="$0%s"
+if [ -x "$chained" ]; then
+	exec "$chained" "$@"
+fi
+`, marker, shellQuote(executable), PrepareCommitMsg, chainedSuffix)
+}
+
+// Install writes the hook to dir, creating dir if needed. A hook that was
+// not written by ditto is renamed so that it keeps running after ditto's.
+// It reports whether an existing hook was chained.
+func Install(dir, executable string) (chained bool, err error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return false, fmt.Errorf("create hooks dir: %w", err)
+	}
+
+	path := filepath.Join(dir, PrepareCommitMsg)
+	installed, err := isInstalled(path)
+	if err != nil {
+		return false, err
+	}
+
+	if !installed {
+		if _, err := os.Stat(path); err == nil {
+			if _, err := os.Stat(path + chainedSuffix); err == nil {
+				return false, fmt.Errorf("both %s and %s exist, refusing to overwrite either", path, path+chainedSuffix)
+			}
+			if err := os.Rename(path, path+chainedSuffix); err != nil {
+				return false, fmt.Errorf("chain existing hook: %w", err)
+			}
+			chained = true
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return false, fmt.Errorf("stat hook: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(Script(executable)), 0o755); err != nil {
+		return chained, fmt.Errorf("write hook: %w", err)
+	}
+	return chained, nil
+}
+
+// Uninstall removes the ditto hook from dir and restores the hook it
+// chained, if any. It reports whether a hook was restored.
+func Uninstall(dir string) (restored bool, err error) {
+	path := filepath.Join(dir, PrepareCommitMsg)
+	installed, err := isInstalled(path)
+	if err != nil {
+		return false, err
+	}
+	if !installed {
+		return false, ErrNotInstalled
+	}
+
+	if err := os.Remove(path); err != nil {
+		return false, fmt.Errorf("remove hook: %w", err)
+	}
+
+	if _, err := os.Stat(path + chainedSuffix); err != nil {
+		return false, nil
+	}
+	if err := os.Rename(path+chainedSuffix, path); err != nil {
+		return false, fmt.Errorf("restore chained hook: %w", err)
+	}
+	return true, nil
+}
+
+// ShouldGenerate reports whether a message should be generated for a
+// commit with the given prepare-commit-msg source. Only plain commits,
+// which have no source, qualify: messages from -m/-F, templates, merges,
+// squashes and amends (-c/-C/--amend) are left alone.
+func ShouldGenerate(source string) bool {
+	return source == ""
+}
+
+// WriteMessage puts msg at the top of the commit message file at path,
+// keeping what git already wrote there, such as the status comments.
+func WriteMessage(path, msg string) error {
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read message file: %w", err)
+	}
+
+	content := strings.TrimSpace(msg) + "\n"
+	if rest := strings.TrimLeft(string(existing), "\n"); rest != "" {
+		content += "\n" + rest
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("write message file: %w", err)
+	}
+	return nil
+}
+
+func isInstalled(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("read hook: %w", err)
+	}
+	return strings.Contains(string(data), marker), nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,89 @@
+package hook_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/hook"
+)
+
+func TestInstallChainsExistingHook(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, hook.PrepareCommitMsg)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\necho custom\n"), 0o755))
+
+	chained, err := hook.Install(dir, "/usr/local/bin/ditto")
+	require.NoError(t, err)
+	assert.True(t, chained)
+
+	script, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, hook.Script("/usr/local/bin/ditto"), string(script))
+
+	previous, err := os.ReadFile(path + ".pre-ditto")
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\necho custom\n", string(previous))
+
+	// Reinstalling only refreshes ditto's hook.
+	chained, err = hook.Install(dir, "/opt/ditto")
+	require.NoError(t, err)
+	assert.False(t, chained)
+
+	restored, err := hook.Uninstall(dir)
+	require.NoError(t, err)
+	assert.True(t, restored)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\necho custom\n", string(data))
+	assert.NoFileExists(t, path+".pre-ditto")
+}
+
+func TestInstallCreatesHooksDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".githooks")
+
+	chained, err := hook.Install(dir, "ditto")
+	require.NoError(t, err)
+	assert.False(t, chained)
+
+	info, err := os.Stat(filepath.Join(dir, hook.PrepareCommitMsg))
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&0o100)
+
+	restored, err := hook.Uninstall(dir)
+	require.NoError(t, err)
+	assert.False(t, restored)
+	assert.NoFileExists(t, filepath.Join(dir, hook.PrepareCommitMsg))
+}
+
+func TestUninstallForeignHook(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, hook.PrepareCommitMsg)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"), 0o755))
+
+	_, err := hook.Uninstall(dir)
+	assert.ErrorIs(t, err, hook.ErrNotInstalled)
+	assert.FileExists(t, path)
+}
+
+func TestShouldGenerate(t *testing.T) {
+	assert.True(t, hook.ShouldGenerate(""))
+	for _, source := range []string{"message", "template", "merge", "squash", "commit"} {
+		assert.False(t, hook.ShouldGenerate(source), source)
+	}
+}
+
+func TestWriteMessageKeepsComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	require.NoError(t, os.WriteFile(path, []byte("\n# Please enter the commit message.\n"), 0o644))
+
+	require.NoError(t, hook.WriteMessage(path, "feat: add hook\n\nBody.\n"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add hook\n\nBody.\n\n# Please enter the commit message.\n", string(data))
+}
@@ -0,0 +1,340 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/arthvm/ditto/internal/llm"
+	"github.com/arthvm/ditto/internal/llm/sse"
+)
+
+const (
+	defaultBaseURL   = "https://api.anthropic.com"
+	defaultMaxTokens = 1024
+	apiVersion       = "2023-06-01"
+)
+
+var (
+	// ErrOverloaded is reported when the API is temporarily overloaded (HTTP 529).
+	ErrOverloaded = errors.New("anthropic api: overloaded")
+
+	// ErrRateLimited is reported when the account exceeded its rate limit (HTTP 429).
+	ErrRateLimited = errors.New("anthropic api: rate limited")
+)
+
+// Provider implements workflow.Provider using the Anthropic Messages API.
+type Provider struct {
+	baseURL     string
+	apiKey      string
+	model       string
+	maxTokens   int
+	temperature float32
+}
+
+// Options configures a Provider. BaseURL defaults to the public API and
+// MaxTokens to 1024 when left zero.
+type Options struct {
+	BaseURL     string
+	APIKey      string
+	Model       string
+	MaxTokens   int
+	Temperature float32
+}
+
+// New creates an Anthropic provider. A temperature of 0 uses the model's default.
+func New(opts Options) *Provider {
+	baseURL := opts.BaseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	maxTokens := opts.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = defaultMaxTokens
+	}
+
+	return &Provider{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		apiKey:      opts.APIKey,
+		model:       opts.Model,
+		maxTokens:   maxTokens,
+		temperature: opts.Temperature,
+	}
+}
+
+// APIError is returned when the API answers with a non-200 status or sends an
+// error event mid-stream, in which case StatusCode is zero. It wraps
+// ErrOverloaded or ErrRateLimited when applicable so callers can use errors.Is.
+type APIError struct {
+	StatusCode int
+	Type       string
+	Message    string
+	RetryAfter string
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("anthropic api: %s: %s", e.Type, e.Message)
+	}
+	return fmt.Sprintf("anthropic api: status %d: %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// Status returns the HTTP status. Overload errors reported mid-stream are
+// mapped to 529 so they are treated like their HTTP counterpart.
+func (e *APIError) Status() int {
+	if e.StatusCode == 0 && e.Type == "overloaded_error" {
+		return 529
+	}
+	return e.StatusCode
+}
+
+func (e *APIError) RetryDelay() time.Duration { return llm.ParseRetryAfter(e.RetryAfter) }
+
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == 529 || e.Type == "overloaded_error":
+		return ErrOverloaded
+	case e.StatusCode == http.StatusTooManyRequests || e.Type == "rate_limit_error":
+		return ErrRateLimited
+	default:
+		return nil
+	}
+}
+
+func (p *Provider) Generate(ctx context.Context, system, user string) (string, error) {
+	resp, err := p.send(ctx, system, user, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var msgResp messagesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&msgResp); err != nil {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+
+	var text strings.Builder
+	for _, block := range msgResp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+
+	if text.Len() == 0 {
+		return "", fmt.Errorf("anthropic api: empty response (stop reason %q)", msgResp.StopReason)
+	}
+	llm.RecordUsage(ctx, msgResp.Usage.llmUsage())
+
+	return text.String(), nil
+}
+
+// GenerateStream consumes the Messages API event stream, forwarding
+// text deltas as they arrive. Errors sent mid-stream (e.g. overloaded_error)
+// are mapped the same way as HTTP errors.
+func (p *Provider) GenerateStream(ctx context.Context, system, user string, onChunk func(string)) (string, error) {
+	resp, err := p.send(ctx, system, user, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var full strings.Builder
+	var streamErr error
+	// Input tokens are reported when the message starts and the output
+	// count is updated with every message_delta.
+	var tokens usage
+	err = sse.Read(resp.Body, func(ev sse.Event) bool {
+		switch ev.Name {
+		case "message_start":
+			var start messageStart
+			if json.Unmarshal([]byte(ev.Data), &start) == nil {
+				tokens = start.Message.Usage
+			}
+		case "message_delta":
+			var delta messageDelta
+			if json.Unmarshal([]byte(ev.Data), &delta) == nil && delta.Usage.OutputTokens > 0 {
+				tokens.OutputTokens = delta.Usage.OutputTokens
+			}
+		case "content_block_delta":
+			var delta contentBlockDelta
+			if err := json.Unmarshal([]byte(ev.Data), &delta); err != nil {
+				streamErr = fmt.Errorf("decode chunk: %w", err)
+				return false
+			}
+			if delta.Delta.Type == "text_delta" && delta.Delta.Text != "" {
+				full.WriteString(delta.Delta.Text)
+				onChunk(delta.Delta.Text)
+			}
+		case "error":
+			var errResp errorResponse
+			if err := json.Unmarshal([]byte(ev.Data), &errResp); err != nil {
+				streamErr = fmt.Errorf("decode error event: %w", err)
+				return false
+			}
+			streamErr = &APIError{
+				Type:    errResp.Error.Type,
+				Message: errResp.Error.Message,
+			}
+			return false
+		case "message_stop":
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return "", fmt.Errorf("anthropic stream: %w", err)
+	}
+	if streamErr != nil {
+		return "", streamErr
+	}
+
+	if full.Len() == 0 {
+		return "", errors.New("anthropic api: empty response")
+	}
+	llm.RecordUsage(ctx, tokens.llmUsage())
+
+	return full.String(), nil
+}
+
+// send performs the Messages API request and returns the response when the
+// API answered with 200. The caller must close the body.
+func (p *Provider) send(ctx context.Context, system, user string, stream bool) (*http.Response, error) {
+	if p.apiKey == "" {
+		return nil, errors.New("anthropic: missing api key (set anthropic.api_key or ANTHROPIC_API_KEY)")
+	}
+
+	body, err := p.buildRequest(system, user, stream)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v1/messages", body)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	p.setHeaders(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+
+	return resp, nil
+}
+
+func decodeError(resp *http.Response) error {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: resp.Header.Get("Retry-After"),
+	}
+
+	// Read and decode errors are intentionally ignored: the status code is
+	// already informative and the raw body is kept as the message fallback.
+	raw, _ := io.ReadAll(resp.Body)
+
+	var errResp errorResponse
+	if json.Unmarshal(raw, &errResp) == nil && errResp.Error.Type != "" {
+		apiErr.Type = errResp.Error.Type
+		apiErr.Message = errResp.Error.Message
+	} else {
+		apiErr.Type = "error"
+		apiErr.Message = string(raw)
+	}
+
+	return apiErr
+}
+
+func (p *Provider) setHeaders(req *http.Request) {
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Api-Key", p.apiKey)
+	req.Header.Set("Anthropic-Version", apiVersion)
+}
+
+func (p *Provider) buildRequest(system, user string, stream bool) (*bytes.Buffer, error) {
+	reqBody := messagesRequest{
+		Model:     p.model,
+		MaxTokens: p.maxTokens,
+		System:    system,
+		Stream:    stream,
+		Messages: []message{
+			{Role: "user", Content: user},
+		},
+	}
+	if p.temperature != 0 {
+		reqBody.Temperature = &p.temperature
+	}
+
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(reqBody); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type messagesRequest struct {
+	Model       string    `json:"model"`
+	MaxTokens   int       `json:"max_tokens"`
+	System      string    `json:"system,omitempty"`
+	Messages    []message `json:"messages"`
+	Stream      bool      `json:"stream,omitempty"`
+	Temperature *float32  `json:"temperature,omitempty"`
+}
+
+type messagesResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      usage  `json:"usage"`
+}
+
+type usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+func (u usage) llmUsage() llm.Usage {
+	return llm.Usage{InputTokens: u.InputTokens, OutputTokens: u.OutputTokens}
+}
+
+type messageStart struct {
+	Message struct {
+		Usage usage `json:"usage"`
+	} `json:"message"`
+}
+
+type messageDelta struct {
+	Usage usage `json:"usage"`
+}
+
+type contentBlockDelta struct {
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
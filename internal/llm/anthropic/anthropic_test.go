@@ -0,0 +1,147 @@
+package anthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/llm"
+	"github.com/arthvm/ditto/internal/llm/anthropic"
+)
+
+func TestGenerate(t *testing.T) {
+	var gotBody map[string]any
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+		assert.Equal(t, "2023-06-01", r.Header.Get("Anthropic-Version"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+
+		w.Write([]byte(`{
+			"content": [
+				{"type": "text", "text": "fix(api): "},
+				{"type": "text", "text": "handle nil body"}
+			],
+			"stop_reason": "end_turn"
+		}`))
+	}))
+	defer srv.Close()
+
+	p := anthropic.New(anthropic.Options{
+		BaseURL:   srv.URL,
+		APIKey:    "secret",
+		Model:     "claude-sonnet-4-5",
+		MaxTokens: 512,
+	})
+
+	msg, err := p.Generate(context.Background(), "system prompt", "user prompt")
+	require.NoError(t, err)
+	assert.Equal(t, "fix(api): handle nil body", msg)
+
+	assert.Equal(t, "claude-sonnet-4-5", gotBody["model"])
+	assert.Equal(t, "system prompt", gotBody["system"])
+	assert.EqualValues(t, 512, gotBody["max_tokens"])
+
+	messages := gotBody["messages"].([]any)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "user", messages[0].(map[string]any)["role"])
+	assert.Equal(t, "user prompt", messages[0].(map[string]any)["content"])
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		target error
+	}{
+		{
+			name:   "overloaded",
+			status: 529,
+			body:   `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			target: anthropic.ErrOverloaded,
+		},
+		{
+			name:   "rate limited",
+			status: http.StatusTooManyRequests,
+			body:   `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`,
+			target: anthropic.ErrRateLimited,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "7")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			p := anthropic.New(anthropic.Options{BaseURL: srv.URL, APIKey: "k", Model: "m"})
+
+			_, err := p.Generate(context.Background(), "s", "u")
+			require.Error(t, err)
+			assert.ErrorIs(t, err, tt.target)
+
+			var apiErr *anthropic.APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tt.status, apiErr.StatusCode)
+			assert.Equal(t, "7", apiErr.RetryAfter)
+		})
+	}
+}
+
+func TestGenerateMissingKey(t *testing.T) {
+	p := anthropic.New(anthropic.Options{Model: "m"})
+
+	_, err := p.Generate(context.Background(), "s", "u")
+	assert.ErrorContains(t, err, "missing api key")
+}
+
+func TestGenerateStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":50,\"output_tokens\":1}}}\n\n" +
+			"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"docs: \"}}\n\n" +
+			"event: ping\ndata: {\"type\":\"ping\"}\n\n" +
+			"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"update readme\"}}\n\n" +
+			"event: message_delta\ndata: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":6}}\n\n" +
+			"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"))
+	}))
+	defer srv.Close()
+
+	p := anthropic.New(anthropic.Options{BaseURL: srv.URL, APIKey: "k", Model: "m"})
+
+	var tracker llm.UsageTracker
+	ctx := llm.WithUsageTracker(context.Background(), &tracker)
+
+	var chunks []string
+	msg, err := p.GenerateStream(ctx, "s", "u", func(c string) {
+		chunks = append(chunks, c)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "docs: update readme", msg)
+	assert.Equal(t, []string{"docs: ", "update readme"}, chunks)
+
+	usage, _ := tracker.Usage()
+	assert.Equal(t, llm.Usage{InputTokens: 50, OutputTokens: 6}, usage)
+}
+
+func TestGenerateStreamErrorEvent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n"))
+	}))
+	defer srv.Close()
+
+	p := anthropic.New(anthropic.Options{BaseURL: srv.URL, APIKey: "k", Model: "m"})
+
+	_, err := p.GenerateStream(context.Background(), "s", "u", func(string) {})
+	assert.ErrorIs(t, err, anthropic.ErrOverloaded)
+}
@@ -2,6 +2,7 @@ package copilot
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -19,6 +20,31 @@ import (
 // prevents these calls from hanging indefinitely on network issues.
 var authClient = &http.Client{Timeout: 15 * time.Second}
 
+// errAccessDenied is returned when the user declines the device flow.
+var errAccessDenied = errors.New("access denied by user")
+
+// statusError is a non-200 answer from a GitHub OAuth endpoint.
+type statusError struct {
+	op     string
+	status int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s: status %d", e.op, e.status)
+}
+
+// isPermanent reports whether a failed device flow would fail the same way
+// if started again: the user denied access, or GitHub rejected the client
+// with 401 or 403. Network errors and server errors may clear up.
+func isPermanent(err error) bool {
+	if errors.Is(err, errAccessDenied) {
+		return true
+	}
+	var statusErr *statusError
+	return errors.As(err, &statusErr) &&
+		(statusErr.status == http.StatusUnauthorized || statusErr.status == http.StatusForbidden)
+}
+
 // warnf prints a non-fatal warning to stderr.
 func warnf(format string, args ...any) {
 	fmt.Fprintf(os.Stderr, "warning: "+format+"\n", args...)
@@ -229,9 +255,9 @@ func deviceFlowAuth(clientID string) (*storedToken, error) {
 		return nil, err
 	}
 
-	fmt.Printf("\nTo authenticate with GitHub Copilot:\n")
-	fmt.Printf("  1. Open:  %s\n", verificationURL)
-	fmt.Printf("  2. Enter: %s\n\n", code.UserCode)
+	fmt.Fprintf(os.Stderr, "\nTo authenticate with GitHub Copilot:\n")
+	fmt.Fprintf(os.Stderr, "  1. Open:  %s\n", verificationURL)
+	fmt.Fprintf(os.Stderr, "  2. Enter: %s\n\n", code.UserCode)
 
 	_ = openBrowser(verificationURL) // best-effort; URL is already printed above
 
@@ -258,7 +284,7 @@ func requestDeviceCode(clientID string) (*deviceCodeResponse, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("device code request: status %d", resp.StatusCode)
+		return nil, &statusError{op: "device code request", status: resp.StatusCode}
 	}
 
 	var code deviceCodeResponse
@@ -299,7 +325,7 @@ func pollForToken(clientID string, code *deviceCodeResponse) (*storedToken, erro
 		case "expired_token":
 			return nil, fmt.Errorf("device code expired, please try again")
 		case "access_denied":
-			return nil, fmt.Errorf("access denied by user")
+			return nil, errAccessDenied
 		default:
 			return nil, fmt.Errorf("unexpected error: %s", tokenResp.Error)
 		}
@@ -327,7 +353,7 @@ func exchangeDeviceCode(clientID, deviceCode string) (*tokenResponse, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("token request: status %d", resp.StatusCode)
+		return nil, &statusError{op: "token request", status: resp.StatusCode}
 	}
 
 	var tokenResp tokenResponse
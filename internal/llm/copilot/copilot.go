@@ -1,13 +1,13 @@
 package copilot
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"sync"
+
+	"github.com/arthvm/ditto/internal/llm/openai"
 )
 
 const baseURL = "https://api.githubcopilot.com"
@@ -16,11 +16,24 @@ const baseURL = "https://api.githubcopilot.com"
 // It authenticates with a GitHub OAuth token obtained via the device flow,
 // stored in the system keychain. If the token becomes invalid, it
 // re-authenticates automatically without user intervention.
+//
+// A Provider is safe for concurrent use. When several requests are rejected
+// with the same token, only one of them re-authenticates and the others
+// wait for it and retry with the new token.
 type Provider struct {
 	model       string
 	temperature float32
-	token       string
-	clientID    string
+	baseURL     string
+	// authenticate obtains a new token once the current one is rejected.
+	authenticate func() (string, error)
+
+	mu    sync.Mutex
+	token string
+	// authErr is a permanent failure of the last re-authentication,
+	// returned to every caller holding the same rejected token instead of
+	// starting another device flow. Other failures are not kept, so the
+	// next rejected request tries again.
+	authErr error
 }
 
 func New(model string, temperature float32, apiKey, clientID string) (*Provider, error) {
@@ -32,130 +45,113 @@ func New(model string, temperature float32, apiKey, clientID string) (*Provider,
 	return &Provider{
 		model:       model,
 		temperature: temperature,
-		token:       token,
-		clientID:    clientID,
+		baseURL:     baseURL,
+		authenticate: func() (string, error) {
+			clearStoredToken()
+			return runDeviceFlow(clientID)
+		},
+		token: token,
 	}, nil
 }
 
 func (p *Provider) Generate(ctx context.Context, system, user string) (string, error) {
-	result, err := p.generate(ctx, system, user)
-	if err == nil {
-		return result, nil
-	}
-
-	// On auth failure, clear the stored token and re-authenticate once.
-	if isAuthError(err) {
-		clearStoredToken()
-		newToken, authErr := runDeviceFlow(p.clientID)
-		if authErr != nil {
-			return "", fmt.Errorf("re-authentication failed: %w", authErr)
-		}
-		p.token = newToken
-		return p.generate(ctx, system, user)
-	}
-
-	return "", err
+	return p.withAuth(func(chat *openai.Provider) (string, error) {
+		return chat.Generate(ctx, system, user)
+	})
 }
 
-func (p *Provider) generate(ctx context.Context, system, user string) (string, error) {
-	body, err := p.buildRequest(system, user)
-	if err != nil {
-		return "", fmt.Errorf("build request: %w", err)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/chat/completions", body)
-	if err != nil {
-		return "", fmt.Errorf("new request: %w", err)
-	}
-	p.setHeaders(req)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("copilot request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
-		return "", &authError{status: resp.StatusCode}
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		// Read error is intentionally ignored: the status code is already
-		// informative and a body read failure would obscure the real error.
-		errBody, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("copilot api: status %d: %s", resp.StatusCode, errBody)
-	}
-
-	var chatResp chatCompletionResponse
-	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
-		return "", fmt.Errorf("decode response: %w", err)
-	}
-
-	if len(chatResp.Choices) == 0 {
-		return "", fmt.Errorf("copilot api: empty response")
-	}
-
-	return chatResp.Choices[0].Message.Content, nil
+// GenerateStream retries after re-authentication like Generate. Auth errors
+// are reported before any chunk is emitted, so this never duplicates output.
+func (p *Provider) GenerateStream(ctx context.Context, system, user string, onChunk func(string)) (string, error) {
+	return p.withAuth(func(chat *openai.Provider) (string, error) {
+		return chat.GenerateStream(ctx, system, user, onChunk)
+	})
 }
 
-type authError struct {
-	status int
+// GenerateJSON forwards to the OpenAI-compatible json_schema response format.
+func (p *Provider) GenerateJSON(ctx context.Context, system, user, name string, schema map[string]any) (string, error) {
+	return p.withAuth(func(chat *openai.Provider) (string, error) {
+		return chat.GenerateJSON(ctx, system, user, name, schema)
+	})
 }
 
-func (e *authError) Error() string {
-	return fmt.Sprintf("copilot api: auth error (status %d)", e.status)
+// GenerateJSONStream forwards to the OpenAI-compatible json_schema response
+// format while streaming.
+func (p *Provider) GenerateJSONStream(ctx context.Context, system, user, name string, schema map[string]any, onChunk func(string)) (string, error) {
+	return p.withAuth(func(chat *openai.Provider) (string, error) {
+		return chat.GenerateJSONStream(ctx, system, user, name, schema, onChunk)
+	})
 }
 
-func isAuthError(err error) bool {
-	return errors.As(err, new(*authError))
+// withAuth runs call with the current token. On auth failure, the stored
+// token is cleared and call is retried once after re-authenticating.
+func (p *Provider) withAuth(call func(*openai.Provider) (string, error)) (string, error) {
+	token := p.currentToken()
+	result, err := call(p.chat(token))
+	if err == nil || !isAuthError(err) {
+		return result, err
+	}
+
+	if err := p.reauthenticate(token); err != nil {
+		return "", err
+	}
+	return call(p.chat(p.currentToken()))
 }
 
-func (p *Provider) setHeaders(req *http.Request) {
-	req.Header.Set("Authorization", "Bearer "+p.token)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Editor-Version", "Ditto/1.0")
-	req.Header.Set("Editor-Plugin-Version", "Ditto/1.0")
-	req.Header.Set("Copilot-Integration-Id", "vscode-chat")
+func (p *Provider) currentToken() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.token
 }
 
-func (p *Provider) buildRequest(system, user string) (*bytes.Buffer, error) {
-	messages := []chatMessage{
-		{Role: "system", Content: system},
-		{Role: "user", Content: user},
-	}
+// reauthenticate replaces rejected, the token a request failed with. The
+// lock is held during the device flow so that concurrent callers wait for
+// it, then find the token already replaced and return at once.
+func (p *Provider) reauthenticate(rejected string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	reqBody := chatCompletionRequest{
-		Model:    p.model,
-		Messages: messages,
-		Stream:   false,
+	if p.token != rejected {
+		return nil
 	}
-	if p.temperature != 0 {
-		reqBody.Temperature = &p.temperature
+	if p.authErr != nil {
+		return p.authErr
 	}
 
-	buf := &bytes.Buffer{}
-	if err := json.NewEncoder(buf).Encode(reqBody); err != nil {
-		return nil, err
+	newToken, err := p.authenticate()
+	if err != nil {
+		err = fmt.Errorf("re-authentication failed: %w", err)
+		if isPermanent(err) {
+			p.authErr = err
+		}
+		return err
 	}
-	return buf, nil
+	p.token = newToken
+	return nil
 }
 
-// OpenAI-compatible request/response types.
-
-type chatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+// chat returns an OpenAI-compatible client bound to token. The Copilot API
+// speaks the chat completions protocol and only differs in its base URL and
+// the editor identification headers.
+func (p *Provider) chat(token string) *openai.Provider {
+	return openai.New(openai.Options{
+		Name:        "copilot",
+		BaseURL:     p.baseURL,
+		APIKey:      token,
+		Model:       p.model,
+		Temperature: p.temperature,
+		Headers: map[string]string{
+			"Editor-Version":         "Ditto/1.0",
+			"Editor-Plugin-Version":  "Ditto/1.0",
+			"Copilot-Integration-Id": "vscode-chat",
+		},
+	})
 }
 
-type chatCompletionRequest struct {
-	Model       string        `json:"model"`
-	Messages    []chatMessage `json:"messages"`
-	Stream      bool          `json:"stream"`
-	Temperature *float32      `json:"temperature,omitempty"`
-}
-
-type chatCompletionResponse struct {
-	Choices []struct {
-		Message chatMessage `json:"message"`
-	} `json:"choices"`
+func isAuthError(err error) bool {
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden
 }
@@ -0,0 +1,137 @@
+package copilot_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/llm/copilot"
+)
+
+// newServer answers chat completions for the "fresh" token and rejects
+// any other with 401.
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		assert.Equal(t, "vscode-chat", r.Header.Get("Copilot-Integration-Id"))
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			http.Error(w, `{"error":"bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"feat: add thing"}}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGenerate(t *testing.T) {
+	srv := newServer(t)
+
+	p := copilot.NewForTest(srv.URL, "fresh", func() (string, error) {
+		t.Fatal("unexpected re-authentication")
+		return "", nil
+	})
+
+	msg, err := p.Generate(context.Background(), "system", "user")
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add thing", msg)
+}
+
+func TestGenerateReauthenticatesOnce(t *testing.T) {
+	srv := newServer(t)
+
+	var calls atomic.Int32
+	p := copilot.NewForTest(srv.URL, "expired", func() (string, error) {
+		calls.Add(1)
+		return "fresh", nil
+	})
+
+	const n = 8
+	var wg sync.WaitGroup
+	results := make([]string, n)
+	errs := make([]error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = p.Generate(context.Background(), "system", "user")
+		}()
+	}
+	wg.Wait()
+
+	for i := range n {
+		require.NoError(t, errs[i])
+		assert.Equal(t, "feat: add thing", results[i])
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestGenerateReauthenticationFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "access denied", err: copilot.ErrAccessDenied},
+		{name: "unauthorized", err: copilot.NewStatusError("token request", http.StatusUnauthorized)},
+		{name: "forbidden", err: copilot.NewStatusError("device code request", http.StatusForbidden)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newServer(t)
+
+			var calls atomic.Int32
+			p := copilot.NewForTest(srv.URL, "expired", func() (string, error) {
+				calls.Add(1)
+				return "", tt.err
+			})
+
+			for range 2 {
+				_, err := p.Generate(context.Background(), "system", "user")
+				require.ErrorIs(t, err, tt.err)
+				assert.Contains(t, err.Error(), "re-authentication failed: ")
+			}
+			assert.Equal(t, int32(1), calls.Load(), "a failed device flow is not restarted")
+		})
+	}
+}
+
+func TestGenerateRetriesTransientReauthenticationFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "network error", err: errors.New("token request: connection reset by peer")},
+		{name: "server error", err: copilot.NewStatusError("token request", http.StatusBadGateway)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newServer(t)
+
+			var calls atomic.Int32
+			p := copilot.NewForTest(srv.URL, "expired", func() (string, error) {
+				if calls.Add(1) == 1 {
+					return "", tt.err
+				}
+				return "fresh", nil
+			})
+
+			_, err := p.Generate(context.Background(), "system", "user")
+			require.ErrorIs(t, err, tt.err)
+
+			msg, err := p.Generate(context.Background(), "system", "user")
+			require.NoError(t, err)
+			assert.Equal(t, "feat: add thing", msg)
+			assert.Equal(t, int32(2), calls.Load())
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package copilot
+
+// ErrAccessDenied exposes errAccessDenied to tests.
+var ErrAccessDenied = errAccessDenied
+
+// NewStatusError returns the error of an OAuth endpoint answering status.
+func NewStatusError(op string, status int) error {
+	return &statusError{op: op, status: status}
+}
+
+// NewForTest creates a Provider that talks to baseURL with token and calls
+// authenticate instead of the device flow when the token is rejected.
+func NewForTest(baseURL, token string, authenticate func() (string, error)) *Provider {
+	return &Provider{
+		model:        "gpt-4o",
+		baseURL:      baseURL,
+		authenticate: authenticate,
+		token:        token,
+	}
+}
@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusError is implemented by provider errors caused by an unsuccessful
+// API response. It lets callers such as the retry layer classify failures
+// without knowing each provider's error type.
+type StatusError interface {
+	error
+	// Status returns the HTTP status code of the failed response.
+	Status() int
+	// RetryDelay returns how long the server asked to wait before retrying,
+	// or 0 if it did not say.
+	RetryDelay() time.Duration
+}
+
+// IsSchemaUnsupported reports whether err means the server rejected the
+// JSON schema of a structured request rather than the request as a whole,
+// as OpenAI-compatible servers and older Ollama versions do when they do not
+// implement structured output. Such requests are worth repeating without a
+// schema; other failures are not.
+func IsSchemaUnsupported(err error) bool {
+	var statusErr StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+
+	switch statusErr.Status() {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusNotImplemented:
+	default:
+		return false
+	}
+
+	msg := strings.ToLower(statusErr.Error())
+	return strings.Contains(msg, "schema") || strings.Contains(msg, "format")
+}
+
+// ParseRetryAfter parses a Retry-After header value, which is either a number
+// of seconds or an HTTP date. Invalid or past values yield 0.
+func ParseRetryAfter(v string) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs * float64(time.Second))
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
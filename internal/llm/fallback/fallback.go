@@ -0,0 +1,255 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arthvm/ditto/internal/llm"
+)
+
+// Entry is a named provider in a fallback chain. The provider is built
+// lazily on first use so that a provider which cannot even be constructed
+// (e.g. Copilot without network for authentication) only fails its own turn.
+type Entry struct {
+	Name string
+	New  func() (llm.Provider, error)
+
+	once     sync.Once
+	provider llm.Provider
+	err      error
+}
+
+func (e *Entry) get() (llm.Provider, error) {
+	e.once.Do(func() {
+		e.provider, e.err = e.New()
+	})
+	return e.provider, e.err
+}
+
+// Provider implements workflow.Provider by trying each entry in order until
+// one returns a non-empty response. An entry is skipped when it errors,
+// exceeds the attempt timeout, or returns only whitespace.
+type Provider struct {
+	entries        []*Entry
+	attemptTimeout time.Duration
+	report         func(format string, args ...any)
+
+	mu   sync.Mutex
+	last string
+}
+
+// New creates a fallback chain. attemptTimeout bounds each individual attempt
+// (0 means only the caller's context applies). report, if non-nil, is used to
+// tell the user when a provider fails and which one produced the response.
+//
+// A chain of a single entry only defers building the provider until it is
+// first used: there is nothing to fall back to, so its errors are returned
+// as is and the attempt timeout does not apply.
+func New(entries []*Entry, attemptTimeout time.Duration, report func(format string, args ...any)) *Provider {
+	if report == nil {
+		report = func(string, ...any) {}
+	}
+	return &Provider{entries: entries, attemptTimeout: attemptTimeout, report: report}
+}
+
+// Last returns the name of the provider that produced the latest successful
+// response, or an empty string if none succeeded yet.
+func (p *Provider) Last() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.last
+}
+
+func (p *Provider) Generate(ctx context.Context, system, user string) (string, error) {
+	return p.run(ctx, nil, func(ctx context.Context, provider llm.Provider) (string, error) {
+		return provider.Generate(ctx, system, user)
+	})
+}
+
+// GenerateStream streams from entries that support it and emits the whole
+// response at once for those that do not. Text cannot be taken back once
+// it was emitted: when an entry fails partway through its response, the
+// report says so before the next entry starts over.
+func (p *Provider) GenerateStream(ctx context.Context, system, user string, onChunk func(string)) (string, error) {
+	s := &stream{onChunk: onChunk}
+	return p.run(ctx, s, func(ctx context.Context, provider llm.Provider) (string, error) {
+		if sp, ok := provider.(llm.StreamingProvider); ok {
+			return sp.GenerateStream(ctx, system, user, s.emit)
+		}
+
+		res, err := provider.Generate(ctx, system, user)
+		if err == nil {
+			s.emit(res)
+		}
+		return res, err
+	})
+}
+
+// GenerateJSON asks entries that support structured output for a JSON
+// response and falls back to plain generation for those that do not.
+func (p *Provider) GenerateJSON(ctx context.Context, system, user, name string, schema map[string]any) (string, error) {
+	return p.run(ctx, nil, func(ctx context.Context, provider llm.Provider) (string, error) {
+		if sp, ok := provider.(llm.StructuredProvider); ok {
+			return sp.GenerateJSON(ctx, system, user, name, schema)
+		}
+		return provider.Generate(ctx, system, user)
+	})
+}
+
+// GenerateJSONStream streams structured output from entries that support
+// both, and otherwise degrades like GenerateJSON and GenerateStream do.
+func (p *Provider) GenerateJSONStream(ctx context.Context, system, user, name string, schema map[string]any, onChunk func(string)) (string, error) {
+	s := &stream{onChunk: onChunk}
+	return p.run(ctx, s, func(ctx context.Context, provider llm.Provider) (string, error) {
+		if sp, ok := provider.(llm.StructuredStreamingProvider); ok {
+			return sp.GenerateJSONStream(ctx, system, user, name, schema, s.emit)
+		}
+
+		if sp, ok := provider.(llm.StreamingProvider); ok {
+			if _, structured := provider.(llm.StructuredProvider); !structured {
+				return sp.GenerateStream(ctx, system, user, s.emit)
+			}
+		}
+
+		var res string
+		var err error
+		if sp, ok := provider.(llm.StructuredProvider); ok {
+			res, err = sp.GenerateJSON(ctx, system, user, name, schema)
+		} else {
+			res, err = provider.Generate(ctx, system, user)
+		}
+		if err == nil {
+			s.emit(res)
+		}
+		return res, err
+	})
+}
+
+// stream passes chunks on to onChunk and remembers whether the current
+// attempt emitted any.
+type stream struct {
+	onChunk func(string)
+	emitted bool
+}
+
+func (s *stream) emit(chunk string) {
+	s.emitted = true
+	s.onChunk(chunk)
+}
+
+// SupportsStreaming reports whether the first entry that can be built
+// streams. Later entries only answer when it fails, and degrade as
+// described on GenerateStream.
+func (p *Provider) SupportsStreaming() bool {
+	provider := p.first()
+	return provider != nil && llm.SupportsStreaming(provider)
+}
+
+// SupportsStructured reports whether the first entry that can be built
+// supports structured output.
+func (p *Provider) SupportsStructured() bool {
+	provider := p.first()
+	return provider != nil && llm.SupportsStructured(provider)
+}
+
+// first returns the first entry that can be built, building entries as
+// needed, or nil when none can. Build errors are reported when the entry
+// is used.
+func (p *Provider) first() llm.Provider {
+	for _, entry := range p.entries {
+		if provider, err := entry.get(); err == nil {
+			return provider
+		}
+	}
+	return nil
+}
+
+// run tries the entries in turn. s is the stream of a streaming call, nil
+// otherwise.
+func (p *Provider) run(ctx context.Context, s *stream, call func(context.Context, llm.Provider) (string, error)) (string, error) {
+	if len(p.entries) == 0 {
+		return "", errors.New("fallback: no providers configured")
+	}
+
+	if len(p.entries) == 1 {
+		entry := p.entries[0]
+		provider, err := entry.get()
+		if err != nil {
+			return "", err
+		}
+
+		res, err := call(ctx, provider)
+		if err != nil {
+			return "", err
+		}
+		p.mu.Lock()
+		p.last = entry.Name
+		p.mu.Unlock()
+		return res, nil
+	}
+
+	var errs []error
+	for i, entry := range p.entries {
+		if s != nil {
+			s.emitted = false
+		}
+
+		res, err := p.attempt(ctx, entry, call)
+		if err == nil {
+			p.mu.Lock()
+			p.last = entry.Name
+			p.mu.Unlock()
+
+			if i > 0 {
+				p.report("response generated by %s", entry.Name)
+			}
+			return res, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", entry.Name, err))
+
+		// The caller gave up (cancelled or overall timeout): stop here
+		// rather than starting attempts that cannot finish.
+		if ctx.Err() != nil {
+			break
+		}
+
+		if i < len(p.entries)-1 {
+			next := p.entries[i+1].Name
+			if s != nil && s.emitted {
+				p.report("%s failed partway through its response: %v; discarding the text above and starting over with %s", entry.Name, err, next)
+			} else {
+				p.report("%s failed: %v; falling back to %s", entry.Name, err, next)
+			}
+		}
+	}
+
+	return "", fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+}
+
+func (p *Provider) attempt(ctx context.Context, entry *Entry, call func(context.Context, llm.Provider) (string, error)) (string, error) {
+	provider, err := entry.get()
+	if err != nil {
+		return "", err
+	}
+
+	if p.attemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.attemptTimeout)
+		defer cancel()
+	}
+
+	res, err := call(ctx, provider)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(res) == "" {
+		return "", errors.New("empty response")
+	}
+
+	return res, nil
+}
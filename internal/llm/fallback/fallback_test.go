@@ -0,0 +1,299 @@
+package fallback_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/llm"
+	"github.com/arthvm/ditto/internal/llm/fallback"
+)
+
+type stubProvider struct {
+	res   string
+	err   error
+	delay time.Duration
+	calls int
+}
+
+func (s *stubProvider) Generate(ctx context.Context, _, _ string) (string, error) {
+	s.calls++
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	return s.res, s.err
+}
+
+type structuredStub struct {
+	stubProvider
+	schemas []string
+}
+
+func (s *structuredStub) GenerateJSON(ctx context.Context, system, user, name string, _ map[string]any) (string, error) {
+	s.schemas = append(s.schemas, name)
+	return s.Generate(ctx, system, user)
+}
+
+func entry(name string, p llm.Provider) *fallback.Entry {
+	return &fallback.Entry{Name: name, New: func() (llm.Provider, error) { return p, nil }}
+}
+
+func TestGenerateFallsBack(t *testing.T) {
+	tests := []struct {
+		name    string
+		primary *fallback.Entry
+	}{
+		{
+			name:    "error",
+			primary: entry("copilot", &stubProvider{err: errors.New("auth failed")}),
+		},
+		{
+			name:    "empty response",
+			primary: entry("copilot", &stubProvider{res: "  \n"}),
+		},
+		{
+			name:    "attempt timeout",
+			primary: entry("copilot", &stubProvider{res: "late", delay: time.Second}),
+		},
+		{
+			name: "construction error",
+			primary: &fallback.Entry{Name: "copilot", New: func() (llm.Provider, error) {
+				return nil, errors.New("no network")
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reports []string
+			report := func(format string, args ...any) {
+				reports = append(reports, fmt.Sprintf(format, args...))
+			}
+
+			p := fallback.New([]*fallback.Entry{
+				tt.primary,
+				entry("ollama", &stubProvider{res: "feat: offline"}),
+			}, 50*time.Millisecond, report)
+
+			res, err := p.Generate(context.Background(), "s", "u")
+			require.NoError(t, err)
+			assert.Equal(t, "feat: offline", res)
+			assert.Equal(t, "ollama", p.Last())
+			require.Len(t, reports, 2)
+			assert.Contains(t, reports[0], "copilot failed")
+			assert.Equal(t, "response generated by ollama", reports[1])
+		})
+	}
+}
+
+func TestGenerateStopsAtFirstSuccess(t *testing.T) {
+	second := &stubProvider{res: "unused"}
+	p := fallback.New([]*fallback.Entry{
+		entry("copilot", &stubProvider{res: "fix: ok"}),
+		entry("ollama", second),
+	}, 0, nil)
+
+	res, err := p.Generate(context.Background(), "s", "u")
+	require.NoError(t, err)
+	assert.Equal(t, "fix: ok", res)
+	assert.Equal(t, "copilot", p.Last())
+	assert.Zero(t, second.calls)
+}
+
+func TestGenerateAllFail(t *testing.T) {
+	p := fallback.New([]*fallback.Entry{
+		entry("copilot", &stubProvider{err: errors.New("boom")}),
+		entry("ollama", &stubProvider{err: errors.New("connection refused")}),
+	}, 0, nil)
+
+	_, err := p.Generate(context.Background(), "s", "u")
+	assert.ErrorContains(t, err, "copilot: boom")
+	assert.ErrorContains(t, err, "ollama: connection refused")
+}
+
+func TestGenerateStopsWhenCallerCancels(t *testing.T) {
+	second := &stubProvider{res: "unused"}
+	p := fallback.New([]*fallback.Entry{
+		entry("copilot", &stubProvider{res: "slow", delay: time.Second}),
+		entry("ollama", second),
+	}, 0, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := p.Generate(ctx, "s", "u")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Zero(t, second.calls)
+}
+
+func TestSingleEntry(t *testing.T) {
+	boom := errors.New("boom")
+
+	tests := []struct {
+		name     string
+		entry    *fallback.Entry
+		wantRes  string
+		wantErr  error
+		wantLast string
+	}{
+		{
+			name:     "success",
+			entry:    entry("copilot", &stubProvider{res: "feat: ok", delay: 20 * time.Millisecond}),
+			wantRes:  "feat: ok",
+			wantLast: "copilot",
+		},
+		{
+			name:    "error is returned as is",
+			entry:   entry("copilot", &stubProvider{err: boom}),
+			wantErr: boom,
+		},
+		{
+			name: "construction error is returned as is",
+			entry: &fallback.Entry{Name: "copilot", New: func() (llm.Provider, error) {
+				return nil, boom
+			}},
+			wantErr: boom,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reports []string
+			report := func(format string, args ...any) {
+				reports = append(reports, fmt.Sprintf(format, args...))
+			}
+			// The attempt timeout is shorter than the provider's delay: it
+			// only applies when there is something to fall back to.
+			p := fallback.New([]*fallback.Entry{tt.entry}, time.Millisecond, report)
+
+			res, err := p.Generate(context.Background(), "s", "u")
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err, "not wrapped in an all providers failed error")
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantRes, res)
+			assert.Equal(t, tt.wantLast, p.Last())
+			assert.Empty(t, reports)
+		})
+	}
+}
+
+func TestSingleEntryIsLazy(t *testing.T) {
+	built := 0
+	p := fallback.New([]*fallback.Entry{{Name: "copilot", New: func() (llm.Provider, error) {
+		built++
+		return &stubProvider{res: "feat: ok"}, nil
+	}}}, 0, nil)
+	assert.Zero(t, built, "building the chain does not build the provider")
+
+	for range 2 {
+		_, err := p.Generate(context.Background(), "s", "u")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, built)
+}
+
+func TestGenerateJSONFallsBackToPlainGeneration(t *testing.T) {
+	primary := &structuredStub{stubProvider: stubProvider{err: errors.New("down")}}
+	secondary := &stubProvider{res: "title\n\nbody"}
+
+	p := fallback.New([]*fallback.Entry{
+		entry("openai", primary),
+		entry("anthropic", secondary),
+	}, 0, nil)
+
+	res, err := p.GenerateJSON(context.Background(), "s", "u", "pull_request", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "title\n\nbody", res)
+	assert.Equal(t, []string{"pull_request"}, primary.schemas)
+	assert.Equal(t, 1, secondary.calls)
+}
+
+func TestSupportsReportsFirstBuildableEntry(t *testing.T) {
+	p := fallback.New([]*fallback.Entry{
+		{Name: "copilot", New: func() (llm.Provider, error) { return nil, errors.New("no network") }},
+		entry("anthropic", &stubProvider{}),
+		entry("openai", &structuredStub{}),
+	}, 0, nil)
+
+	assert.False(t, p.SupportsStructured())
+	assert.False(t, p.SupportsStreaming())
+
+	p = fallback.New([]*fallback.Entry{entry("openai", &structuredStub{})}, 0, nil)
+	assert.True(t, p.SupportsStructured())
+}
+
+func TestGenerateJSONStreamEmitsWholeResponseWithoutStreaming(t *testing.T) {
+	primary := &structuredStub{stubProvider: stubProvider{res: `{"title":"t"}`}}
+	p := fallback.New([]*fallback.Entry{entry("openai", primary)}, 0, nil)
+
+	var chunks []string
+	res, err := p.GenerateJSONStream(context.Background(), "s", "u", "pull_request", nil, func(c string) {
+		chunks = append(chunks, c)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"title":"t"}`, res)
+	assert.Equal(t, []string{`{"title":"t"}`}, chunks)
+	assert.Equal(t, []string{"pull_request"}, primary.schemas)
+}
+
+// streamStub streams chunks and then fails with err, if set.
+type streamStub struct {
+	stubProvider
+	chunks []string
+}
+
+func (s *streamStub) GenerateStream(ctx context.Context, system, user string, onChunk func(string)) (string, error) {
+	for _, c := range s.chunks {
+		onChunk(c)
+	}
+	return s.Generate(ctx, system, user)
+}
+
+func TestGenerateStreamMarksDiscardedOutput(t *testing.T) {
+	primary := &streamStub{stubProvider: stubProvider{err: errors.New("connection reset")}, chunks: []string{"feat: add"}}
+	secondary := &streamStub{stubProvider: stubProvider{res: "fix: handle"}, chunks: []string{"fix: ", "handle"}}
+
+	var reports []string
+	report := func(format string, args ...any) {
+		reports = append(reports, fmt.Sprintf(format, args...))
+	}
+	p := fallback.New([]*fallback.Entry{entry("openai", primary), entry("ollama", secondary)}, 0, report)
+
+	var chunks []string
+	res, err := p.GenerateStream(context.Background(), "s", "u", func(c string) {
+		chunks = append(chunks, c)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "fix: handle", res)
+	assert.Equal(t, []string{"feat: add", "fix: ", "handle"}, chunks)
+	require.Len(t, reports, 2)
+	assert.Equal(t, "openai failed partway through its response: connection reset; discarding the text above and starting over with ollama", reports[0])
+	assert.Equal(t, "response generated by ollama", reports[1])
+}
+
+func TestGenerateStreamFailureBeforeOutput(t *testing.T) {
+	primary := &streamStub{stubProvider: stubProvider{err: errors.New("unauthorized")}}
+	secondary := &streamStub{stubProvider: stubProvider{res: "fix: handle"}, chunks: []string{"fix: handle"}}
+
+	var reports []string
+	report := func(format string, args ...any) {
+		reports = append(reports, fmt.Sprintf(format, args...))
+	}
+	p := fallback.New([]*fallback.Entry{entry("openai", primary), entry("ollama", secondary)}, 0, report)
+
+	_, err := p.GenerateStream(context.Background(), "s", "u", func(string) {})
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	assert.Equal(t, "openai failed: unauthorized; falling back to ollama", reports[0])
+}
@@ -2,10 +2,16 @@ package gemini
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 	"sync"
+	"time"
 
 	"google.golang.org/genai"
+
+	"github.com/arthvm/ditto/internal/llm"
 )
 
 // Provider implements workflow.Provider using the Google Gemini API.
@@ -34,18 +40,26 @@ func (p *Provider) getClient(ctx context.Context) (*genai.Client, error) {
 }
 
 func (p *Provider) Generate(ctx context.Context, system, user string) (string, error) {
+	return p.generate(ctx, user, p.config(system))
+}
+
+// GenerateJSON sets a JSON response MIME type and passes schema as the
+// response schema.
+func (p *Provider) GenerateJSON(ctx context.Context, system, user, _ string, schema map[string]any) (string, error) {
+	return p.generate(ctx, user, p.jsonConfig(system, schema))
+}
+
+// GenerateJSONStream is GenerateJSON with the response streamed.
+func (p *Provider) GenerateJSONStream(ctx context.Context, system, user, _ string, schema map[string]any, onChunk func(string)) (string, error) {
+	return p.stream(ctx, user, p.jsonConfig(system, schema), onChunk)
+}
+
+func (p *Provider) generate(ctx context.Context, user string, cfg *genai.GenerateContentConfig) (string, error) {
 	client, err := p.getClient(ctx)
 	if err != nil {
 		return "", fmt.Errorf("generate client: %w", err)
 	}
 
-	cfg := &genai.GenerateContentConfig{
-		SystemInstruction: genai.NewContentFromText(system, genai.RoleUser),
-	}
-	if p.temperature != 0 {
-		cfg.Temperature = &p.temperature
-	}
-
 	result, err := client.Models.GenerateContent(
 		ctx,
 		p.model,
@@ -53,8 +67,116 @@ func (p *Provider) Generate(ctx context.Context, system, user string) (string, e
 		cfg,
 	)
 	if err != nil {
-		return "", err
+		return "", wrapError(err)
 	}
+	recordUsage(ctx, result.UsageMetadata)
 
 	return result.Text(), nil
 }
+
+func (p *Provider) GenerateStream(ctx context.Context, system, user string, onChunk func(string)) (string, error) {
+	return p.stream(ctx, user, p.config(system), onChunk)
+}
+
+func (p *Provider) stream(ctx context.Context, user string, cfg *genai.GenerateContentConfig, onChunk func(string)) (string, error) {
+	client, err := p.getClient(ctx)
+	if err != nil {
+		return "", fmt.Errorf("generate client: %w", err)
+	}
+
+	var full strings.Builder
+	// Each chunk carries the usage so far; the last one holds the totals.
+	var usage *genai.GenerateContentResponseUsageMetadata
+	for result, err := range client.Models.GenerateContentStream(
+		ctx,
+		p.model,
+		genai.Text(user),
+		cfg,
+	) {
+		if err != nil {
+			return "", wrapError(err)
+		}
+		if result.UsageMetadata != nil {
+			usage = result.UsageMetadata
+		}
+
+		text := result.Text()
+		if text == "" {
+			continue
+		}
+		full.WriteString(text)
+		onChunk(text)
+	}
+	recordUsage(ctx, usage)
+
+	return full.String(), nil
+}
+
+// recordUsage reports token usage to the tracker in ctx. Thinking tokens
+// are billed as output, so they are counted with the candidates.
+func recordUsage(ctx context.Context, u *genai.GenerateContentResponseUsageMetadata) {
+	if u == nil {
+		return
+	}
+	llm.RecordUsage(ctx, llm.Usage{
+		InputTokens:  int(u.PromptTokenCount),
+		OutputTokens: int(u.CandidatesTokenCount + u.ThoughtsTokenCount),
+	})
+}
+
+func (p *Provider) jsonConfig(system string, schema map[string]any) *genai.GenerateContentConfig {
+	cfg := p.config(system)
+	cfg.ResponseMIMEType = "application/json"
+	cfg.ResponseJsonSchema = schema
+	return cfg
+}
+
+func (p *Provider) config(system string) *genai.GenerateContentConfig {
+	cfg := &genai.GenerateContentConfig{
+		SystemInstruction: genai.NewContentFromText(system, genai.RoleUser),
+	}
+	if p.temperature != 0 {
+		cfg.Temperature = &p.temperature
+	}
+	return cfg
+}
+
+// apiError adapts genai.APIError to llm.StatusError.
+type apiError struct {
+	genai.APIError
+}
+
+func (e *apiError) Unwrap() error { return e.APIError }
+
+// Status maps RESOURCE_EXHAUSTED to 429 since quota errors are not always
+// reported with that HTTP code.
+func (e *apiError) Status() int {
+	if e.APIError.Status == "RESOURCE_EXHAUSTED" {
+		return http.StatusTooManyRequests
+	}
+	return e.Code
+}
+
+// RetryDelay reads the google.rpc.RetryInfo detail that accompanies
+// quota errors, e.g. {"retryDelay": "17s"}.
+func (e *apiError) RetryDelay() time.Duration {
+	for _, detail := range e.Details {
+		if t, _ := detail["@type"].(string); !strings.HasSuffix(t, "google.rpc.RetryInfo") {
+			continue
+		}
+		if v, ok := detail["retryDelay"].(string); ok {
+			if d, err := time.ParseDuration(v); err == nil {
+				return d
+			}
+		}
+	}
+	return 0
+}
+
+func wrapError(err error) error {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return &apiError{APIError: apiErr}
+	}
+	return err
+}
@@ -1,11 +1,17 @@
 package ollama
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"time"
+
+	"github.com/arthvm/ditto/internal/llm"
 )
 
 // Provider implements workflow.Provider using the Ollama local API.
@@ -20,6 +26,22 @@ func New(host, model string, temperature float32) *Provider {
 	return &Provider{host: host, model: model, temperature: temperature}
 }
 
+// APIError is returned when the server answers with a non-200 status, e.g.
+// 404 for a model that was not pulled or 503 while it is being loaded.
+type APIError struct {
+	StatusCode int
+	Body       string
+	RetryAfter string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("ollama api: status %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *APIError) Status() int { return e.StatusCode }
+
+func (e *APIError) RetryDelay() time.Duration { return llm.ParseRetryAfter(e.RetryAfter) }
+
 type generateOptions struct {
 	Temperature float32 `json:"temperature,omitempty"`
 }
@@ -31,45 +53,148 @@ type generateRequestBody struct {
 	Stream  bool            `json:"stream"`
 	Raw     bool            `json:"raw"`
 	Options generateOptions `json:"options,omitempty"`
+	// Format constrains the response to a JSON schema.
+	Format map[string]any `json:"format,omitempty"`
 }
 
 type generateResponseBody struct {
 	Response string `json:"response"`
+	Done     bool   `json:"done"`
+	Error    string `json:"error"`
+	// Token counts are only set on the final response.
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+}
+
+func (b generateResponseBody) usage() llm.Usage {
+	return llm.Usage{InputTokens: b.PromptEvalCount, OutputTokens: b.EvalCount}
 }
 
 func (p *Provider) Generate(ctx context.Context, system, user string) (string, error) {
+	return p.generate(ctx, system, user, nil)
+}
+
+// GenerateJSON passes schema as the request format, which Ollama enforces
+// through constrained decoding.
+func (p *Provider) GenerateJSON(ctx context.Context, system, user, _ string, schema map[string]any) (string, error) {
+	return p.generate(ctx, system, user, schema)
+}
+
+func (p *Provider) generate(ctx context.Context, system, user string, format map[string]any) (string, error) {
+	res, err := p.send(ctx, system, user, false, format)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var resBody generateResponseBody
+
+	if err := json.NewDecoder(res.Body).Decode(&resBody); err != nil {
+		return "", fmt.Errorf("decode body: %w", err)
+	}
+	llm.RecordUsage(ctx, resBody.usage())
+
+	return resBody.Response, nil
+}
+
+// GenerateStream uses the newline-delimited JSON stream returned by
+// /api/generate when stream is enabled.
+func (p *Provider) GenerateStream(ctx context.Context, system, user string, onChunk func(string)) (string, error) {
+	return p.stream(ctx, system, user, nil, onChunk)
+}
+
+// GenerateJSONStream streams a response constrained to schema.
+func (p *Provider) GenerateJSONStream(ctx context.Context, system, user, _ string, schema map[string]any, onChunk func(string)) (string, error) {
+	return p.stream(ctx, system, user, schema, onChunk)
+}
+
+func (p *Provider) stream(ctx context.Context, system, user string, format map[string]any, onChunk func(string)) (string, error) {
+	res, err := p.send(ctx, system, user, true, format)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var full strings.Builder
+	done := false
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var chunk generateResponseBody
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return "", fmt.Errorf("decode chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return "", fmt.Errorf("ollama: %s", chunk.Error)
+		}
+
+		if chunk.Response != "" {
+			full.WriteString(chunk.Response)
+			onChunk(chunk.Response)
+		}
+		if chunk.Done {
+			llm.RecordUsage(ctx, chunk.usage())
+			done = true
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("read stream: %w", err)
+	}
+	// The connection closed before the final chunk: what arrived is only
+	// part of the response.
+	if !done {
+		return "", fmt.Errorf("read stream: %w", io.ErrUnexpectedEOF)
+	}
+
+	return full.String(), nil
+}
+
+func (p *Provider) send(ctx context.Context, system, user string, stream bool, format map[string]any) (*http.Response, error) {
 	url := fmt.Sprintf("%s/api/generate", p.host)
 
 	body := generateRequestBody{
 		Model:   p.model,
 		System:  system,
 		Prompt:  user,
-		Stream:  false,
+		Stream:  stream,
 		Raw:     false,
 		Options: generateOptions{Temperature: p.temperature},
+		Format:  format,
 	}
 	bodyBuf := &bytes.Buffer{}
 
 	if err := json.NewEncoder(bodyBuf).Encode(body); err != nil {
-		return "", fmt.Errorf("encode body: %w", err)
+		return nil, fmt.Errorf("encode body: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bodyBuf)
 	if err != nil {
-		return "", fmt.Errorf("new request: %w", err)
+		return nil, fmt.Errorf("new request: %w", err)
 	}
 
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("http do: %w", err)
+		return nil, fmt.Errorf("http do: %w", err)
 	}
-	defer res.Body.Close()
-
-	var resBody generateResponseBody
 
-	if err := json.NewDecoder(res.Body).Decode(&resBody); err != nil {
-		return "", fmt.Errorf("decode body: %w", err)
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		// Read error is intentionally ignored: the status code is already
+		// informative and a body read failure would obscure the real error.
+		errBody, _ := io.ReadAll(res.Body)
+		return nil, &APIError{
+			StatusCode: res.StatusCode,
+			Body:       string(errBody),
+			RetryAfter: res.Header.Get("Retry-After"),
+		}
 	}
 
-	return resBody.Response, nil
+	return res, nil
 }
@@ -0,0 +1,60 @@
+package ollama_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/llm"
+	"github.com/arthvm/ditto/internal/llm/ollama"
+)
+
+func TestGenerateStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/generate", r.URL.Path)
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, true, body["stream"])
+
+		w.Write([]byte(`{"response":"feat: ","done":false}` + "\n" +
+			`{"response":"stream","done":false}` + "\n" +
+			`{"response":"","done":true,"prompt_eval_count":120,"eval_count":15}` + "\n"))
+	}))
+	defer srv.Close()
+
+	p := ollama.New(srv.URL, "m", 0)
+
+	var tracker llm.UsageTracker
+	ctx := llm.WithUsageTracker(context.Background(), &tracker)
+
+	var chunks []string
+	msg, err := p.GenerateStream(ctx, "s", "u", func(c string) {
+		chunks = append(chunks, c)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "feat: stream", msg)
+	assert.Equal(t, []string{"feat: ", "stream"}, chunks)
+
+	usage, _ := tracker.Usage()
+	assert.Equal(t, llm.Usage{InputTokens: 120, OutputTokens: 15}, usage)
+}
+
+func TestGenerateStreamClosedBeforeDone(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"response":"feat: ","done":false}` + "\n"))
+	}))
+	defer srv.Close()
+
+	p := ollama.New(srv.URL, "m", 0)
+
+	msg, err := p.GenerateStream(context.Background(), "s", "u", func(string) {})
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Empty(t, msg)
+}
@@ -0,0 +1,304 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/arthvm/ditto/internal/llm"
+	"github.com/arthvm/ditto/internal/llm/sse"
+)
+
+const defaultBaseURL = "https://api.openai.com/v1"
+
+// Provider implements workflow.Provider against any OpenAI-compatible
+// chat completions endpoint (OpenAI, vLLM, LM Studio, LiteLLM, ...).
+type Provider struct {
+	name         string
+	baseURL      string
+	apiKey       string
+	organization string
+	model        string
+	temperature  float32
+	headers      map[string]string
+}
+
+// Options configures a Provider. Only Model is required: BaseURL defaults to
+// the public OpenAI API and an empty APIKey omits the Authorization header,
+// which is what most self-hosted servers expect.
+type Options struct {
+	// Name is used to prefix error messages (e.g. "copilot"). Defaults to "openai".
+	Name         string
+	BaseURL      string
+	APIKey       string
+	Organization string
+	Model        string
+	Temperature  float32
+	// Headers are sent with every request, after the standard ones, so they
+	// can override them if needed.
+	Headers map[string]string
+}
+
+// New creates an OpenAI-compatible provider. A temperature of 0 uses the
+// model's default.
+func New(opts Options) *Provider {
+	name := opts.Name
+	if name == "" {
+		name = "openai"
+	}
+
+	baseURL := opts.BaseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	return &Provider{
+		name:         name,
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		apiKey:       opts.APIKey,
+		organization: opts.Organization,
+		model:        opts.Model,
+		temperature:  opts.Temperature,
+		headers:      opts.Headers,
+	}
+}
+
+// APIError is returned when the endpoint answers with a non-200 status.
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+	RetryAfter string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s api: status %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+func (e *APIError) Status() int { return e.StatusCode }
+
+func (e *APIError) RetryDelay() time.Duration { return llm.ParseRetryAfter(e.RetryAfter) }
+
+func (p *Provider) Generate(ctx context.Context, system, user string) (string, error) {
+	return p.complete(ctx, system, user, nil)
+}
+
+// GenerateJSON requests a response that matches schema through the
+// json_schema response format.
+func (p *Provider) GenerateJSON(ctx context.Context, system, user, name string, schema map[string]any) (string, error) {
+	return p.complete(ctx, system, user, &responseFormat{
+		Type:       "json_schema",
+		JSONSchema: &jsonSchema{Name: name, Schema: schema, Strict: true},
+	})
+}
+
+// GenerateJSONStream streams a response that matches schema.
+func (p *Provider) GenerateJSONStream(ctx context.Context, system, user, name string, schema map[string]any, onChunk func(string)) (string, error) {
+	return p.stream(ctx, system, user, &responseFormat{
+		Type:       "json_schema",
+		JSONSchema: &jsonSchema{Name: name, Schema: schema, Strict: true},
+	}, onChunk)
+}
+
+// complete runs a non-streaming chat completion.
+func (p *Provider) complete(ctx context.Context, system, user string, format *responseFormat) (string, error) {
+	resp, err := p.send(ctx, p.buildRequest(system, user, false, format))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var chatResp chatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return "", fmt.Errorf("decode response: %w", err)
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("%s api: empty response", p.name)
+	}
+	chatResp.Usage.record(ctx)
+
+	return chatResp.Choices[0].Message.Content, nil
+}
+
+func (p *Provider) GenerateStream(ctx context.Context, system, user string, onChunk func(string)) (string, error) {
+	return p.stream(ctx, system, user, nil, onChunk)
+}
+
+// stream runs a streaming chat completion.
+func (p *Provider) stream(ctx context.Context, system, user string, format *responseFormat, onChunk func(string)) (string, error) {
+	resp, err := p.send(ctx, p.buildRequest(system, user, true, format))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var full strings.Builder
+	var decodeErr error
+	err = sse.Read(resp.Body, func(ev sse.Event) bool {
+		if ev.Data == "[DONE]" {
+			return false
+		}
+
+		var chunk chatCompletionChunk
+		if err := json.Unmarshal([]byte(ev.Data), &chunk); err != nil {
+			decodeErr = fmt.Errorf("decode chunk: %w", err)
+			return false
+		}
+		// Endpoints that report usage while streaming send it in the
+		// final chunk, which has no choices.
+		chunk.Usage.record(ctx)
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			return true
+		}
+
+		text := chunk.Choices[0].Delta.Content
+		full.WriteString(text)
+		onChunk(text)
+		return true
+	})
+	if err != nil {
+		return "", fmt.Errorf("%s stream: %w", p.name, err)
+	}
+	if decodeErr != nil {
+		return "", decodeErr
+	}
+
+	if full.Len() == 0 {
+		return "", fmt.Errorf("%s api: empty response", p.name)
+	}
+
+	return full.String(), nil
+}
+
+// send performs the chat completions request and returns the response when
+// the endpoint answered with 200. The caller must close the body.
+func (p *Provider) send(ctx context.Context, reqBody chatCompletionRequest) (*http.Response, error) {
+	body := &bytes.Buffer{}
+	if err := json.NewEncoder(body).Encode(reqBody); err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/chat/completions", body)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	p.setHeaders(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s request: %w", p.name, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		// Read error is intentionally ignored: the status code is already
+		// informative and a body read failure would obscure the real error.
+		errBody, _ := io.ReadAll(resp.Body)
+		return nil, &APIError{
+			Provider:   p.name,
+			StatusCode: resp.StatusCode,
+			Body:       string(errBody),
+			RetryAfter: resp.Header.Get("Retry-After"),
+		}
+	}
+
+	return resp, nil
+}
+
+func (p *Provider) setHeaders(req *http.Request) {
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	if p.organization != "" {
+		req.Header.Set("OpenAI-Organization", p.organization)
+	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
+}
+
+func (p *Provider) buildRequest(system, user string, stream bool, format *responseFormat) chatCompletionRequest {
+	messages := []chatMessage{
+		{Role: "system", Content: system},
+		{Role: "user", Content: user},
+	}
+
+	reqBody := chatCompletionRequest{
+		Model:          p.model,
+		Messages:       messages,
+		Stream:         stream,
+		ResponseFormat: format,
+	}
+	if stream {
+		// Without this the endpoint reports no usage while streaming.
+		reqBody.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+	if p.temperature != 0 {
+		reqBody.Temperature = &p.temperature
+	}
+	return reqBody
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionRequest struct {
+	Model          string          `json:"model"`
+	Messages       []chatMessage   `json:"messages"`
+	Stream         bool            `json:"stream"`
+	StreamOptions  *streamOptions  `json:"stream_options,omitempty"`
+	Temperature    *float32        `json:"temperature,omitempty"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type responseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *jsonSchema `json:"json_schema,omitempty"`
+}
+
+type jsonSchema struct {
+	Name   string         `json:"name"`
+	Schema map[string]any `json:"schema"`
+	Strict bool           `json:"strict"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Usage *usage `json:"usage"`
+}
+
+type chatCompletionChunk struct {
+	Choices []struct {
+		Delta chatMessage `json:"delta"`
+	} `json:"choices"`
+	Usage *usage `json:"usage"`
+}
+
+type usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// record reports u to the usage tracker in ctx. A nil usage, sent by
+// endpoints that do not report it, is ignored.
+func (u *usage) record(ctx context.Context) {
+	if u == nil {
+		return
+	}
+	llm.RecordUsage(ctx, llm.Usage{InputTokens: u.PromptTokens, OutputTokens: u.CompletionTokens})
+}
@@ -0,0 +1,211 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/llm"
+	"github.com/arthvm/ditto/internal/llm/openai"
+)
+
+func TestGenerate(t *testing.T) {
+	var gotBody map[string]any
+	var gotHeaders http.Header
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		gotHeaders = r.Header.Clone()
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"feat: add thing"}}]}`))
+	}))
+	defer srv.Close()
+
+	p := openai.New(openai.Options{
+		BaseURL:      srv.URL + "/v1/",
+		APIKey:       "secret",
+		Organization: "org-1",
+		Model:        "qwen2.5-coder",
+		Headers:      map[string]string{"X-Team": "platform"},
+	})
+
+	msg, err := p.Generate(context.Background(), "system prompt", "user prompt")
+	require.NoError(t, err)
+	assert.Equal(t, "feat: add thing", msg)
+
+	assert.Equal(t, "Bearer secret", gotHeaders.Get("Authorization"))
+	assert.Equal(t, "org-1", gotHeaders.Get("OpenAI-Organization"))
+	assert.Equal(t, "platform", gotHeaders.Get("X-Team"))
+
+	assert.Equal(t, "qwen2.5-coder", gotBody["model"])
+	assert.NotContains(t, gotBody, "temperature")
+	messages := gotBody["messages"].([]any)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "system", messages[0].(map[string]any)["role"])
+	assert.Equal(t, "user prompt", messages[1].(map[string]any)["content"])
+}
+
+func TestGenerateWithoutAPIKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer srv.Close()
+
+	p := openai.New(openai.Options{BaseURL: srv.URL, Model: "local"})
+
+	msg, err := p.Generate(context.Background(), "s", "u")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", msg)
+}
+
+func TestGenerateJSON(t *testing.T) {
+	var gotBody map[string]any
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+		w.Write([]byte(`{"choices":[{"message":{"content":"{\"title\":\"Add thing\"}"}}]}`))
+	}))
+	defer srv.Close()
+
+	p := openai.New(openai.Options{BaseURL: srv.URL, Model: "local"})
+
+	schema := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"title": map[string]any{"type": "string"}},
+	}
+	msg, err := p.GenerateJSON(context.Background(), "s", "u", "pull_request", schema)
+	require.NoError(t, err)
+	assert.Equal(t, `{"title":"Add thing"}`, msg)
+
+	format := gotBody["response_format"].(map[string]any)
+	assert.Equal(t, "json_schema", format["type"])
+	jsonSchema := format["json_schema"].(map[string]any)
+	assert.Equal(t, "pull_request", jsonSchema["name"])
+	assert.Equal(t, true, jsonSchema["strict"])
+	assert.Equal(t, "object", jsonSchema["schema"].(map[string]any)["type"])
+	assert.Equal(t, false, gotBody["stream"])
+}
+
+func TestGenerateOmitsResponseFormat(t *testing.T) {
+	var gotBody map[string]any
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer srv.Close()
+
+	p := openai.New(openai.Options{BaseURL: srv.URL, Model: "local"})
+
+	_, err := p.Generate(context.Background(), "s", "u")
+	require.NoError(t, err)
+	assert.NotContains(t, gotBody, "response_format")
+	assert.NotContains(t, gotBody, "stream_options")
+}
+
+func TestGenerateRecordsUsage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}],"usage":{"prompt_tokens":120,"completion_tokens":15}}`))
+	}))
+	defer srv.Close()
+
+	p := openai.New(openai.Options{BaseURL: srv.URL, Model: "local"})
+
+	var tracker llm.UsageTracker
+	ctx := llm.WithUsageTracker(context.Background(), &tracker)
+
+	_, err := p.Generate(ctx, "s", "u")
+	require.NoError(t, err)
+	_, err = p.Generate(ctx, "s", "u")
+	require.NoError(t, err)
+
+	usage, requests := tracker.Usage()
+	assert.Equal(t, llm.Usage{InputTokens: 240, OutputTokens: 30}, usage)
+	assert.Equal(t, 2, requests)
+}
+
+func TestGenerateAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"bad key"}`))
+	}))
+	defer srv.Close()
+
+	p := openai.New(openai.Options{Name: "gateway", BaseURL: srv.URL, Model: "m"})
+
+	_, err := p.Generate(context.Background(), "s", "u")
+
+	var apiErr *openai.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.EqualError(t, err, `gateway api: status 401: {"error":"bad key"}`)
+}
+
+func TestGenerateStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, true, body["stream"])
+		assert.Equal(t, map[string]any{"include_usage": true}, body["stream_options"])
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(": keep-alive\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"content\":\"feat: \"}}]}\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"content\":\"stream\"}}]}\n\n" +
+			"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":120,\"completion_tokens\":15}}\n\n" +
+			"data: [DONE]\n\n"))
+	}))
+	defer srv.Close()
+
+	p := openai.New(openai.Options{BaseURL: srv.URL, Model: "m"})
+
+	var tracker llm.UsageTracker
+	ctx := llm.WithUsageTracker(context.Background(), &tracker)
+
+	var chunks []string
+	msg, err := p.GenerateStream(ctx, "s", "u", func(c string) {
+		chunks = append(chunks, c)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "feat: stream", msg)
+	assert.Equal(t, []string{"feat: ", "stream"}, chunks)
+
+	usage, requests := tracker.Usage()
+	assert.Equal(t, llm.Usage{InputTokens: 120, OutputTokens: 15}, usage)
+	assert.Equal(t, 1, requests)
+}
+
+func TestGenerateJSONStream(t *testing.T) {
+	var gotBody map[string]any
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"{\\\"title\\\":\"}}]}\n\n" +
+			"data: {\"choices\":[{\"delta\":{\"content\":\"\\\"Add thing\\\"}\"}}]}\n\n" +
+			"data: [DONE]\n\n"))
+	}))
+	defer srv.Close()
+
+	p := openai.New(openai.Options{BaseURL: srv.URL, Model: "m"})
+
+	var chunks []string
+	msg, err := p.GenerateJSONStream(context.Background(), "s", "u", "pull_request", map[string]any{"type": "object"}, func(c string) {
+		chunks = append(chunks, c)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"title":"Add thing"}`, msg)
+	assert.Equal(t, []string{`{"title":`, `"Add thing"}`}, chunks)
+	assert.Equal(t, true, gotBody["stream"])
+	assert.Equal(t, "json_schema", gotBody["response_format"].(map[string]any)["type"])
+}
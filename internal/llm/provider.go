@@ -6,3 +6,55 @@ import "context"
 type Provider interface {
 	Generate(ctx context.Context, system, user string) (string, error)
 }
+
+// StreamingProvider is implemented by providers that can emit the response
+// incrementally. onChunk is called with each piece of text as it arrives and
+// the full response is returned once generation completes.
+type StreamingProvider interface {
+	Provider
+	GenerateStream(ctx context.Context, system, user string, onChunk func(string)) (string, error)
+}
+
+// StructuredProvider is implemented by providers that can constrain the
+// response to a JSON schema using a native feature of their API. name
+// identifies the schema for APIs that require one. The response is the JSON
+// document as text.
+type StructuredProvider interface {
+	Provider
+	GenerateJSON(ctx context.Context, system, user, name string, schema map[string]any) (string, error)
+}
+
+// StructuredStreamingProvider is implemented by providers that can stream a
+// response constrained to a JSON schema. onChunk receives pieces of the JSON
+// document as they arrive.
+type StructuredStreamingProvider interface {
+	StructuredProvider
+	GenerateJSONStream(ctx context.Context, system, user, name string, schema map[string]any, onChunk func(string)) (string, error)
+}
+
+// Capabilities is implemented by providers that wrap others, such as the
+// retry and fallback layers. They implement every optional interface and
+// degrade when the wrapped provider lacks the feature, so a type assertion
+// says nothing about what will actually happen; these methods do.
+type Capabilities interface {
+	SupportsStreaming() bool
+	SupportsStructured() bool
+}
+
+// SupportsStreaming reports whether p emits responses incrementally.
+func SupportsStreaming(p Provider) bool {
+	if c, ok := p.(Capabilities); ok {
+		return c.SupportsStreaming()
+	}
+	_, ok := p.(StreamingProvider)
+	return ok
+}
+
+// SupportsStructured reports whether p constrains responses to a schema.
+func SupportsStructured(p Provider) bool {
+	if c, ok := p.(Capabilities); ok {
+		return c.SupportsStructured()
+	}
+	_, ok := p.(StructuredProvider)
+	return ok
+}
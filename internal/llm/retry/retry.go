@@ -0,0 +1,214 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/arthvm/ditto/internal/llm"
+)
+
+// Policy controls how failed generations are retried.
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. It doubles on each
+	// subsequent retry, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Provider wraps another provider and retries transient failures with
+// exponential backoff. A Retry-After delay requested by the server is
+// honored when it is longer than the computed backoff. Retries never
+// outlive the context deadline: when the next attempt could not start
+// before it, the last error is returned immediately.
+type Provider struct {
+	provider llm.Provider
+	policy   Policy
+}
+
+// New wraps provider with the given retry policy.
+func New(provider llm.Provider, policy Policy) *Provider {
+	return &Provider{provider: provider, policy: policy}
+}
+
+func (p *Provider) Generate(ctx context.Context, system, user string) (string, error) {
+	return p.do(ctx, func() (string, bool, error) {
+		res, err := p.provider.Generate(ctx, system, user)
+		return res, true, err
+	})
+}
+
+// GenerateStream retries only while nothing has been emitted yet: once a
+// chunk reached the caller, replaying the response would duplicate output.
+func (p *Provider) GenerateStream(ctx context.Context, system, user string, onChunk func(string)) (string, error) {
+	sp, ok := p.provider.(llm.StreamingProvider)
+	if !ok {
+		res, err := p.Generate(ctx, system, user)
+		if err == nil {
+			onChunk(res)
+		}
+		return res, err
+	}
+
+	return p.do(ctx, func() (string, bool, error) {
+		emitted := false
+		res, err := sp.GenerateStream(ctx, system, user, func(chunk string) {
+			emitted = true
+			onChunk(chunk)
+		})
+		return res, !emitted, err
+	})
+}
+
+// GenerateJSON retries structured generation, or plain generation when the
+// wrapped provider does not support it.
+func (p *Provider) GenerateJSON(ctx context.Context, system, user, name string, schema map[string]any) (string, error) {
+	sp, ok := p.provider.(llm.StructuredProvider)
+	if !ok {
+		return p.Generate(ctx, system, user)
+	}
+
+	return p.do(ctx, func() (string, bool, error) {
+		res, err := sp.GenerateJSON(ctx, system, user, name, schema)
+		return res, true, err
+	})
+}
+
+// GenerateJSONStream retries structured streaming like GenerateStream. It
+// degrades to GenerateJSON, emitting the whole document at once, or to
+// GenerateStream when the wrapped provider lacks one of the two features.
+func (p *Provider) GenerateJSONStream(ctx context.Context, system, user, name string, schema map[string]any, onChunk func(string)) (string, error) {
+	sp, ok := p.provider.(llm.StructuredStreamingProvider)
+	switch {
+	case ok:
+	case llm.SupportsStructured(p.provider):
+		res, err := p.GenerateJSON(ctx, system, user, name, schema)
+		if err == nil {
+			onChunk(res)
+		}
+		return res, err
+	default:
+		return p.GenerateStream(ctx, system, user, onChunk)
+	}
+
+	return p.do(ctx, func() (string, bool, error) {
+		emitted := false
+		res, err := sp.GenerateJSONStream(ctx, system, user, name, schema, func(chunk string) {
+			emitted = true
+			onChunk(chunk)
+		})
+		return res, !emitted, err
+	})
+}
+
+// SupportsStreaming reports whether the wrapped provider streams.
+func (p *Provider) SupportsStreaming() bool {
+	return llm.SupportsStreaming(p.provider)
+}
+
+// SupportsStructured reports whether the wrapped provider supports
+// structured output.
+func (p *Provider) SupportsStructured() bool {
+	return llm.SupportsStructured(p.provider)
+}
+
+// do runs attempt until it succeeds, fails permanently, or the policy or
+// deadline is exhausted. attempt reports whether it is safe to retry.
+func (p *Provider) do(ctx context.Context, attempt func() (string, bool, error)) (string, error) {
+	for n := 1; ; n++ {
+		res, retryable, err := attempt()
+		if err == nil {
+			return res, nil
+		}
+
+		if !retryable || n >= p.policy.MaxAttempts || !IsTransient(err) || ctx.Err() != nil {
+			return "", err
+		}
+
+		delay := p.backoff(n)
+		if d := retryDelay(err); d > delay {
+			delay = d
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			return "", fmt.Errorf("%w (not retrying: next attempt in %s would exceed the timeout)", err, delay.Round(time.Second))
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return "", err
+		}
+	}
+}
+
+// backoff returns the delay before retry n (1-based), with jitter so that
+// concurrent callers do not retry in lockstep.
+func (p *Provider) backoff(n int) time.Duration {
+	d := p.policy.InitialBackoff
+	for i := 1; i < n && d < p.policy.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.policy.MaxBackoff > 0 && d > p.policy.MaxBackoff {
+		d = p.policy.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+// IsTransient reports whether err is worth retrying: rate limits, server
+// errors, and dropped or timed out connections. Cancellation and deadline
+// errors from the caller's context are never transient.
+func IsTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var statusErr llm.StatusError
+	if errors.As(err, &statusErr) {
+		status := statusErr.Status()
+		return status == http.StatusTooManyRequests ||
+			status == http.StatusRequestTimeout ||
+			status >= http.StatusInternalServerError
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func retryDelay(err error) time.Duration {
+	var statusErr llm.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryDelay()
+	}
+	return 0
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,136 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/llm/retry"
+)
+
+type statusErr struct {
+	status int
+	delay  time.Duration
+}
+
+func (e *statusErr) Error() string             { return fmt.Sprintf("status %d", e.status) }
+func (e *statusErr) Status() int               { return e.status }
+func (e *statusErr) RetryDelay() time.Duration { return e.delay }
+
+type flakyProvider struct {
+	errs   []error
+	calls  int
+	chunks []string
+}
+
+func (f *flakyProvider) Generate(_ context.Context, _, _ string) (string, error) {
+	f.calls++
+	if f.calls <= len(f.errs) {
+		return "", f.errs[f.calls-1]
+	}
+	return "feat: done", nil
+}
+
+func (f *flakyProvider) GenerateStream(ctx context.Context, s, u string, onChunk func(string)) (string, error) {
+	for _, c := range f.chunks {
+		onChunk(c)
+	}
+	return f.Generate(ctx, s, u)
+}
+
+var fastPolicy = retry.Policy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     5 * time.Millisecond,
+}
+
+func TestGenerateRetriesTransientErrors(t *testing.T) {
+	inner := &flakyProvider{errs: []error{
+		&statusErr{status: http.StatusTooManyRequests},
+		fmt.Errorf("read: %w", syscall.ECONNRESET),
+	}}
+
+	res, err := retry.New(inner, fastPolicy).Generate(context.Background(), "s", "u")
+	require.NoError(t, err)
+	assert.Equal(t, "feat: done", res)
+	assert.Equal(t, 3, inner.calls)
+}
+
+func TestGenerateGivesUpAfterMaxAttempts(t *testing.T) {
+	inner := &flakyProvider{errs: []error{
+		&statusErr{status: http.StatusBadGateway},
+		&statusErr{status: http.StatusBadGateway},
+		&statusErr{status: http.StatusBadGateway},
+	}}
+
+	_, err := retry.New(inner, fastPolicy).Generate(context.Background(), "s", "u")
+	assert.EqualError(t, err, "status 502")
+	assert.Equal(t, 3, inner.calls)
+}
+
+func TestGenerateDoesNotRetryPermanentErrors(t *testing.T) {
+	inner := &flakyProvider{errs: []error{&statusErr{status: http.StatusUnauthorized}}}
+
+	_, err := retry.New(inner, fastPolicy).Generate(context.Background(), "s", "u")
+	assert.Error(t, err)
+	assert.Equal(t, 1, inner.calls)
+}
+
+func TestGenerateRespectsDeadline(t *testing.T) {
+	inner := &flakyProvider{errs: []error{&statusErr{status: http.StatusTooManyRequests, delay: time.Minute}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err := retry.New(inner, fastPolicy).Generate(ctx, "s", "u")
+	assert.ErrorContains(t, err, "would exceed the timeout")
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, 1, inner.calls)
+}
+
+func TestGenerateStreamDoesNotRetryAfterOutput(t *testing.T) {
+	inner := &flakyProvider{
+		errs:   []error{&statusErr{status: http.StatusServiceUnavailable}},
+		chunks: []string{"partial"},
+	}
+
+	_, err := retry.New(inner, fastPolicy).GenerateStream(context.Background(), "s", "u", func(string) {})
+	assert.Error(t, err)
+	assert.Equal(t, 1, inner.calls)
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limited", &statusErr{status: 429}, true},
+		{"server error", &statusErr{status: 503}, true},
+		{"overloaded", &statusErr{status: 529}, true},
+		{"bad request", &statusErr{status: 400}, false},
+		{"connection reset", fmt.Errorf("do: %w", syscall.ECONNRESET), true},
+		{"deadline", context.DeadlineExceeded, false},
+		{"other", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, retry.IsTransient(tt.err))
+		})
+	}
+}
+
+func TestSupportsReportsWrappedProvider(t *testing.T) {
+	p := retry.New(&flakyProvider{}, fastPolicy)
+	assert.True(t, p.SupportsStreaming())
+	assert.False(t, p.SupportsStructured())
+}
@@ -0,0 +1,64 @@
+// Package sse reads Server-Sent Events streams as used by the OpenAI and
+// Anthropic streaming APIs.
+package sse
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Event is a single dispatched server-sent event.
+type Event struct {
+	Name string
+	Data string
+}
+
+// Read parses events from r and calls fn for each of them until the stream
+// ends or fn returns false. Comment lines and unknown fields are ignored.
+func Read(r io.Reader, fn func(Event) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var ev Event
+	var data []string
+	dispatch := func() bool {
+		if len(data) == 0 {
+			ev = Event{}
+			return true
+		}
+		ev.Data = strings.Join(data, "\n")
+		cont := fn(ev)
+		ev, data = Event{}, nil
+		return cont
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if !dispatch() {
+				return nil
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			ev.Name = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	dispatch()
+	return nil
+}
@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// Usage is the number of tokens billed for one or more requests.
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// UsageTracker accumulates the usage that providers report for requests
+// made with a context returned by WithUsageTracker. It is safe for
+// concurrent use.
+type UsageTracker struct {
+	mu       sync.Mutex
+	usage    Usage
+	requests int
+}
+
+type usageTrackerKey struct{}
+
+// WithUsageTracker returns a context whose requests are accounted in t.
+func WithUsageTracker(ctx context.Context, t *UsageTracker) context.Context {
+	return context.WithValue(ctx, usageTrackerKey{}, t)
+}
+
+// RecordUsage adds u to the tracker attached to ctx, if any. Providers call
+// it once per successful request that reported usage.
+func RecordUsage(ctx context.Context, u Usage) {
+	t, ok := ctx.Value(usageTrackerKey{}).(*UsageTracker)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.usage.InputTokens += u.InputTokens
+	t.usage.OutputTokens += u.OutputTokens
+	t.requests++
+}
+
+// Usage returns the total usage and the number of requests that reported it.
+func (t *UsageTracker) Usage() (Usage, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.usage, t.requests
+}
@@ -2,17 +2,24 @@ package platform
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/arthvm/ditto/internal/workflow"
 )
 
 // GitHub implements the workflow.Platform interface using the gh CLI
 // and GitHub-specific conventions (e.g. .github/pull_request_template.md).
-type GitHub struct{}
+type GitHub struct {
+	// Out receives the output of gh pr create. Nil means os.Stdout.
+	Out io.Writer
+}
 
 func (g GitHub) FindPRTemplate(repoRoot, customPath string) (string, error) {
 	if customPath != "" {
@@ -67,11 +74,45 @@ func (g GitHub) OpenPR(ctx context.Context, params workflow.OpenPRParams) error
 		args = append(args, "--draft")
 	}
 
+	for _, label := range params.Labels {
+		args = append(args, "--label", label)
+	}
+
 	cmd := exec.CommandContext(ctx, "gh", args...)
 
 	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = g.Out
+	if g.Out == nil {
+		cmd.Stdout = os.Stdout
+	}
 	cmd.Stderr = os.Stderr
 
 	return cmd.Run()
 }
+
+// Issue reads a GitHub issue with gh issue view. id is the issue number,
+// with or without a leading '#'.
+func (g GitHub) Issue(ctx context.Context, id string) (workflow.Issue, error) {
+	number := strings.TrimPrefix(id, "#")
+	if number == "" || strings.Trim(number, "0123456789") != "" {
+		return workflow.Issue{}, fmt.Errorf("%q is not a GitHub issue number", id)
+	}
+
+	out, err := exec.CommandContext(ctx, "gh", "issue", "view", number, "--json", "title,body").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return workflow.Issue{}, fmt.Errorf("gh issue view: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return workflow.Issue{}, fmt.Errorf("gh issue view: %w", err)
+	}
+
+	var issue struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	}
+	if err := json.Unmarshal(out, &issue); err != nil {
+		return workflow.Issue{}, fmt.Errorf("decode issue: %w", err)
+	}
+	return workflow.Issue{ID: id, Title: issue.Title, Body: issue.Body}, nil
+}
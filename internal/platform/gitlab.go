@@ -0,0 +1,313 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/arthvm/ditto/internal/workflow"
+)
+
+// GitLab implements the workflow.Platform interface for GitLab merge
+// requests, through the REST API when a token is set and the glab CLI
+// otherwise.
+type GitLab struct {
+	// Out receives the output of glab mr create, or the URL of the merge
+	// request. Nil means os.Stdout.
+	Out io.Writer
+	// BaseURL is the address of the GitLab instance, such as
+	// https://gitlab.com.
+	BaseURL string
+	// Project is the path of the project, such as group/subgroup/project.
+	Project string
+	// Token authenticates REST API calls. Empty means glab is used.
+	Token string
+}
+
+// mrTemplatesDir is where GitLab looks for merge request description
+// templates.
+var mrTemplatesDir = filepath.Join(".gitlab", "merge_request_templates")
+
+// FindPRTemplate returns the merge request template: customPath when it
+// exists, otherwise Default.md in .gitlab/merge_request_templates/, which
+// GitLab applies by default, or the only template of that directory.
+func (g GitLab) FindPRTemplate(repoRoot, customPath string) (string, error) {
+	if customPath != "" {
+		p := customPath
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(repoRoot, p)
+		}
+		content, err := os.ReadFile(p)
+		if err == nil {
+			return string(content), nil
+		}
+
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("mr template: %w", err)
+		}
+	}
+
+	dir := filepath.Join(repoRoot, mrTemplatesDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("mr template: %w", err)
+	}
+
+	var templates []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.EqualFold(filepath.Ext(e.Name()), ".md") {
+			templates = append(templates, e.Name())
+		}
+	}
+
+	i := slices.IndexFunc(templates, func(name string) bool { return strings.EqualFold(name, "Default.md") })
+	switch {
+	case i >= 0:
+	case len(templates) == 1:
+		i = 0
+	default:
+		return "", nil
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, templates[i]))
+	if err != nil {
+		return "", fmt.Errorf("mr template: %w", err)
+	}
+	return string(content), nil
+}
+
+// OpenPR opens a merge request from params.Head into params.Base. With
+// UseEditor, the title and description are edited in the git editor first.
+func (g GitLab) OpenPR(ctx context.Context, params workflow.OpenPRParams) error {
+	if params.UseEditor {
+		var err error
+		params.Title, params.Body, err = editPR(ctx, params.Title, params.Body)
+		if err != nil {
+			return err
+		}
+	}
+
+	if g.Token != "" {
+		return g.createMR(ctx, params)
+	}
+	if _, err := exec.LookPath("glab"); err != nil {
+		return errors.New("opening a merge request needs a GitLab token (gitlab.token or GITLAB_TOKEN) or the glab CLI")
+	}
+
+	args := []string{
+		"mr", "create",
+		"--title", params.Title,
+		"--description", params.Body,
+		"--target-branch", params.Base,
+		"--source-branch", params.Head,
+		"--yes",
+	}
+
+	if params.Draft {
+		args = append(args, "--draft")
+	}
+
+	if len(params.Labels) > 0 {
+		args = append(args, "--label", strings.Join(params.Labels, ","))
+	}
+
+	cmd := exec.CommandContext(ctx, "glab", args...)
+
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = g.out()
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+type mergeRequest struct {
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	Labels       string `json:"labels,omitempty"`
+}
+
+// createMR opens the merge request through the REST API.
+func (g GitLab) createMR(ctx context.Context, params workflow.OpenPRParams) error {
+	title := params.Title
+	if params.Draft {
+		title = "Draft: " + title
+	}
+
+	var created struct {
+		WebURL string `json:"web_url"`
+	}
+	err := g.api(ctx, http.MethodPost, "merge_requests", mergeRequest{
+		SourceBranch: params.Head,
+		TargetBranch: params.Base,
+		Title:        title,
+		Description:  params.Body,
+		Labels:       strings.Join(params.Labels, ","),
+	}, &created)
+	if err != nil {
+		return fmt.Errorf("create merge request: %w", err)
+	}
+
+	fmt.Fprintln(g.out(), created.WebURL)
+	return nil
+}
+
+// Issue reads a GitLab issue. id is the issue number, with or without a
+// leading '#'.
+func (g GitLab) Issue(ctx context.Context, id string) (workflow.Issue, error) {
+	number := strings.TrimPrefix(id, "#")
+	if number == "" || strings.Trim(number, "0123456789") != "" {
+		return workflow.Issue{}, fmt.Errorf("%q is not a GitLab issue number", id)
+	}
+
+	var issue struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	}
+	if g.Token != "" {
+		if err := g.api(ctx, http.MethodGet, "issues/"+number, nil, &issue); err != nil {
+			return workflow.Issue{}, fmt.Errorf("get issue: %w", err)
+		}
+	} else {
+		out, err := exec.CommandContext(ctx, "glab", "api", g.projectPath("issues/"+number)).Output()
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+				return workflow.Issue{}, fmt.Errorf("glab api: %s", strings.TrimSpace(string(exitErr.Stderr)))
+			}
+			return workflow.Issue{}, fmt.Errorf("glab api: %w", err)
+		}
+		if err := json.Unmarshal(out, &issue); err != nil {
+			return workflow.Issue{}, fmt.Errorf("decode issue: %w", err)
+		}
+	}
+
+	return workflow.Issue{ID: id, Title: issue.Title, Body: issue.Description}, nil
+}
+
+// api calls an endpoint of the project and decodes the response into
+// out. A nil in sends no body.
+func (g GitLab) api(ctx context.Context, method, endpoint string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("build request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	endpointURL := strings.TrimSuffix(g.BaseURL, "/") + "/api/v4/" + g.projectPath(endpoint)
+	req, err := http.NewRequestWithContext(ctx, method, endpointURL, body)
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("PRIVATE-TOKEN", g.Token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("gitlab request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// GitLab explains errors in a message field, which may be a string,
+		// a list or an object keyed by field.
+		var apiErr struct {
+			Message any `json:"message"`
+			Error   any `json:"error"`
+		}
+		errBody, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(errBody, &apiErr) == nil {
+			if apiErr.Message != nil {
+				return fmt.Errorf("gitlab api: %s: %v", resp.Status, apiErr.Message)
+			}
+			if apiErr.Error != nil {
+				return fmt.Errorf("gitlab api: %s: %v", resp.Status, apiErr.Error)
+			}
+		}
+		return fmt.Errorf("gitlab api: %s: %s", resp.Status, strings.TrimSpace(string(errBody)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// projectPath returns the API path of an endpoint of the project.
+func (g GitLab) projectPath(endpoint string) string {
+	return "projects/" + url.PathEscape(g.Project) + "/" + endpoint
+}
+
+func (g GitLab) out() io.Writer {
+	if g.Out == nil {
+		return os.Stdout
+	}
+	return g.Out
+}
+
+// editPR opens the title and description in the editor git is configured
+// to use. The first line is the title and the rest the description. Unlike
+// commit messages, '#' lines are kept since descriptions are Markdown.
+func editPR(ctx context.Context, title, body string) (string, string, error) {
+	f, err := os.CreateTemp("", "ditto-mr-*.md")
+	if err != nil {
+		return "", "", fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(title + "\n\n" + body + "\n"); err != nil {
+		f.Close()
+		return "", "", fmt.Errorf("write temp file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", "", fmt.Errorf("write temp file: %w", err)
+	}
+
+	editor := "vi"
+	if out, err := exec.CommandContext(ctx, "git", "var", "GIT_EDITOR").Output(); err == nil {
+		if e := strings.TrimSpace(string(out)); e != "" {
+			editor = e
+		}
+	}
+
+	// The editor may carry arguments, so it runs through the shell like git
+	// runs it.
+	cmd := exec.CommandContext(ctx, "sh", "-c", editor+` "$@"`, editor, f.Name())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", "", fmt.Errorf("run editor: %w", err)
+	}
+
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", "", fmt.Errorf("read temp file: %w", err)
+	}
+
+	title, body, _ = strings.Cut(strings.TrimSpace(string(edited)), "\n")
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", "", errors.New("empty merge request title")
+	}
+	return title, strings.TrimSpace(body), nil
+}
@@ -0,0 +1,122 @@
+package platform_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/platform"
+	"github.com/arthvm/ditto/internal/workflow"
+)
+
+func TestGitLabFindPRTemplate(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, ".gitlab", "merge_request_templates")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+
+	g := platform.GitLab{}
+
+	got, err := g.FindPRTemplate(root, "")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Bug.md"), []byte("bug"), 0o644))
+	got, err = g.FindPRTemplate(root, "")
+	require.NoError(t, err)
+	assert.Equal(t, "bug", got, "the only template is used")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Feature.md"), []byte("feature"), 0o644))
+	got, err = g.FindPRTemplate(root, "")
+	require.NoError(t, err)
+	assert.Empty(t, got, "no template is picked among several")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Default.md"), []byte("default"), 0o644))
+	got, err = g.FindPRTemplate(root, "")
+	require.NoError(t, err)
+	assert.Equal(t, "default", got)
+
+	got, err = g.FindPRTemplate(root, ".gitlab/merge_request_templates/Feature.md")
+	require.NoError(t, err)
+	assert.Equal(t, "feature", got)
+}
+
+func TestGitLabOpenPR(t *testing.T) {
+	var gotBody map[string]any
+	var gotPath, gotToken string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		gotPath = r.URL.EscapedPath()
+		gotToken = r.Header.Get("PRIVATE-TOKEN")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"iid":7,"web_url":"https://gitlab.example.com/group/sub/project/-/merge_requests/7"}`))
+	}))
+	defer srv.Close()
+
+	var out bytes.Buffer
+	g := platform.GitLab{Out: &out, BaseURL: srv.URL + "/", Project: "group/sub/project", Token: "secret"}
+
+	err := g.OpenPR(context.Background(), workflow.OpenPRParams{
+		Title:  "Add widgets",
+		Body:   "## Summary\n\nAdds widgets.",
+		Head:   "feat/widgets",
+		Base:   "main",
+		Draft:  true,
+		Labels: []string{"feature", "ui"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "/api/v4/projects/group%2Fsub%2Fproject/merge_requests", gotPath)
+	assert.Equal(t, "secret", gotToken)
+	assert.Equal(t, map[string]any{
+		"source_branch": "feat/widgets",
+		"target_branch": "main",
+		"title":         "Draft: Add widgets",
+		"description":   "## Summary\n\nAdds widgets.",
+		"labels":        "feature,ui",
+	}, gotBody)
+	assert.Equal(t, "https://gitlab.example.com/group/sub/project/-/merge_requests/7\n", out.String())
+}
+
+func TestGitLabOpenPRError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"message":["Another open merge request already exists for this source branch: !6"]}`))
+	}))
+	defer srv.Close()
+
+	g := platform.GitLab{BaseURL: srv.URL, Project: "group/project", Token: "secret"}
+
+	err := g.OpenPR(context.Background(), workflow.OpenPRParams{Title: "t", Head: "feat/x", Base: "main"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "409 Conflict")
+	assert.Contains(t, err.Error(), "Another open merge request already exists")
+}
+
+func TestGitLabIssue(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/v4/projects/group%2Fproject/issues/42", r.URL.EscapedPath())
+		w.Write([]byte(`{"iid":42,"title":"Crash on start","description":"Steps to reproduce"}`))
+	}))
+	defer srv.Close()
+
+	g := platform.GitLab{BaseURL: srv.URL, Project: "group/project", Token: "secret"}
+
+	issue, err := g.Issue(context.Background(), "#42")
+	require.NoError(t, err)
+	assert.Equal(t, workflow.Issue{ID: "#42", Title: "Crash on start", Body: "Steps to reproduce"}, issue)
+
+	_, err = g.Issue(context.Background(), "PROJ-1")
+	assert.Error(t, err)
+}
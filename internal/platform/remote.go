@@ -0,0 +1,83 @@
+package platform
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Names of the supported hosting platforms.
+const (
+	GitHubName = "github"
+	GitLabName = "gitlab"
+)
+
+// Remote is the location of a repository on a hosting platform.
+type Remote struct {
+	// Scheme is http or https for HTTP URLs, and https otherwise.
+	Scheme string
+	// Host is the web host of the platform, with its port for HTTP URLs.
+	// SSH ports are dropped since they do not serve the web interface.
+	Host string
+	// Path is the path of the repository, such as group/subgroup/project.
+	Path string
+}
+
+// ParseRemote parses the URL of a git remote: scp-like SSH addresses
+// (git@host:group/project.git) as well as ssh:// and http(s):// URLs.
+func ParseRemote(rawURL string) (Remote, error) {
+	r := Remote{Scheme: "https"}
+	if strings.Contains(rawURL, "://") {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return Remote{}, fmt.Errorf("parse remote %q: %w", rawURL, err)
+		}
+		r.Host, r.Path = u.Host, u.Path
+		if u.Scheme == "http" || u.Scheme == "https" {
+			r.Scheme = u.Scheme
+		} else {
+			r.Host = u.Hostname()
+		}
+	} else {
+		host, path, ok := strings.Cut(rawURL, ":")
+		if !ok {
+			return Remote{}, fmt.Errorf("parse remote %q: not a URL", rawURL)
+		}
+		if _, after, ok := strings.Cut(host, "@"); ok {
+			host = after
+		}
+		r.Host, r.Path = host, path
+	}
+
+	r.Path = strings.TrimSuffix(strings.Trim(r.Path, "/"), ".git")
+	if r.Host == "" || r.Path == "" {
+		return Remote{}, fmt.Errorf("parse remote %q: missing host or path", rawURL)
+	}
+	return r, nil
+}
+
+// WebURL returns the address of the web interface of the platform.
+func (r Remote) WebURL() string {
+	return r.Scheme + "://" + r.Host
+}
+
+// Detect names the platform hosting r: GitLab when the host contains
+// "gitlab" or is the host of gitlabURL, a self-hosted instance, and GitHub
+// otherwise.
+func Detect(r Remote, gitlabURL string) string {
+	host := strings.ToLower(r.Host)
+	if strings.Contains(host, "gitlab") {
+		return GitLabName
+	}
+	if u, err := url.Parse(gitlabURL); err == nil && u.Hostname() != "" && strings.EqualFold(u.Hostname(), hostname(host)) {
+		return GitLabName
+	}
+	return GitHubName
+}
+
+func hostname(host string) string {
+	if h, _, ok := strings.Cut(host, ":"); ok {
+		return h
+	}
+	return host
+}
@@ -0,0 +1,39 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BranchSystem builds the system prompt for naming a branch. types lists
+// the change types the model may choose from.
+func BranchSystem(types []string, additionalContext string) string {
+	return fmt.Sprintf(`You are a Git expert who names branches for the work a developer is about to start. You will receive a description of the work, the issues it addresses, or both. Your task is to classify the work and summarize it in a few words.
+
+## Instructions:
+1. Choose the type that best describes the work among: %s
+2. Write a slug of two to five lowercase English words separated by dashes that says what the work changes, such as "add-token-refresh" or "fix-empty-diff-crash"
+3. Do not repeat the type or the issue number in the slug
+
+## Response format:
+Respond with a single JSON object and nothing else: no code fences and no explanations. It has these fields:
+- **type** (string): the chosen type
+- **slug** (string): the slug
+
+%s
+
+---
+`, strings.Join(types, ", "), wrapAdditionalContext(additionalContext))
+}
+
+// BranchUser builds the user prompt from the description of the work and
+// the issues it addresses.
+func BranchUser(description string, issues []string) string {
+	return fmt.Sprintf(`--- DESCRIPTION START ---
+%s
+--- DESCRIPTION END ---
+--- RELATED ISSUES START ---
+%s
+--- RELATED ISSUES END ---
+`, description, strings.Join(issues, "\n\n"))
+}
@@ -0,0 +1,54 @@
+package prompt
+
+import "fmt"
+
+// ChangelogSystem builds the system prompt for writing release notes.
+// customPrompt replaces the default writing guidelines.
+func ChangelogSystem(customPrompt, additionalContext string) string {
+	guidelines := defaultChangelogGuidelines
+	if customPrompt != "" {
+		guidelines = customPrompt
+	}
+
+	return fmt.Sprintf(`You are a technical writer who turns commit history into release notes for the users of a project. You will receive the notable commits of a release, grouped by Conventional Commit type. Your task is to write the changelog entries for the release following the Keep a Changelog format.
+
+%s
+
+## Instructions:
+1. Write one entry per user-visible change. Merge commits that describe the same change into a single entry
+2. Sort each entry into the Keep a Changelog category that fits it best: added, changed, deprecated, removed, fixed or security
+3. List every breaking change once, in the breaking field only, and explain what users have to do about it
+4. Leave out changes that users cannot notice, such as internal refactorings
+
+## Response format:
+Respond with a single JSON object and nothing else: no code fences and no explanations. Every field is an array of strings, each string being one entry written in Markdown without a leading list marker. Use an empty array for categories without entries:
+- **breaking**: changes that break compatibility
+- **added**: new features
+- **changed**: changes in existing functionality, including performance improvements
+- **deprecated**: features that will be removed in a future release
+- **removed**: features that were removed
+- **fixed**: bug fixes
+- **security**: fixed vulnerabilities
+
+%s
+
+---
+`, guidelines, wrapAdditionalContext(additionalContext))
+}
+
+const defaultChangelogGuidelines = `## Writing guidelines:
+- Write for users, not for the developers of the project: describe the effect of a change, not how it was implemented
+- Start each entry with a capital letter and end it with a period
+- Keep entries to one or two sentences
+- Mention the scope of a commit only when it helps users find the feature
+- Do not mention commit hashes, commit types or issue numbers`
+
+// ChangelogUser builds the user prompt from the grouped commits.
+func ChangelogUser(version, changes string) string {
+	return fmt.Sprintf(`Version: %s
+
+--- COMMITS START ---
+%s
+--- COMMITS END ---
+`, version, changes)
+}
@@ -6,7 +6,13 @@ import (
 )
 
 type CommitParams struct {
-	Diff              string
+	Diff string
+	// Summaries replace Diff when the diff was too large for a single
+	// prompt and was summarized chunk by chunk instead.
+	Summaries []string
+	// Log lists the commits being squashed, whose messages give context
+	// for a single message covering all of them.
+	Log               string
 	Issues            []string
 	AdditionalContext string
 }
@@ -48,7 +54,7 @@ const defaultCommitConvention = `## Conventional Commits Rules:
   - 'perf': performance improvement
   - 'ci': CI/CD changes
   - 'revert': revert previous commit
-- Create a concise description (max 50 characters)
+- Keep the whole subject line, type and scope included, within 50 characters
 - If needed, add explanatory body after blank line
 - For breaking changes, add '!' after type/scope and 'BREAKING CHANGE:' in footer
 - Issue references in footer:
@@ -58,6 +64,33 @@ const defaultCommitConvention = `## Conventional Commits Rules:
   - BREAKING CHANGE footer must be separated by a blank line from other metadata`
 
 func CommitUser(params CommitParams) string {
+	return squashedCommits(params.Log) + commitChanges(params)
+}
+
+// squashedCommits introduces the commits a squashed message replaces.
+func squashedCommits(log string) string {
+	if log == "" {
+		return ""
+	}
+	return fmt.Sprintf(`The changes below were made in several commits that are squashed into one. Write a single message describing the combined change; do not list the commits one by one. Their messages are given for context:
+--- SQUASHED COMMITS START ---
+%s
+--- SQUASHED COMMITS END ---
+`, strings.TrimSpace(log))
+}
+
+func commitChanges(params CommitParams) string {
+	if len(params.Summaries) > 0 {
+		return fmt.Sprintf(`The diff is too large to be shown in full. It was split into %d parts and each part was summarized below. Base the commit message on these summaries as a whole.
+--- CHANGE SUMMARIES START ---
+%s
+--- CHANGE SUMMARIES END ---
+--- RELATED ISSUES START ---
+%s
+--- RELATED ISSUES END ---
+`, len(params.Summaries), formatSummaries(params.Summaries), strings.Join(params.Issues, "\n"))
+	}
+
 	return fmt.Sprintf(`--- DIFF START ---
 %s
 --- DIFF END ---
@@ -66,3 +99,32 @@ func CommitUser(params CommitParams) string {
 --- RELATED ISSUES END ---
 `, params.Diff, strings.Join(params.Issues, "\n"))
 }
+
+func formatSummaries(summaries []string) string {
+	parts := make([]string, len(summaries))
+	for i, s := range summaries {
+		parts[i] = fmt.Sprintf("Part %d:\n%s", i+1, strings.TrimSpace(s))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// ChunkSummarySystem is the system prompt used to summarize one part of a
+// diff that is too large to be sent at once.
+const ChunkSummarySystem = `You are a Git expert helping to write a commit message for a very large change. You will receive one part of the diff. Summarize what this part changes and, when it can be inferred, why.
+
+## Instructions:
+1. Mention the files or components involved
+2. Focus on behavior and intent rather than restating lines of code
+3. Point out breaking changes, removed functionality and new dependencies
+4. Use at most 5 short bullet points
+
+## Response format:
+Provide only the bullet points, without additional explanations.
+`
+
+func ChunkSummaryUser(diff string, part, total int) string {
+	return fmt.Sprintf(`--- DIFF PART %d OF %d START ---
+%s
+--- DIFF PART %d OF %d END ---
+`, part, total, diff, part, total)
+}
@@ -15,14 +15,18 @@ type PRParams struct {
 	AdditionalContext string
 }
 
-func PRSystem(customPrompt, template, additionalContext string) string {
+// PRSystem builds the system prompt for PR generation. The model is asked
+// for a JSON object with the title, body, labels and whether the changes
+// are breaking. labels lists the labels it may choose from; when empty the
+// labels field is not requested.
+func PRSystem(customPrompt, template, additionalContext string, labels []string) string {
 	var templateBlock string
 
 	if template != "" {
 		templateBlock = fmt.Sprintf(`
 ## PR Body Format Instructions:
 1.  **Analyze Context**: First, analyze the provided changes to understand the information corresponding to the 'PR Body Structure' (What & Why, How, Testing, etc.).
-2.  **Use Template**: Your final output **must** strictly use the format defined in the '--- TEMPLATE ---' block below. Preserve all headers, formatting, and language from the template.
+2.  **Use Template**: The PR body **must** strictly use the format defined in the '--- TEMPLATE ---' block below. Preserve all headers, formatting, and language from the template.
 3.  **Populate Template**: Use the information from your analysis (Step 1) to populate the appropriate sections of the template. For example, the "What & Why" information should go into the template's description or motivation section.
 4.  **Handle Missing Information**: If you cannot infer information for a specific section of the template from the context, **keep the section header but leave its content empty** for the user to complete.
 5. **Do not apply template to title**: The title of the PR should not be influenced whatsoever by the template defined below
@@ -59,14 +63,22 @@ You will receive:
 6. **Write comprehensive body**: Synthesize all commits into a coherent change description
 
 ## Response Format:
-Provide only the formatted PR information without additional explanations:
-
-[TITLE]
-[BODY]
+Respond with a single JSON object and nothing else: no code fences and no explanations. It has these fields:
+- **title** (string): the PR title alone, on one line
+- **body** (string): the PR body in Markdown. It must not be empty and must not start by repeating the title
+- **breaking** (boolean): whether the changes break compatibility for users of the project%s
 
 %s
 ---
-`, convention, templateBlock, wrapAdditionalContext(additionalContext))
+`, convention, templateBlock, labelsField(labels), wrapAdditionalContext(additionalContext))
+}
+
+func labelsField(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`
+- **labels** (array of strings): the labels that apply to the PR, chosen only from: %s. Use an empty array when none apply`, strings.Join(labels, ", "))
 }
 
 func PRUser(params PRParams) string {
@@ -0,0 +1,36 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Revision is one round of feedback on a generated draft.
+type Revision struct {
+	Draft       string
+	Instruction string
+}
+
+// Refine extends the original user prompt with the drafts produced so far
+// and the user's feedback on each of them, so the model revises its latest
+// answer instead of starting from scratch.
+func Refine(user string, revisions []Revision) string {
+	var b strings.Builder
+	b.WriteString(user)
+
+	for i, r := range revisions {
+		fmt.Fprintf(&b, `
+--- DRAFT %d START ---
+%s
+--- DRAFT %d END ---
+--- FEEDBACK ON DRAFT %d START ---
+%s
+--- FEEDBACK ON DRAFT %d END ---
+`, i+1, strings.TrimSpace(r.Draft), i+1, i+1, strings.TrimSpace(r.Instruction), i+1)
+	}
+
+	b.WriteString(`
+Rewrite the latest draft so that it addresses all of the feedback above while still following the original instructions. Provide only the revised result, without additional explanations.
+`)
+	return b.String()
+}
@@ -0,0 +1,47 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SplitSystem builds the system prompt for grouping the hunks of a diff
+// into commits. customPrompt replaces the default commit convention.
+func SplitSystem(customPrompt, additionalContext string) string {
+	convention := defaultCommitConvention
+	if customPrompt != "" {
+		convention = customPrompt
+	}
+
+	return fmt.Sprintf(`You are a Git expert who turns a large set of changes into a series of small, atomic commits. You will receive a diff divided into numbered hunks. Your task is to group the hunks into logical commits and write a commit message for each one following the convention below.
+
+%s
+
+## Instructions:
+1. Each commit must hold one logical change that makes sense on its own, such as a feature, a fix, a refactoring, or documentation
+2. Assign every hunk to exactly one commit. Keep hunks that depend on each other in the same commit so that every commit builds
+3. Order the commits so that each one only depends on the commits before it
+4. Prefer fewer commits when changes are closely related; do not create one commit per file without a reason
+5. Reference the provided issues, if any, in the footer of the commits they relate to
+
+## Response format:
+Respond with a single JSON object and nothing else: no code fences and no explanations. It has a **commits** field holding an array of objects, in the order they should be created, with these fields:
+- **message** (string): the full commit message
+- **hunks** (array of integers): the numbers of the hunks that belong to the commit
+
+%s
+
+---
+`, convention, wrapAdditionalContext(additionalContext))
+}
+
+// SplitUser builds the user prompt from the numbered hunks.
+func SplitUser(hunks string, issues []string) string {
+	return fmt.Sprintf(`--- HUNKS START ---
+%s
+--- HUNKS END ---
+--- RELATED ISSUES START ---
+%s
+--- RELATED ISSUES END ---
+`, hunks, strings.Join(issues, "\n"))
+}
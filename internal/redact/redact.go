@@ -0,0 +1,183 @@
+// Package redact masks secrets in text before it leaves the machine.
+package redact
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Pattern is a named secret detector. When the expression has a capture
+// group named "secret", only that group is replaced; otherwise the whole
+// match is.
+type Pattern struct {
+	Name  string
+	Regex *regexp.Regexp
+}
+
+// DefaultPatterns detect common credentials.
+var DefaultPatterns = []Pattern{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)},
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[A-Z0-9]{16}\b`)},
+	{"aws-secret-key", regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})`)},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)},
+	{"gitlab-token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20,}\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`)},
+	{"google-api-key", regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{"openai-api-key", regexp.MustCompile(`\bsk-(?:proj-|ant-)?[A-Za-z0-9_\-]{20,}\b`)},
+	{"stripe-key", regexp.MustCompile(`\b(?:sk|rk)_(?:live|test)_[A-Za-z0-9]{16,}\b`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\b`)},
+	{"url-credentials", regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s:/@]+:(?P<secret>[^\s@/]+)@`)},
+	// KEY=value assignments as found in .env files and shell exports where
+	// the name suggests a credential.
+	// Values that look like code or variable references are left alone.
+	{"env-secret", regexp.MustCompile(`(?im)^[+\- ]?\s*(?:export\s+)?[A-Z0-9_]*(?:SECRET|TOKEN|PASSWORD|PASSWD|API_?KEY|PRIVATE_?KEY|CREDENTIALS?)[A-Z0-9_]*\s*=\s*["']?(?P<secret>[^\s"'#(){}$]{4,})(?:["'\s#]|$)`)},
+}
+
+// Options configures a Redactor.
+type Options struct {
+	// Patterns are added to DefaultPatterns.
+	Patterns []Pattern
+	// Entropy enables the detection of random-looking tokens that no pattern
+	// matched. Tokens must be at least EntropyMinLength characters long and
+	// have a Shannon entropy of at least EntropyThreshold bits per character.
+	Entropy          bool
+	EntropyMinLength int
+	EntropyThreshold float64
+}
+
+// Redactor replaces secrets with placeholders such as [REDACTED:github-token].
+type Redactor struct {
+	patterns []Pattern
+	opts     Options
+}
+
+var highEntropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{20,}`)
+
+// New creates a Redactor using DefaultPatterns plus opts.Patterns.
+func New(opts Options) *Redactor {
+	if opts.EntropyMinLength == 0 {
+		opts.EntropyMinLength = 32
+	}
+	if opts.EntropyThreshold == 0 {
+		opts.EntropyThreshold = 4.5
+	}
+
+	patterns := append(append([]Pattern{}, DefaultPatterns...), opts.Patterns...)
+	return &Redactor{patterns: patterns, opts: opts}
+}
+
+// CompilePattern builds a Pattern from a user-supplied expression.
+func CompilePattern(name, expr string) (Pattern, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return Pattern{}, fmt.Errorf("redact pattern %q: %w", name, err)
+	}
+	return Pattern{Name: name, Regex: re}, nil
+}
+
+// Redact returns s with every detected secret replaced and the number of
+// replacements made.
+func (r *Redactor) Redact(s string) (string, int) {
+	count := 0
+
+	for _, p := range r.patterns {
+		s = replace(p, s, &count)
+	}
+
+	if r.opts.Entropy {
+		s = r.replaceHighEntropy(s, &count)
+	}
+
+	return s, count
+}
+
+func (r *Redactor) replaceHighEntropy(s string, count *int) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range highEntropyCandidate.FindAllStringIndex(s, -1) {
+		tok := s[m[0]:m[1]]
+		if len(tok) < r.opts.EntropyMinLength || !mixed(tok) || isChecksum(s[:m[0]], tok) {
+			continue
+		}
+		if shannon(tok) < r.opts.EntropyThreshold {
+			continue
+		}
+		b.WriteString(s[last:m[0]])
+		b.WriteString(placeholder("high-entropy"))
+		last = m[1]
+		*count++
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// isChecksum recognizes the integrity hashes found in lockfiles (go.sum
+// "h1:", npm "sha512-"), which are random-looking but not secret.
+func isChecksum(before, tok string) bool {
+	return strings.HasSuffix(before, "h1:") ||
+		strings.HasPrefix(tok, "sha1-") ||
+		strings.HasPrefix(tok, "sha256-") ||
+		strings.HasPrefix(tok, "sha384-") ||
+		strings.HasPrefix(tok, "sha512-")
+}
+
+func replace(p Pattern, s string, count *int) string {
+	group := p.Regex.SubexpIndex("secret")
+
+	matches := p.Regex.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if group >= 0 && m[2*group] >= 0 {
+			start, end = m[2*group], m[2*group+1]
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(placeholder(p.Name))
+		last = end
+		*count++
+	}
+	b.WriteString(s[last:])
+
+	return b.String()
+}
+
+func placeholder(name string) string {
+	return "[REDACTED:" + name + "]"
+}
+
+// mixed reports whether tok combines letters and digits, which rules out
+// long identifiers such as package paths or CamelCase names.
+func mixed(tok string) bool {
+	var letter, digit bool
+	for _, c := range tok {
+		switch {
+		case c >= '0' && c <= '9':
+			digit = true
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+			letter = true
+		}
+	}
+	return letter && digit
+}
+
+func shannon(s string) float64 {
+	freq := make(map[rune]float64)
+	for _, c := range s {
+		freq[c]++
+	}
+
+	var h float64
+	n := float64(len(s))
+	for _, f := range freq {
+		p := f / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
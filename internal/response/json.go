@@ -0,0 +1,21 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// DecodeJSON decodes the outermost JSON object in s into v, tolerating
+// reasoning blocks, a code fence or chatter around it.
+func DecodeJSON(s string, v any) error {
+	s = unwrapFence(strings.TrimSpace(thinkRE.ReplaceAllString(s, "")))
+
+	start := strings.Index(s, "{")
+	end := strings.LastIndex(s, "}")
+	if start < 0 || end < start {
+		return errors.New("no JSON object in response")
+	}
+
+	return json.Unmarshal([]byte(s[start:end+1]), v)
+}
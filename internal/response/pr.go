@@ -0,0 +1,62 @@
+package response
+
+import (
+	"errors"
+	"strings"
+)
+
+// PR is a pull request extracted from a response.
+type PR struct {
+	Title    string   `json:"title"`
+	Body     string   `json:"body"`
+	Labels   []string `json:"labels"`
+	Breaking bool     `json:"breaking"`
+}
+
+// ParsePR reads a PR from a JSON object with title, body, labels and
+// breaking fields, as returned by providers with structured output and by
+// most others when asked for it. Any other response is cleaned and split
+// into a title on the first line and a body on the rest. A body that opens
+// by repeating the title has that line removed.
+func ParsePR(s string) (PR, error) {
+	pr, ok := decodePR(s)
+	if !ok {
+		title, body, _ := strings.Cut(Clean(s), "\n")
+		pr = PR{Title: title, Body: body}
+	}
+
+	pr.Title = cleanTitle(strings.Join(strings.Fields(pr.Title), " "))
+	pr.Body = dropRepeatedTitle(pr.Title, strings.TrimSpace(pr.Body))
+
+	if pr.Title == "" {
+		return pr, errors.New("empty title")
+	}
+	if pr.Body == "" {
+		return pr, errors.New("empty body")
+	}
+	return pr, nil
+}
+
+func decodePR(s string) (PR, bool) {
+	var pr PR
+	if err := DecodeJSON(s, &pr); err != nil || strings.TrimSpace(pr.Title) == "" {
+		return PR{}, false
+	}
+	return pr, true
+}
+
+// dropRepeatedTitle removes the first line of body when it only restates
+// title, possibly as a heading, in bold, or without its type prefix.
+func dropRepeatedTitle(title, body string) string {
+	first, rest, _ := strings.Cut(body, "\n")
+	line := cleanTitle(strings.Trim(first, "*_ "))
+	if line == "" {
+		return body
+	}
+
+	_, description, _ := strings.Cut(title, ": ")
+	if !strings.EqualFold(line, title) && !strings.EqualFold(line, description) {
+		return body
+	}
+	return strings.TrimSpace(rest)
+}
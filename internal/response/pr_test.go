@@ -0,0 +1,104 @@
+package response_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/response"
+)
+
+func TestParsePR(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want response.PR
+	}{
+		{
+			name: "structured",
+			in:   `{"title":"feat(auth): add token refresh","body":"Refresh tokens before they expire.","labels":["enhancement"],"breaking":false}`,
+			want: response.PR{
+				Title:  "feat(auth): add token refresh",
+				Body:   "Refresh tokens before they expire.",
+				Labels: []string{"enhancement"},
+			},
+		},
+		{
+			name: "fenced json with preamble",
+			in:   "Here's the PR:\n\n```json\n{\n  \"title\": \"fix: drop v1 endpoints\",\n  \"body\": \"## What\\n\\nRemove the v1 API.\",\n  \"breaking\": true\n}\n```",
+			want: response.PR{
+				Title:    "fix: drop v1 endpoints",
+				Body:     "## What\n\nRemove the v1 API.",
+				Breaking: true,
+			},
+		},
+		{
+			name: "plain text title as heading in bold",
+			in:   "# **Add interactive review**\n\nLets users refine messages.",
+			want: response.PR{
+				Title: "Add interactive review",
+				Body:  "Lets users refine messages.",
+			},
+		},
+		{
+			name: "plain text title starting with an issue number",
+			in:   "#123 regression in retries\n\nStop retrying on 4xx.",
+			want: response.PR{
+				Title: "#123 regression in retries",
+				Body:  "Stop retrying on 4xx.",
+			},
+		},
+		{
+			name: "body repeats the title",
+			in:   `{"title":"feat: add dry runs","body":"# feat: add dry runs\n\nPrint the message instead of committing."}`,
+			want: response.PR{
+				Title: "feat: add dry runs",
+				Body:  "Print the message instead of committing.",
+			},
+		},
+		{
+			name: "body repeats the description in bold",
+			in:   `{"title":"feat: add dry runs","body":"**Add dry runs**\n\nPrint the message instead of committing."}`,
+			want: response.PR{
+				Title: "feat: add dry runs",
+				Body:  "Print the message instead of committing.",
+			},
+		},
+		{
+			name: "plain text",
+			in:   "[TITLE] docs: document the fallback chain\n[BODY] Explain how providers are tried in order.",
+			want: response.PR{
+				Title: "docs: document the fallback chain",
+				Body:  "Explain how providers are tried in order.",
+			},
+		},
+		{
+			name: "plain text body with json example",
+			in:   "feat: add json output\n\nExample:\n\n```json\n{\"command\": \"commit\"}\n```",
+			want: response.PR{
+				Title: "feat: add json output",
+				Body:  "Example:\n\n```json\n{\"command\": \"commit\"}\n```",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := response.ParsePR(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParsePRRejectsEmptyBody(t *testing.T) {
+	_, err := response.ParsePR(`{"title":"feat: add thing","body":""}`)
+	assert.EqualError(t, err, "empty body")
+
+	_, err = response.ParsePR("feat: add thing")
+	assert.EqualError(t, err, "empty body")
+
+	_, err = response.ParsePR(`{"title":"feat: add thing","body":"feat: add thing"}`)
+	assert.EqualError(t, err, "empty body")
+}
@@ -0,0 +1,178 @@
+// Package response cleans up model output so that only the text the model
+// was asked for remains.
+package response
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	thinkRE = regexp.MustCompile(`(?s)<think>.*?</think>`)
+
+	// preambleRE matches lines that introduce the answer instead of being
+	// part of it, e.g. "Sure! Here's the commit message:". An introduction
+	// must name what it introduces and end with a colon or a period, so
+	// that a first line that merely starts like one, such as "Here's a
+	// faster parser", is kept.
+	preambleRE = regexp.MustCompile(`(?i)^(?:(?:sure|certainly|okay|ok|of course|absolutely|great)\b[!,.]?\s*)?(?:(?:here(?:'s| is| are)|below is|the following is|based on (?:the|these|this)|i(?:'ve| have) (?:generated|written|created))\b.*\b(?:commit message|message|pull request|pr|title|body|description|summary)\b[^:.]*[:.]|(?:proposed |suggested |generated )?(?:commit message|pull request|pr)(?: title and body)?:?)$`)
+
+	// headingRE matches the Markdown heading marker of a line.
+	headingRE = regexp.MustCompile(`^#{1,6}\s+`)
+	// chatterRE matches a leading line that only acknowledges the request.
+	chatterRE = regexp.MustCompile(`(?i)^(?:sure|certainly|okay|ok|of course|absolutely)[!.]?$`)
+
+	// labelRE matches labels models put in front of the title or body,
+	// such as "**Title:**" or "[TITLE]".
+	labelRE = regexp.MustCompile(`(?i)^(?:\[(?:title|body)\]|\*{0,2}(?:title|subject|body|commit message|pr title|pr body|pr description)\*{0,2}:\*{0,2})\s*`)
+
+	// epilogueRE matches paragraphs that comment on the answer after it.
+	// PR bodies legitimately end with their own notes, so only phrases
+	// that talk about the generated text itself qualify.
+	epilogueRE = regexp.MustCompile(`(?i)^(?:\*{0,2}explanation\*{0,2}:|this (?:commit message|message|title)\b|the (?:commit message|message|title|pr description) (?:above|follows|uses|is)\b|i(?:'ve| have) (?:followed|used|kept|included)\b|let me know\b|feel free\b|hope this helps\b|if you(?:'d| would)? (?:like|want|need)\b)`)
+)
+
+// Clean removes the chatter models wrap around an answer: reasoning blocks,
+// preambles such as "Here's the commit message:", a code fence around the
+// whole answer, literal [TITLE]/[BODY] markers and "Title:" labels, and
+// trailing explanations. Code fences inside the answer, such as examples in
+// a PR body, are kept.
+func Clean(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = thinkRE.ReplaceAllString(s, "")
+	s = strings.TrimSpace(s)
+
+	s = dropPreamble(s)
+	s = unwrapFence(s)
+	s = dropPreamble(s)
+	s = dropEpilogue(s)
+	s = dropLabels(s)
+
+	return strings.TrimSpace(s)
+}
+
+// dropPreamble removes introductory lines before the answer.
+func dropPreamble(s string) string {
+	for {
+		first, rest, _ := strings.Cut(s, "\n")
+		first = strings.TrimSpace(first)
+		if !chatterRE.MatchString(first) && !preambleRE.MatchString(strings.Trim(first, "*_ ")) {
+			return s
+		}
+		// A lone "Commit message:" can also be a label followed by the
+		// answer on the same line; only drop the line when it is all there is.
+		if rest == "" {
+			return s
+		}
+		s = strings.TrimSpace(rest)
+	}
+}
+
+// unwrapFence returns the content of a code fence that opens the answer.
+// Anything after the closing fence is commentary and is dropped.
+func unwrapFence(s string) string {
+	if !strings.HasPrefix(s, "```") && !strings.HasPrefix(s, "~~~") {
+		return s
+	}
+	fence := s[:3]
+
+	_, inner, ok := strings.Cut(s, "\n")
+	if !ok {
+		return s
+	}
+
+	lines := strings.Split(inner, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == fence {
+			return strings.TrimSpace(strings.Join(lines[:i], "\n"))
+		}
+	}
+	// Unclosed fence: keep everything after the opening line.
+	return strings.TrimSpace(inner)
+}
+
+// dropEpilogue removes trailing paragraphs that explain the answer, along
+// with a horizontal rule separating them from it.
+func dropEpilogue(s string) string {
+	paragraphs := strings.Split(s, "\n\n")
+	for len(paragraphs) > 1 {
+		last := strings.TrimSpace(paragraphs[len(paragraphs)-1])
+		if last != "---" && last != "" && !epilogueRE.MatchString(last) {
+			break
+		}
+		paragraphs = paragraphs[:len(paragraphs)-1]
+	}
+
+	s = strings.Join(paragraphs, "\n\n")
+	return strings.TrimSuffix(strings.TrimSpace(s), "\n---")
+}
+
+// dropLabels removes [TITLE]/[BODY] markers and "Title:"-style labels from
+// the first line and from the start of the text that follows it, and
+// unwraps a first line that is wholly set in bold, code or quotes.
+func dropLabels(s string) string {
+	lines := strings.Split(s, "\n")
+
+	lines = dropLabelAt(lines, 0)
+	if len(lines) > 0 {
+		lines[0] = unwrapLine(lines[0])
+	}
+
+	// The body label is either on the line after the title or after the
+	// blank line separating them.
+	for i := 1; i < len(lines) && i <= 2; i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		lines = dropLabelAt(lines, i)
+		break
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// dropLabelAt strips a label from lines[i], removing the line entirely
+// when the label was all it held.
+func dropLabelAt(lines []string, i int) []string {
+	line := strings.TrimSpace(lines[i])
+	loc := labelRE.FindStringIndex(line)
+	if loc == nil {
+		return lines
+	}
+
+	if rest := strings.TrimSpace(line[loc[1]:]); rest != "" {
+		lines[i] = rest
+		return lines
+	}
+
+	lines = append(lines[:i], lines[i+1:]...)
+	// Do not leave the text starting with blank lines.
+	for i < len(lines) && i == 0 && strings.TrimSpace(lines[i]) == "" {
+		lines = append(lines[:i], lines[i+1:]...)
+	}
+	return lines
+}
+
+// cleanTitle unwraps a PR title set as a heading, in bold, code or quotes.
+// Commit subjects are only unwrapped: "#123 regression" is not a heading.
+func cleanTitle(line string) string {
+	return unwrapLine(headingRE.ReplaceAllString(strings.TrimSpace(line), ""))
+}
+
+// unwrapLine removes bold, code or quotes set around the whole line. A
+// delimiter that also occurs inside, as in `"a" or "b"`, quotes only part
+// of the line, which is then kept as is.
+func unwrapLine(line string) string {
+	line = strings.TrimSpace(line)
+	for _, delim := range []string{"**", "__", "`", `"`, "'"} {
+		inner, ok := strings.CutPrefix(line, delim)
+		if !ok {
+			continue
+		}
+		inner, ok = strings.CutSuffix(inner, delim)
+		if ok && inner != "" && !strings.Contains(inner, delim) {
+			line = strings.TrimSpace(inner)
+		}
+	}
+	return line
+}
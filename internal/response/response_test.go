@@ -0,0 +1,124 @@
+package response_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/arthvm/ditto/internal/response"
+)
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "already clean",
+			in:   "feat(auth): add token refresh\n\nRefresh tokens before they expire.\n\nCloses #12\n",
+			want: "feat(auth): add token refresh\n\nRefresh tokens before they expire.\n\nCloses #12",
+		},
+		{
+			name: "fenced commit message",
+			in:   "```\nfix(api): handle empty payloads\n\nReturn 400 instead of panicking.\n```",
+			want: "fix(api): handle empty payloads\n\nReturn 400 instead of panicking.",
+		},
+		{
+			name: "fence with language and trailing explanation",
+			in:   "```text\nchore: bump go to 1.25\n```\n\nThis commit message follows the Conventional Commits format.",
+			want: "chore: bump go to 1.25",
+		},
+		{
+			name: "preamble and fence",
+			in:   "Sure! Here's the commit message for your changes:\n\n```git\nrefactor(cmd): extract provider factory\n\nMove construction of providers into a single function.\n```\n\nLet me know if you'd like any adjustments!",
+			want: "refactor(cmd): extract provider factory\n\nMove construction of providers into a single function.",
+		},
+		{
+			name: "bold preamble without fence",
+			in:   "**Commit message:**\n\ndocs: document the fallback chain",
+			want: "docs: document the fallback chain",
+		},
+		{
+			name: "reasoning block",
+			in:   "<think>\nThe diff changes the retry loop, so this is a fix.\n</think>\n\nfix(retry): stop at the deadline",
+			want: "fix(retry): stop at the deadline",
+		},
+		{
+			name: "unclosed fence",
+			in:   "```\nstyle: format imports\n",
+			want: "style: format imports",
+		},
+		{
+			name: "explanation section and rule",
+			in:   "perf(budget): cache token estimates\n\nAvoid recomputing estimates for unchanged hunks.\n\n---\n\n**Explanation:**\n- `perf` because it speeds up fitting\n- scope is the budget package",
+			want: "perf(budget): cache token estimates\n\nAvoid recomputing estimates for unchanged hunks.",
+		},
+		{
+			name: "literal PR markers",
+			in:   "[TITLE]\nAdd GitLab support\n[BODY]\n## Summary\nOpen merge requests with glab.",
+			want: "Add GitLab support\n## Summary\nOpen merge requests with glab.",
+		},
+		{
+			name: "inline PR markers",
+			in:   "[TITLE] Add GitLab support\n\n[BODY] Open merge requests with glab.",
+			want: "Add GitLab support\n\nOpen merge requests with glab.",
+		},
+		{
+			name: "bold title and body labels",
+			in:   "**Title:** feat: add dry-run mode\n\n**Body:**\n## What\nPrint instead of committing.",
+			want: "feat: add dry-run mode\n\n## What\nPrint instead of committing.",
+		},
+		{
+			name: "subject in bold",
+			in:   "**feat: add interactive review**\n\nLets users refine messages.",
+			want: "feat: add interactive review\n\nLets users refine messages.",
+		},
+		{
+			name: "subject starting with an issue number",
+			in:   "#123 regression in the retry loop",
+			want: "#123 regression in the retry loop",
+		},
+		{
+			name: "subject quoting strings",
+			in:   "fix: accept \"yes\" and \"no\"",
+			want: "fix: accept \"yes\" and \"no\"",
+		},
+		{
+			name: "subject partly quoted at both ends",
+			in:   "\"a\" is now an alias of \"b\"",
+			want: "\"a\" is now an alias of \"b\"",
+		},
+		{
+			name: "first line that only starts like a preamble",
+			in:   "Here's a faster parser\n\nIt streams tokens.",
+			want: "Here's a faster parser\n\nIt streams tokens.",
+		},
+		{
+			name: "first line of a custom format",
+			in:   "The following endpoints are deprecated\n\n- /v1/users",
+			want: "The following endpoints are deprecated\n\n- /v1/users",
+		},
+		{
+			name: "code blocks inside PR body are kept",
+			in:   "Add JSON output\n\n## Usage\n```sh\nditto commit -o json\n```\n\nThis PR also updates the README.",
+			want: "Add JSON output\n\n## Usage\n```sh\nditto commit -o json\n```\n\nThis PR also updates the README.",
+		},
+		{
+			name: "quoted subject",
+			in:   "\"test(hook): cover uninstall\"",
+			want: "test(hook): cover uninstall",
+		},
+		{
+			name: "windows line endings",
+			in:   "Here is the commit message:\r\n\r\nci: cache go modules\r\n",
+			want: "ci: cache go modules",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, response.Clean(tt.in))
+		})
+	}
+}
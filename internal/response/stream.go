@@ -0,0 +1,79 @@
+package response
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// StreamField returns a chunk handler for a JSON document being streamed
+// that passes the decoded value of the string field name to emit as it
+// arrives, so that a structured response can be shown like plain text.
+// Everything else in the document is dropped.
+func StreamField(name string, emit func(string)) func(string) {
+	keyRE := regexp.MustCompile(`(?:^|[^\\])"` + regexp.QuoteMeta(name) + `"\s*:\s*"`)
+
+	var pending string
+	found, done := false, false
+	return func(chunk string) {
+		if done {
+			return
+		}
+		pending += chunk
+
+		if !found {
+			loc := keyRE.FindStringIndex(pending)
+			if loc == nil {
+				return
+			}
+			found = true
+			pending = pending[loc[1]:]
+		}
+
+		var text string
+		text, pending, done = decodeString(pending)
+		if text != "" {
+			emit(text)
+		}
+	}
+}
+
+// decodeString decodes the start of the body of a JSON string up to its
+// closing quote. It returns the decoded text, the undecoded rest, which is
+// an escape sequence cut short by the end of s, and whether the closing
+// quote was reached.
+func decodeString(s string) (string, string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '"':
+			return b.String(), "", true
+		case '\\':
+			n := escapeLen(s[i:])
+			if i+n > len(s) {
+				return b.String(), s[i:], false
+			}
+			var r string
+			if err := json.Unmarshal([]byte(`"`+s[i:i+n]+`"`), &r); err == nil {
+				b.WriteString(r)
+			}
+			i += n
+		default:
+			b.WriteByte(s[i])
+			i++
+		}
+	}
+	return b.String(), "", false
+}
+
+// escapeLen returns the length of the escape sequence at the start of s.
+// A high surrogate is decoded together with the low surrogate after it.
+func escapeLen(s string) int {
+	if len(s) < 2 || s[1] != 'u' {
+		return 2
+	}
+	if len(s) >= 6 && strings.ContainsAny(s[2:3], "dD") && strings.ContainsAny(s[3:4], "89abAB") {
+		return 12
+	}
+	return 6
+}
@@ -0,0 +1,55 @@
+package response_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/arthvm/ditto/internal/response"
+)
+
+func TestStreamField(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{
+			name:   "body split across chunks",
+			chunks: []string{`{"bo`, `dy": "## What\n`, `\nAdds \"quoted\" te`, `xt", "title": "feat: x"}`},
+			want:   "## What\n\nAdds \"quoted\" text",
+		},
+		{
+			name:   "escape cut by a chunk",
+			chunks: []string{`{"body":"a\`, `tb\u00`, `e9 \ud83d`, `\ude00"}`},
+			want:   "a\tbé 😀",
+		},
+		{
+			name:   "key quoted inside another field",
+			chunks: []string{`{"title":"rename \"body\": field","body":"done"}`},
+			want:   "done",
+		},
+		{
+			name:   "fenced document",
+			chunks: []string{"```json\n{\"title\":\"t\",\n  \"body\" : \"text\"\n}\n```"},
+			want:   "text",
+		},
+		{
+			name:   "no such field",
+			chunks: []string{"feat: plain text\n\nbody"},
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got strings.Builder
+			onChunk := response.StreamField("body", func(s string) { got.WriteString(s) })
+			for _, c := range tt.chunks {
+				onChunk(c)
+			}
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
@@ -0,0 +1,216 @@
+// Package semver parses, compares and bumps Semantic Versions
+// (https://semver.org).
+package semver
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Level is the part of a version a release increments.
+type Level int
+
+const (
+	Patch Level = iota
+	Minor
+	Major
+)
+
+func (l Level) String() string {
+	switch l {
+	case Major:
+		return "major"
+	case Minor:
+		return "minor"
+	default:
+		return "patch"
+	}
+}
+
+// Version is a semantic version. Build metadata is dropped when parsing
+// since it plays no part in precedence.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+	// Pre is the pre-release part, such as "rc.1", without its hyphen.
+	Pre string
+}
+
+// Parse parses a version such as "1.2.3" or "1.2.3-rc.1+build.5".
+func Parse(s string) (Version, error) {
+	core, _, _ := strings.Cut(s, "+")
+	core, pre, hasPre := strings.Cut(core, "-")
+	if hasPre && !validPre(pre) {
+		return Version{}, fmt.Errorf("parse version %q: invalid pre-release %q", s, pre)
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("parse version %q: want MAJOR.MINOR.PATCH", s)
+	}
+
+	var nums [3]int
+	for i, p := range parts {
+		n, ok := number(p)
+		if !ok {
+			return Version{}, fmt.Errorf("parse version %q: invalid number %q", s, p)
+		}
+		nums[i] = n
+	}
+	return Version{Major: nums[0], Minor: nums[1], Patch: nums[2], Pre: pre}, nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Bump returns the next release of v at level. A pre-release of a version
+// is released as that version when the level allows it: 1.2.0-rc.1 bumped
+// at minor level gives 1.2.0, not 1.3.0.
+func (v Version) Bump(level Level) Version {
+	pre := v.Pre != ""
+	switch {
+	case level == Major && !(pre && v.Minor == 0 && v.Patch == 0):
+		return Version{Major: v.Major + 1}
+	case level == Major:
+		return Version{Major: v.Major}
+	case level == Minor && !(pre && v.Patch == 0):
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	case level == Minor:
+		return Version{Major: v.Major, Minor: v.Minor}
+	case !pre:
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	default:
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	}
+}
+
+// Compare returns -1, 0 or +1 depending on whether v has a lower, equal or
+// higher precedence than w.
+func Compare(v, w Version) int {
+	if c := cmp.Compare(v.Major, w.Major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Minor, w.Minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Patch, w.Patch); c != 0 {
+		return c
+	}
+
+	// A pre-release has a lower precedence than the release itself.
+	switch {
+	case v.Pre == w.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case w.Pre == "":
+		return -1
+	}
+
+	a, b := strings.Split(v.Pre, "."), strings.Split(w.Pre, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareIdentifier(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// compareIdentifier compares pre-release identifiers: numeric ones by
+// value and below alphanumeric ones, which compare in ASCII order.
+func compareIdentifier(a, b string) int {
+	x, aNum := number(a)
+	y, bNum := number(b)
+	switch {
+	case aNum && bNum:
+		return cmp.Compare(x, y)
+	case aNum:
+		return -1
+	case bNum:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// number parses a numeric identifier, which has no leading zeros.
+func number(s string) (int, bool) {
+	if s == "" || (len(s) > 1 && s[0] == '0') || strings.Trim(s, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+func validPre(pre string) bool {
+	for id := range strings.SplitSeq(pre, ".") {
+		if id == "" {
+			return false
+		}
+		for _, r := range id {
+			if !(r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+				return false
+			}
+		}
+		if strings.Trim(id, "0123456789") == "" && len(id) > 1 && id[0] == '0' {
+			return false
+		}
+	}
+	return true
+}
+
+// Tag is a tag that names a version.
+type Tag struct {
+	Name    string
+	Version Version
+}
+
+// Tags returns the tags made of prefix followed by a version, highest
+// version first. Other tags are ignored.
+func Tags(names []string, prefix string) []Tag {
+	var tags []Tag
+	for _, name := range names {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		v, err := Parse(rest)
+		if err != nil {
+			continue
+		}
+		tags = append(tags, Tag{Name: name, Version: v})
+	}
+
+	slices.SortStableFunc(tags, func(a, b Tag) int { return Compare(b.Version, a.Version) })
+	return tags
+}
+
+// NextPre returns the next pre-release of v with the identifier id, such
+// as 1.3.0-rc.2 after 1.3.0-rc.1, given the existing versions.
+func NextPre(v Version, id string, existing []Version) Version {
+	n := 0
+	for _, e := range existing {
+		if e.Major != v.Major || e.Minor != v.Minor || e.Patch != v.Patch {
+			continue
+		}
+		rest, ok := strings.CutPrefix(e.Pre, id+".")
+		if !ok {
+			continue
+		}
+		if k, ok := number(rest); ok && k > n {
+			n = k
+		}
+	}
+
+	v.Pre = fmt.Sprintf("%s.%d", id, n+1)
+	return v
+}
@@ -0,0 +1,85 @@
+package semver_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/semver"
+)
+
+func TestParse(t *testing.T) {
+	v, err := semver.Parse("1.20.3-rc.1+build.5")
+	require.NoError(t, err)
+	assert.Equal(t, semver.Version{Major: 1, Minor: 20, Patch: 3, Pre: "rc.1"}, v)
+	assert.Equal(t, "1.20.3-rc.1", v.String())
+
+	for _, s := range []string{"1.2", "v1.2.3", "1.02.3", "1.2.3-", "1.2.3-rc..1", "1.2.3-01"} {
+		_, err := semver.Parse(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// Ordered by precedence, from the semver specification.
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.10.0", "2.0.0",
+	}
+	for i := 1; i < len(ordered); i++ {
+		a, err := semver.Parse(ordered[i-1])
+		require.NoError(t, err)
+		b, err := semver.Parse(ordered[i])
+		require.NoError(t, err)
+
+		assert.Equal(t, -1, semver.Compare(a, b), "%s < %s", a, b)
+		assert.Equal(t, 1, semver.Compare(b, a), "%s > %s", b, a)
+		assert.Equal(t, 0, semver.Compare(a, a))
+	}
+}
+
+func TestBump(t *testing.T) {
+	tests := []struct {
+		from  string
+		level semver.Level
+		want  string
+	}{
+		{"1.2.3", semver.Patch, "1.2.4"},
+		{"1.2.3", semver.Minor, "1.3.0"},
+		{"1.2.3", semver.Major, "2.0.0"},
+		{"1.3.0-rc.1", semver.Patch, "1.3.0"},
+		{"1.3.0-rc.1", semver.Minor, "1.3.0"},
+		{"1.3.0-rc.1", semver.Major, "2.0.0"},
+		{"2.0.0-rc.1", semver.Major, "2.0.0"},
+	}
+
+	for _, tt := range tests {
+		v, err := semver.Parse(tt.from)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, v.Bump(tt.level).String(), "%s %s", tt.from, tt.level)
+	}
+}
+
+func TestTags(t *testing.T) {
+	tags := semver.Tags([]string{"svc-a/v1.2.0", "svc-a/v1.10.0-rc.1", "svc-b/v3.0.0", "svc-a/v1.9.0", "svc-a/latest"}, "svc-a/v")
+
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	assert.Equal(t, []string{"svc-a/v1.10.0-rc.1", "svc-a/v1.9.0", "svc-a/v1.2.0"}, names)
+}
+
+func TestNextPre(t *testing.T) {
+	v := semver.Version{Major: 1, Minor: 3}
+	existing := []semver.Version{
+		{Major: 1, Minor: 3, Pre: "rc.1"},
+		{Major: 1, Minor: 3, Pre: "rc.2"},
+		{Major: 1, Minor: 3, Pre: "beta.7"},
+		{Major: 1, Minor: 2, Pre: "rc.9"},
+	}
+
+	assert.Equal(t, "1.3.0-rc.3", semver.NextPre(v, "rc", existing).String())
+	assert.Equal(t, "1.3.0-alpha.1", semver.NextPre(v, "alpha", existing).String())
+}
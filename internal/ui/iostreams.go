@@ -1,8 +1,14 @@
 package ui
 
 import (
+	"bufio"
+	"encoding/json"
+	"fmt"
 	"io"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/briandowns/spinner"
@@ -13,7 +19,18 @@ type IOStreams struct {
 	Out    io.Writer
 	ErrOut io.Writer
 
+	reader  *bufio.Reader
 	spinner *spinner.Spinner
+	// streamed tracks whether Stream wrote text since the last StartSpinner,
+	// and whether that text ended with a newline.
+	streamed        bool
+	streamedNewline bool
+
+	// In JSON mode stdout is reserved for a single document: progress is
+	// hidden and warnings are collected for the document instead of printed.
+	jsonMode bool
+	mu       sync.Mutex
+	warnings []string
 }
 
 func Default() *IOStreams {
@@ -24,7 +41,56 @@ func Default() *IOStreams {
 	}
 }
 
+// IsTerminal reports whether ErrOut, where progress is rendered, is an
+// interactive terminal.
+func (s *IOStreams) IsTerminal() bool {
+	return isTerminal(s.ErrOut)
+}
+
+// SetJSON switches the streams to JSON mode, where the only output on Out
+// is the document passed to WriteJSON.
+func (s *IOStreams) SetJSON(on bool) {
+	s.jsonMode = on
+}
+
+// JSON reports whether the streams are in JSON mode.
+func (s *IOStreams) JSON() bool {
+	return s.jsonMode
+}
+
+// HumanOut is where text meant for people, such as the output of git or gh,
+// is written: Out normally, and ErrOut in JSON mode so it does not corrupt
+// the document.
+func (s *IOStreams) HumanOut() io.Writer {
+	if s.jsonMode {
+		return s.ErrOut
+	}
+	return s.Out
+}
+
+// WriteJSON writes v to Out as an indented JSON document.
+func (s *IOStreams) WriteJSON(v any) error {
+	enc := json.NewEncoder(s.Out)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+// Warnings returns the warnings collected in JSON mode.
+func (s *IOStreams) Warnings() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.warnings)
+}
+
+// StartSpinner shows an animated label on ErrOut. Nothing is drawn when
+// ErrOut is not a terminal, so redirected output stays free of noise.
 func (s *IOStreams) StartSpinner(label string) {
+	s.streamed = false
+	if s.jsonMode || !s.IsTerminal() {
+		return
+	}
+
 	sp := spinner.New(
 		spinner.CharSets[14],
 		time.Millisecond*100,
@@ -37,9 +103,67 @@ func (s *IOStreams) StartSpinner(label string) {
 	s.spinner = sp
 }
 
+// Stream renders a chunk of generated text. The spinner is replaced by the
+// text on the first chunk so the output reads as one continuous message.
+// Nothing is rendered in JSON mode.
+func (s *IOStreams) Stream(chunk string) {
+	if s.jsonMode {
+		return
+	}
+	if s.spinner != nil {
+		s.spinner.Stop()
+		s.spinner = nil
+	}
+
+	io.WriteString(s.ErrOut, chunk)
+	s.streamed = true
+	s.streamedNewline = strings.HasSuffix(chunk, "\n")
+}
+
 func (s *IOStreams) StopSpinner() {
 	if s.spinner != nil {
 		s.spinner.Stop()
 		s.spinner = nil
 	}
+
+	if s.streamed && !s.streamedNewline {
+		io.WriteString(s.ErrOut, "\n")
+	}
+	s.streamed = false
+}
+
+// Warnf prints a non-fatal warning to ErrOut. A running spinner is paused so
+// the message does not get mixed with the animation. In JSON mode the
+// warning is collected instead, see Warnings.
+func (s *IOStreams) Warnf(format string, args ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.jsonMode {
+		s.warnings = append(s.warnings, fmt.Sprintf(format, args...))
+		return
+	}
+
+	if s.spinner != nil {
+		s.spinner.Stop()
+		defer s.spinner.Start()
+	}
+	if s.streamed && !s.streamedNewline {
+		io.WriteString(s.ErrOut, "\n")
+		s.streamedNewline = true
+	}
+
+	fmt.Fprintf(s.ErrOut, "warning: "+format+"\n", args...)
+}
+
+func isTerminal(v any) bool {
+	f, ok := v.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
 }
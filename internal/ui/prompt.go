@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// CanPrompt reports whether both input and output are attached to a
+// terminal, so the user can answer questions.
+func (s *IOStreams) CanPrompt() bool {
+	return isTerminal(s.In) && isTerminal(s.ErrOut)
+}
+
+// Show prints a titled block of text, such as a generated message.
+func (s *IOStreams) Show(title, text string) {
+	fmt.Fprintf(s.ErrOut, "\n%s\n%s\n%s\n%s\n",
+		title, strings.Repeat("─", 50), strings.TrimRight(text, "\n"), strings.Repeat("─", 50))
+}
+
+// Select asks the user to pick one of options and returns its index.
+// Options can be chosen by number or by an unambiguous prefix.
+func (s *IOStreams) Select(label string, options []string) (int, error) {
+	var menu strings.Builder
+	for i, opt := range options {
+		fmt.Fprintf(&menu, "  %d) %s\n", i+1, opt)
+	}
+
+	for {
+		fmt.Fprintf(s.ErrOut, "%s\n%s> ", label, menu.String())
+
+		answer, err := s.readLine()
+		if err != nil {
+			return 0, err
+		}
+
+		if i, ok := matchOption(answer, options); ok {
+			return i, nil
+		}
+		fmt.Fprintf(s.ErrOut, "Invalid choice %q.\n", answer)
+	}
+}
+
+// Input asks for a line of free text.
+func (s *IOStreams) Input(label string) (string, error) {
+	fmt.Fprintf(s.ErrOut, "%s\n> ", label)
+	return s.readLine()
+}
+
+// Edit opens text in the editor git is configured to use and returns the
+// result with comment lines removed.
+func (s *IOStreams) Edit(text string) (string, error) {
+	f, err := os.CreateTemp("", "ditto-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	content := text + "\n\n# Lines starting with '#' are ignored.\n"
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return "", fmt.Errorf("write temp file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("write temp file: %w", err)
+	}
+
+	cmd := editorCommand(f.Name())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("run editor: %w", err)
+	}
+
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", fmt.Errorf("read temp file: %w", err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(edited), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
+
+func (s *IOStreams) readLine() (string, error) {
+	if s.reader == nil {
+		s.reader = bufio.NewReader(s.In)
+	}
+
+	line, err := s.reader.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", fmt.Errorf("read input: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func matchOption(answer string, options []string) (int, bool) {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer == "" {
+		return 0, false
+	}
+
+	if n, err := strconv.Atoi(answer); err == nil {
+		return n - 1, n >= 1 && n <= len(options)
+	}
+
+	match := -1
+	for i, opt := range options {
+		if strings.HasPrefix(strings.ToLower(opt), answer) {
+			if match >= 0 {
+				return 0, false
+			}
+			match = i
+		}
+	}
+	return match, match >= 0
+}
+
+// editorCommand resolves the editor like git does (GIT_EDITOR, core.editor,
+// VISUAL, EDITOR) and runs it through the shell since it may carry arguments,
+// e.g. "code --wait".
+func editorCommand(path string) *exec.Cmd {
+	editor := "vi"
+	if out, err := exec.Command("git", "var", "GIT_EDITOR").Output(); err == nil {
+		if e := strings.TrimSpace(string(out)); e != "" {
+			editor = e
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		args := append(strings.Fields(editor), path)
+		return exec.Command(args[0], args[1:]...)
+	}
+	return exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+}
@@ -0,0 +1,24 @@
+package ui_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/ui"
+)
+
+func TestSelect(t *testing.T) {
+	var out bytes.Buffer
+	s := &ui.IOStreams{In: strings.NewReader("re\nreg\n"), ErrOut: &out}
+
+	i, err := s.Select("What do you want to do?", []string{"accept", "regenerate", "refine"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, i)
+
+	assert.Contains(t, out.String(), "What do you want to do?\n  1) accept\n  2) regenerate\n  3) refine\n> ")
+	assert.Contains(t, out.String(), `Invalid choice "re".`)
+}
@@ -2,20 +2,48 @@ package vcs
 
 import (
 	"context"
+	"errors"
+	"io"
+	"strings"
 
 	"github.com/arthvm/ditto/internal/git"
+	"github.com/arthvm/ditto/internal/workflow"
 )
 
+// ErrAllIgnored is returned by CommitDiff and RangeDiff when every change
+// matches an ignore pattern, leaving nothing that may be sent to the model.
+var ErrAllIgnored = errors.New("every change matches an ignore pattern, so there is nothing to describe")
+
 // Git implements the workflow.VCS interface using the git CLI.
-type Git struct{}
+type Git struct {
+	// Ignore lists gitignore-style patterns of paths left out of the diffs
+	// handed to the model. They are still committed.
+	Ignore []string
+	// Out receives the output of git commit. Nil means os.Stdout.
+	Out io.Writer
+}
 
 func (g Git) CommitDiff(ctx context.Context, amend, all bool) (string, error) {
 	opts := buildDiffOptions(amend, all)
-	return git.Diff(ctx, opts...)
+
+	diff, err := git.Diff(ctx, append(opts, git.Excludes(g.Ignore)...)...)
+	if err != nil || strings.TrimSpace(diff) != "" || len(g.Ignore) == 0 {
+		return diff, err
+	}
+
+	// The diff may be empty because every change is ignored. Not even the
+	// names of ignored paths may reach the prompt, so this is an error
+	// rather than a diff stat.
+	names, err := git.Diff(ctx, append(opts, git.NameOnly)...)
+	if err != nil || strings.TrimSpace(names) == "" {
+		return "", err
+	}
+	return "", ErrAllIgnored
 }
 
 func (g Git) DiffStats(ctx context.Context, base, head string) (string, error) {
-	return git.Diff(ctx, git.Stats, git.Branches(base, head))
+	opts := []git.DiffArg{git.Stats, git.Branches(base, head)}
+	return git.Diff(ctx, append(opts, git.Excludes(g.Ignore)...)...)
 }
 
 func (g Git) Log(ctx context.Context, base, head string) (string, error) {
@@ -30,6 +58,17 @@ func (g Git) Root(ctx context.Context) (string, error) {
 	return git.Root(ctx)
 }
 
+// Messages returns the non-merge commits of revRange, newest first.
+func (g Git) Messages(ctx context.Context, revRange string) ([]git.Commit, error) {
+	return git.Messages(ctx, revRange)
+}
+
+// HooksDir returns the directory git runs hooks from, which honors
+// core.hooksPath.
+func (g Git) HooksDir(ctx context.Context) (string, error) {
+	return git.GitPath(ctx, "hooks")
+}
+
 func (g Git) CommitWithMessage(ctx context.Context, msg string, amend, all, edit bool) error {
 	var opts []git.CommitOption
 	if amend {
@@ -41,7 +80,218 @@ func (g Git) CommitWithMessage(ctx context.Context, msg string, amend, all, edit
 	if edit {
 		opts = append(opts, git.Edit)
 	}
-	return git.CommitWithMsg(ctx, msg, opts...)
+	return git.CommitWithMsg(ctx, msg, g.Out, opts...)
+}
+
+// SplitDiff returns the staged changes, or the unstaged changes to tracked
+// files when worktree is true, including binary changes so that the patch
+// can be applied to the index. Ignored paths are included.
+func (g Git) SplitDiff(ctx context.Context, worktree bool) (string, error) {
+	return git.Diff(ctx, append(splitDiffOptions(worktree), git.Binary)...)
+}
+
+// HiddenPaths returns the paths of the same diff that match Ignore.
+func (g Git) HiddenPaths(ctx context.Context, worktree bool) ([]string, error) {
+	if len(g.Ignore) == 0 {
+		return nil, nil
+	}
+
+	opts := append(splitDiffOptions(worktree), git.NameOnly)
+	all, err := git.Diff(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	visible, err := git.Diff(ctx, append(opts, git.Excludes(g.Ignore)...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	shown := make(map[string]bool)
+	for _, path := range strings.Split(strings.TrimSpace(visible), "\n") {
+		shown[path] = true
+	}
+
+	var hidden []string
+	for _, path := range strings.Split(strings.TrimSpace(all), "\n") {
+		if !shown[path] {
+			hidden = append(hidden, path)
+		}
+	}
+	return hidden, nil
+}
+
+// SaveIndex records HEAD and writes the index to a tree. Head is left empty
+// on a branch with no commits yet.
+func (g Git) SaveIndex(ctx context.Context) (workflow.IndexState, error) {
+	var head string
+	hasHead, err := git.HasHead(ctx)
+	if err != nil {
+		return workflow.IndexState{}, err
+	}
+	if hasHead {
+		if head, err = git.RevParse(ctx, "HEAD"); err != nil {
+			return workflow.IndexState{}, err
+		}
+	}
+	tree, err := git.WriteTree(ctx)
+	if err != nil {
+		return workflow.IndexState{}, err
+	}
+	return workflow.IndexState{Head: head, Tree: tree}, nil
+}
+
+// RestoreIndex points HEAD back at state.Head, or deletes the branch again
+// when it had no commits, and reads the saved index.
+func (g Git) RestoreIndex(ctx context.Context, state workflow.IndexState) error {
+	const reason = "ditto split: restore"
+	if state.Head == "" {
+		hasHead, err := git.HasHead(ctx)
+		if err != nil {
+			return err
+		}
+		if hasHead {
+			if err := git.DeleteRef(ctx, "HEAD", reason); err != nil {
+				return err
+			}
+		}
+	} else if err := git.UpdateRef(ctx, "HEAD", state.Head, "", reason); err != nil {
+		return err
+	}
+	return git.ReadTree(ctx, state.Tree)
+}
+
+// ResetIndex makes the index match HEAD, or empties it on a branch with no
+// commits yet.
+func (g Git) ResetIndex(ctx context.Context) error {
+	hasHead, err := git.HasHead(ctx)
+	if err != nil {
+		return err
+	}
+	if hasHead {
+		return git.ReadTree(ctx, "HEAD")
+	}
+
+	empty, err := git.EmptyTree(ctx)
+	if err != nil {
+		return err
+	}
+	return git.ReadTree(ctx, empty)
+}
+
+func (g Git) StagePatch(ctx context.Context, patch string) error {
+	return git.ApplyCached(ctx, patch)
+}
+
+// RangeCommits returns the commits of revRange with their messages,
+// oldest first.
+func (g Git) RangeCommits(ctx context.Context, revRange string) ([]workflow.HistoryCommit, error) {
+	list, err := git.RevList(ctx, revRange)
+	if err != nil {
+		return nil, err
+	}
+	logged, err := git.Messages(ctx, revRange)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make(map[string]string, len(logged))
+	for _, c := range logged {
+		messages[c.Hash] = c.Message
+	}
+
+	commits := make([]workflow.HistoryCommit, len(list))
+	for i, entry := range list {
+		commits[i] = workflow.HistoryCommit{Hash: entry[0], Parents: entry[1:], Message: messages[entry[0]]}
+	}
+	return commits, nil
+}
+
+func (g Git) Reachable(ctx context.Context, revRange, rev string) ([]string, error) {
+	return git.Reachable(ctx, revRange, rev)
+}
+
+// CommitPatch returns the diff of c against its first parent, or against
+// the empty tree for a root commit, without the ignored paths.
+func (g Git) CommitPatch(ctx context.Context, c workflow.HistoryCommit) (string, error) {
+	parent := ""
+	if len(c.Parents) > 0 {
+		parent = c.Parents[0]
+	} else {
+		var err error
+		if parent, err = git.EmptyTree(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	return g.RangeDiff(ctx, parent, c.Hash)
+}
+
+func (g Git) Reword(ctx context.Context, base string, messages map[string]string) (string, error) {
+	return git.Reword(ctx, base, messages)
+}
+
+func (g Git) RevParse(ctx context.Context, rev string) (string, error) {
+	return git.RevParse(ctx, rev)
+}
+
+func (g Git) MergeBase(ctx context.Context, a, b string) (string, error) {
+	return git.MergeBase(ctx, a, b)
+}
+
+// RangeDiff returns the diff between two revisions without the ignored
+// paths. Like CommitDiff, it fails with ErrAllIgnored when every change is
+// ignored.
+func (g Git) RangeDiff(ctx context.Context, from, to string) (string, error) {
+	opts := []git.DiffArg{git.Target(from), git.Target(to)}
+	diff, err := git.Diff(ctx, append(opts, git.Excludes(g.Ignore)...)...)
+	if err != nil || strings.TrimSpace(diff) != "" || len(g.Ignore) == 0 {
+		return diff, err
+	}
+
+	names, err := git.Diff(ctx, append(opts, git.NameOnly)...)
+	if err != nil || strings.TrimSpace(names) == "" {
+		return "", err
+	}
+	return "", ErrAllIgnored
+}
+
+func (g Git) CountCommits(ctx context.Context, revRange string) (int, error) {
+	commits, err := git.RevList(ctx, revRange)
+	return len(commits), err
+}
+
+// SoftReset moves the current branch to rev, keeping the index and the
+// working tree.
+func (g Git) SoftReset(ctx context.Context, rev string) error {
+	return git.ResetSoft(ctx, rev)
+}
+
+func (g Git) Tags(ctx context.Context, rev string) ([]string, error) {
+	return git.Tags(ctx, rev)
+}
+
+// CreateTag creates an annotated tag on HEAD.
+func (g Git) CreateTag(ctx context.Context, name, msg string) error {
+	return git.CreateTag(ctx, name, msg)
+}
+
+func (g Git) CommitDate(ctx context.Context, rev string) (string, error) {
+	return git.CommitDate(ctx, rev)
+}
+
+func (g Git) CheckBranchName(ctx context.Context, name string) error {
+	return git.CheckBranchName(ctx, name)
+}
+
+func (g Git) CreateBranch(ctx context.Context, name string) error {
+	return git.CreateBranch(ctx, name)
+}
+
+func splitDiffOptions(worktree bool) []git.DiffArg {
+	if worktree {
+		return nil
+	}
+	return []git.DiffArg{git.Staged}
 }
 
 func buildDiffOptions(amend, all bool) []git.DiffArg {
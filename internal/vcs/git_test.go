@@ -0,0 +1,121 @@
+package vcs_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/vcs"
+)
+
+func TestCommitDiffIgnore(t *testing.T) {
+	ctx := context.Background()
+
+	repo := t.TempDir()
+	t.Chdir(repo)
+	require.NoError(t, exec.CommandContext(ctx, "git", "init").Run())
+
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "vendor"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "vendor", "lib.go"), []byte("package lib\n"), 0o644))
+	require.NoError(t, exec.CommandContext(ctx, "git", "add", "vendor").Run())
+
+	g := vcs.Git{Ignore: []string{"vendor/"}}
+
+	diff, err := g.CommitDiff(ctx, false, false)
+	assert.ErrorIs(t, err, vcs.ErrAllIgnored)
+	assert.Empty(t, diff)
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0o644))
+	require.NoError(t, exec.CommandContext(ctx, "git", "add", "main.go").Run())
+
+	diff, err = g.CommitDiff(ctx, false, false)
+	require.NoError(t, err)
+	assert.Contains(t, diff, "main.go")
+	assert.NotContains(t, diff, "vendor")
+
+	require.NoError(t, exec.CommandContext(ctx, "git", "reset", "--quiet").Run())
+
+	diff, err = g.CommitDiff(ctx, false, false)
+	require.NoError(t, err, "no changes at all is not an ignore error")
+	assert.Empty(t, diff)
+}
+
+func TestSplitIndexWithoutCommits(t *testing.T) {
+	ctx := context.Background()
+
+	repo := t.TempDir()
+	t.Chdir(repo)
+	git := func(args ...string) string {
+		out, err := exec.CommandContext(ctx, "git", args...).Output()
+		require.NoError(t, err, "git %v", args)
+		return strings.TrimSpace(string(out))
+	}
+	git("init")
+	git("config", "user.name", "Test")
+	git("config", "user.email", "test@example.com")
+
+	require.NoError(t, os.WriteFile("a.txt", []byte("a\n"), 0o644))
+	require.NoError(t, os.WriteFile("b.txt", []byte("b\n"), 0o644))
+	git("add", "a.txt", "b.txt")
+
+	g := vcs.Git{}
+	state, err := g.SaveIndex(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, state.Head)
+
+	require.NoError(t, g.ResetIndex(ctx))
+	assert.Empty(t, git("ls-files"))
+
+	git("add", "a.txt")
+	git("commit", "-q", "-m", "feat: add a")
+
+	require.NoError(t, g.RestoreIndex(ctx, state))
+	_, err = exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", "HEAD").Output()
+	assert.Error(t, err, "the branch has no commits again")
+	assert.Equal(t, "a.txt\nb.txt", git("ls-files"))
+}
+
+func TestRangeDiffIgnore(t *testing.T) {
+	ctx := context.Background()
+
+	repo := t.TempDir()
+	t.Chdir(repo)
+	git := func(args ...string) string {
+		out, err := exec.CommandContext(ctx, "git", args...).Output()
+		require.NoError(t, err, "git %v", args)
+		return strings.TrimSpace(string(out))
+	}
+	git("init")
+	git("config", "user.name", "Test")
+	git("config", "user.email", "test@example.com")
+	git("commit", "--quiet", "--allow-empty", "-m", "chore: init")
+
+	require.NoError(t, os.WriteFile("secrets.env", []byte("TOKEN=abc\n"), 0o644))
+	require.NoError(t, os.WriteFile("go.sum", []byte("example.com/x v1.0.0 h1:abc=\n"), 0o644))
+	git("add", "secrets.env", "go.sum")
+	git("commit", "--quiet", "-m", "wip")
+
+	g := vcs.Git{Ignore: []string{"secrets.env", "go.sum"}}
+
+	diff, err := g.RangeDiff(ctx, "HEAD~1", "HEAD")
+	assert.ErrorIs(t, err, vcs.ErrAllIgnored)
+	assert.NotContains(t, diff, "secrets.env")
+	assert.NotContains(t, diff, "go.sum")
+	assert.NotContains(t, err.Error(), "secrets.env")
+
+	require.NoError(t, os.WriteFile("main.go", []byte("package main\n"), 0o644))
+	git("add", "main.go")
+	git("commit", "--quiet", "-m", "feat: add main")
+
+	diff, err = g.RangeDiff(ctx, "HEAD~2", "HEAD")
+	require.NoError(t, err)
+	assert.Contains(t, diff, "main.go")
+	assert.NotContains(t, diff, "secrets.env")
+	assert.NotContains(t, diff, "go.sum")
+}
@@ -0,0 +1,187 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arthvm/ditto/internal/branch"
+	"github.com/arthvm/ditto/internal/prompt"
+	"github.com/arthvm/ditto/internal/response"
+)
+
+// BranchVCS creates branches.
+type BranchVCS interface {
+	// CheckBranchName returns an error when name is not a valid branch name.
+	CheckBranchName(ctx context.Context, name string) error
+
+	// CreateBranch creates a branch at HEAD and checks it out.
+	CreateBranch(ctx context.Context, name string) error
+}
+
+// Issue is an issue of the hosting platform.
+type Issue struct {
+	ID    string
+	Title string
+	Body  string
+}
+
+// IssueTracker reads issues from the hosting platform.
+type IssueTracker interface {
+	Issue(ctx context.Context, id string) (Issue, error)
+}
+
+type BranchDeps struct {
+	VCS BranchVCS
+	// Issues looks up the issues to name the branch after. Nil means only
+	// their IDs are known.
+	Issues          IssueTracker
+	Provider        Provider
+	Progress        Progress
+	GenerateTimeout time.Duration
+	// Redactor masks secrets in the description and issues. Nil disables
+	// redaction.
+	Redactor Redactor
+}
+
+type BranchParams struct {
+	Description string
+	Issues      []string
+	// Pattern is the shape of the name, see the branch package.
+	Pattern           string
+	Types             []string
+	MaxLength         int
+	AdditionalContext string
+	// DryRun generates the name without creating the branch.
+	DryRun bool
+}
+
+// BranchResult describes the branch Branch named and created.
+type BranchResult struct {
+	Name    string
+	Type    string
+	Slug    string
+	Created bool
+	Timings Timings
+}
+
+// Branch names a branch after a description of the work, the issues it
+// addresses, or both, following a pattern, and checks it out.
+func Branch(ctx context.Context, deps BranchDeps, params BranchParams) (BranchResult, error) {
+	var res BranchResult
+	started := time.Now()
+
+	if strings.TrimSpace(params.Description) == "" && len(params.Issues) == 0 {
+		return res, errors.New("describe the work or give the issues it addresses")
+	}
+	if err := branch.Validate(params.Pattern); err != nil {
+		return res, fmt.Errorf("branch pattern: %w", err)
+	}
+
+	issues, err := describeIssues(ctx, deps, params)
+	if err != nil {
+		return res, err
+	}
+
+	description := params.Description
+	redactAll(deps.Redactor, deps.Progress, &description)
+	for i := range issues {
+		redactAll(deps.Redactor, deps.Progress, &issues[i])
+	}
+
+	system := prompt.BranchSystem(params.Types, params.AdditionalContext)
+	user := prompt.BranchUser(description, issues)
+	res.Timings.Collect = time.Since(started)
+	started = time.Now()
+
+	genOpts := generateOptions{
+		Provider: deps.Provider,
+		Progress: deps.Progress,
+		Timeout:  deps.GenerateTimeout,
+		Schema:   branchSchema(params.Types),
+	}
+	text, err := generate(ctx, genOpts, " Naming branch...", system, user)
+	res.Timings.Generate = time.Since(started)
+	if err != nil {
+		return res, fmt.Errorf("generate branch name: %w", err)
+	}
+
+	var named struct {
+		Type string `json:"type"`
+		Slug string `json:"slug"`
+	}
+	if err := response.DecodeJSON(text, &named); err != nil {
+		return res, fmt.Errorf("parse branch name: %w", err)
+	}
+
+	i := slices.IndexFunc(params.Types, func(t string) bool { return strings.EqualFold(t, strings.TrimSpace(named.Type)) })
+	if i < 0 {
+		return res, fmt.Errorf("the model chose the type %q, which is not one of: %s", named.Type, strings.Join(params.Types, ", "))
+	}
+	res.Type = params.Types[i]
+	res.Slug = branch.Slugify(named.Slug)
+	if res.Slug == "" {
+		return res, errors.New("the model returned an empty slug")
+	}
+
+	var issue string
+	if len(params.Issues) > 0 {
+		issue = params.Issues[0]
+	}
+	res.Name = branch.Name(params.Pattern, branch.Fields{Type: res.Type, Issue: issue, Slug: res.Slug}, params.MaxLength)
+	if err := deps.VCS.CheckBranchName(ctx, res.Name); err != nil {
+		return res, fmt.Errorf("invalid branch name %q: %w", res.Name, err)
+	}
+
+	if params.DryRun {
+		return res, nil
+	}
+
+	if err := deps.VCS.CreateBranch(ctx, res.Name); err != nil {
+		return res, fmt.Errorf("create branch: %w", err)
+	}
+	res.Created = true
+	return res, nil
+}
+
+// describeIssues renders the issues for the prompt, with their title and
+// body when the tracker knows them. An issue that cannot be read is only a
+// problem when there is no description to fall back on.
+func describeIssues(ctx context.Context, deps BranchDeps, params BranchParams) ([]string, error) {
+	described := make([]string, len(params.Issues))
+	for i, id := range params.Issues {
+		described[i] = id
+		if deps.Issues == nil {
+			continue
+		}
+
+		issue, err := deps.Issues.Issue(ctx, id)
+		if err != nil {
+			if strings.TrimSpace(params.Description) == "" {
+				return nil, fmt.Errorf("read issue %s: %w", id, err)
+			}
+			deps.Progress.Warnf("could not read issue %s: %v", id, err)
+			continue
+		}
+		described[i] = fmt.Sprintf("%s: %s\n%s", id, issue.Title, strings.TrimSpace(issue.Body))
+	}
+	return described, nil
+}
+
+func branchSchema(types []string) *Schema {
+	return &Schema{
+		Name: "branch",
+		Definition: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"type": map[string]any{"type": "string", "enum": types},
+				"slug": map[string]any{"type": "string"},
+			},
+			"required":             []string{"type", "slug"},
+			"additionalProperties": false,
+		},
+	}
+}
@@ -0,0 +1,82 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// generateCandidates asks the provider for n alternative responses at once.
+// Responses are not streamed since they arrive interleaved, and are cleaned
+// like those of generate. Failed requests are reported and skipped, and
+// blank or duplicate responses are dropped, so n is an upper bound; an
+// error is returned when no candidate is left.
+func generateCandidates(ctx context.Context, opts generateOptions, label, system, user string, n int) ([]string, error) {
+	opts.Progress.StartSpinner(label)
+
+	results := make([]string, n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			genCtx, genCancel := withGenerateTimeout(ctx, opts.Timeout)
+			defer genCancel()
+
+			results[i], _, errs[i] = opts.call(genCtx, system, user, false)
+		}()
+	}
+	wg.Wait()
+	opts.Progress.StopSpinner()
+
+	var candidates []string
+	seen := make(map[string]bool)
+	for i, res := range results {
+		if errs[i] != nil {
+			opts.Progress.Warnf("candidate %d of %d failed: %v", i+1, n, errs[i])
+			continue
+		}
+
+		key := strings.TrimSpace(res)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		candidates = append(candidates, res)
+	}
+
+	if len(candidates) == 0 {
+		return nil, errors.Join(errors.New("no usable candidates"), errors.Join(errs...))
+	}
+	if len(candidates) < n {
+		opts.Progress.Warnf("got %d distinct candidates out of %d requested", len(candidates), n)
+	}
+	return candidates, nil
+}
+
+// pickCandidate shows every candidate and asks the user to choose one. The
+// menu lists the first line of each candidate.
+func pickCandidate(prompter Prompter, label string, candidates []string) (string, error) {
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	options := make([]string, len(candidates))
+	for i, c := range candidates {
+		prompter.Show(fmt.Sprintf("Candidate %d:", i+1), c)
+
+		subject, _, _ := strings.Cut(strings.TrimSpace(c), "\n")
+		options[i] = subject
+	}
+
+	i, err := prompter.Select("Which "+label+" do you want to use?", options)
+	if err != nil {
+		return "", err
+	}
+	return candidates[i], nil
+}
@@ -0,0 +1,201 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/arthvm/ditto/internal/changelog"
+	"github.com/arthvm/ditto/internal/prompt"
+	"github.com/arthvm/ditto/internal/response"
+	"github.com/arthvm/ditto/internal/semver"
+)
+
+// ChangelogVCS reads the commits and tags of a release.
+type ChangelogVCS interface {
+	// Tags returns the tags that can be reached from rev.
+	Tags(ctx context.Context, rev string) ([]string, error)
+
+	// RevParse resolves rev to a commit hash.
+	RevParse(ctx context.Context, rev string) (string, error)
+
+	// RangeCommits returns the commits of revRange, oldest first.
+	RangeCommits(ctx context.Context, revRange string) ([]HistoryCommit, error)
+
+	// CommitDate returns the date rev was committed on, as YYYY-MM-DD.
+	CommitDate(ctx context.Context, rev string) (string, error)
+}
+
+type ChangelogDeps struct {
+	VCS             ChangelogVCS
+	Provider        Provider
+	Progress        Progress
+	GenerateTimeout time.Duration
+	// Redactor masks secrets in the commit messages. Nil disables redaction.
+	Redactor Redactor
+}
+
+type ChangelogParams struct {
+	// From is the last revision of the previous release. Empty means the
+	// latest tag before To, or the whole history when there is none.
+	From string
+	To   string
+	// Version names the release. Empty means the version of the tag To
+	// points at, or unreleased changes when it is not tagged.
+	Version string
+	// TagPrefix comes before the version in release tag names. Other tags
+	// are ignored.
+	TagPrefix         string
+	SystemPrompt      string
+	AdditionalContext string
+}
+
+// ChangelogResult describes the release notes Changelog wrote.
+type ChangelogResult struct {
+	Release changelog.Release
+	// From is empty when the notes cover the whole history.
+	From    string
+	To      string
+	Commits int
+	Timings Timings
+}
+
+// Changelog writes user-facing release notes from the feat, fix, perf and
+// breaking commits between two revisions.
+func Changelog(ctx context.Context, deps ChangelogDeps, params ChangelogParams) (ChangelogResult, error) {
+	res := ChangelogResult{From: params.From, To: params.To}
+	started := time.Now()
+
+	if res.To == "" {
+		res.To = "HEAD"
+	}
+
+	tag, previous, err := releaseTags(ctx, deps.VCS, res.To, params.TagPrefix)
+	if err != nil {
+		return res, err
+	}
+	if res.From == "" {
+		res.From = previous
+	}
+
+	release := changelog.Release{Version: params.Version}
+	switch {
+	case release.Version != "":
+		release.Date = time.Now().Format(time.DateOnly)
+	case tag != "":
+		release.Version = strings.TrimPrefix(tag, params.TagPrefix)
+		release.Date, err = deps.VCS.CommitDate(ctx, res.To)
+		if err != nil {
+			return res, fmt.Errorf("get release date: %w", err)
+		}
+	}
+
+	revRange := res.To
+	if res.From != "" {
+		revRange = res.From + ".." + res.To
+	}
+	history, err := deps.VCS.RangeCommits(ctx, revRange)
+	if err != nil {
+		return res, fmt.Errorf("get commits: %w", err)
+	}
+	res.Commits = len(history)
+
+	commits := make([]changelog.Commit, 0, len(history))
+	for _, c := range history {
+		if len(c.Parents) < 2 {
+			commits = append(commits, changelog.Commit{Hash: c.Hash, Message: c.Message})
+		}
+	}
+	groups := changelog.Group(commits)
+	if groups.Len() == 0 {
+		return res, fmt.Errorf("no feat, fix, perf or breaking commits in %s", revRange)
+	}
+
+	changes := groups.String()
+	redactAll(deps.Redactor, deps.Progress, &changes)
+
+	version := release.Version
+	if version == "" {
+		version = "Unreleased"
+	}
+	system := prompt.ChangelogSystem(params.SystemPrompt, params.AdditionalContext)
+	user := prompt.ChangelogUser(version, changes)
+	res.Timings.Collect = time.Since(started)
+	started = time.Now()
+
+	genOpts := generateOptions{
+		Provider: deps.Provider,
+		Progress: deps.Progress,
+		Timeout:  deps.GenerateTimeout,
+		Schema:   changelogSchema(),
+	}
+	label := fmt.Sprintf(" Writing release notes for %d changes...", groups.Len())
+	text, err := generate(ctx, genOpts, label, system, user)
+	res.Timings.Generate = time.Since(started)
+	if err != nil {
+		return res, fmt.Errorf("generate changelog: %w", err)
+	}
+
+	if err := response.DecodeJSON(text, &release); err != nil {
+		return res, fmt.Errorf("parse changelog: %w", err)
+	}
+	if release.Empty() {
+		return res, fmt.Errorf("generate changelog: the model wrote no entries")
+	}
+
+	res.Release = release
+	return res, nil
+}
+
+// releaseTags returns the release tag rev points at, if any, and the
+// latest release tag of an earlier commit, if any.
+func releaseTags(ctx context.Context, vcs ChangelogVCS, rev, prefix string) (tag, previous string, err error) {
+	names, err := vcs.Tags(ctx, rev)
+	if err != nil {
+		return "", "", fmt.Errorf("list tags: %w", err)
+	}
+	tags := semver.Tags(names, prefix)
+	if len(tags) == 0 {
+		return "", "", nil
+	}
+
+	target, err := vcs.RevParse(ctx, rev+"^{commit}")
+	if err != nil {
+		return "", "", fmt.Errorf("resolve %s: %w", rev, err)
+	}
+
+	for _, t := range tags {
+		hash, err := vcs.RevParse(ctx, t.Name+"^{commit}")
+		if err != nil {
+			return "", "", fmt.Errorf("resolve %s: %w", t.Name, err)
+		}
+		if hash != target {
+			return tag, t.Name, nil
+		}
+		if tag == "" {
+			tag = t.Name
+		}
+	}
+	return tag, "", nil
+}
+
+func changelogSchema() *Schema {
+	list := map[string]any{"type": "array", "items": map[string]any{"type": "string"}}
+	fields := []string{"breaking", "added", "changed", "deprecated", "removed", "fixed", "security"}
+
+	properties := make(map[string]any, len(fields))
+	for _, f := range fields {
+		properties[f] = list
+	}
+
+	return &Schema{
+		Name: "changelog",
+		Definition: map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             fields,
+			"additionalProperties": false,
+		},
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/arthvm/ditto/internal/budget"
 	"github.com/arthvm/ditto/internal/prompt"
 )
 
@@ -15,6 +16,12 @@ type CommitDeps struct {
 	Provider        Provider
 	Progress        Progress
 	GenerateTimeout time.Duration
+	Stream          bool
+	// Redactor masks secrets in the diff. Nil disables redaction.
+	Redactor Redactor
+	// Prompter is used to review the message and choose between candidates.
+	// Nil means the user cannot be asked.
+	Prompter Prompter
 }
 
 type CommitParams struct {
@@ -24,44 +31,200 @@ type CommitParams struct {
 	SystemPrompt      string
 	AdditionalContext string
 	Issues            []string
+	// IssuePatterns find the issues in the name of the current branch when
+	// Issues is empty, see branch.Issues.
+	IssuePatterns []string
+	// MaxPromptTokens bounds the prompt size; oversized diffs are shrunk to
+	// fit. Zero disables the limit.
+	MaxPromptTokens int
+	// GeneratedFiles are extra patterns of files dropped first from an
+	// oversized diff.
+	GeneratedFiles []string
+	// MapReduce summarizes the diff in parts when it cannot fit the
+	// prompt budget without dropping hunks.
+	MapReduce MapReduceParams
+	// Interactive lets the user accept, regenerate, edit or refine the
+	// message before committing. The accepted message is committed as is.
+	Interactive bool
+	// Candidates is the number of alternative messages to generate. With a
+	// Prompter the user picks one; otherwise all of them are returned and
+	// nothing is committed.
+	Candidates int
+	// DryRun generates the message without committing it.
+	DryRun bool
+	// Lint checks the message against the Conventional Commits rules and
+	// asks the provider to fix what it broke. The issues referenced by
+	// Issues are added to the rules.
+	Lint LintParams
 }
 
-func Commit(ctx context.Context, deps CommitDeps, params CommitParams) error {
+// CommitResult describes what Commit generated and did.
+type CommitResult struct {
+	// Message is the message that was committed, or would have been in
+	// dry-run mode. It is empty when Candidates are returned instead.
+	Message string
+	// Candidates holds the generated alternatives when several were
+	// requested and none could be chosen interactively.
+	Candidates []string
+	// InferredIssues are the issues found in the name of the current branch
+	// because none were given.
+	InferredIssues []string
+	Committed      bool
+	Timings        Timings
+}
+
+func Commit(ctx context.Context, deps CommitDeps, params CommitParams) (CommitResult, error) {
+	var res CommitResult
+	started := time.Now()
+
 	diff, err := deps.VCS.CommitDiff(ctx, params.Amend, params.All)
 	if err != nil {
-		return fmt.Errorf("staged changes: %w", err)
+		return res, fmt.Errorf("staged changes: %w", err)
 	}
 
 	if strings.TrimSpace(diff) == "" {
 		if params.Amend || params.All {
-			return errors.New("no changes to commit")
+			return res, errors.New("no changes to commit")
 		}
-		return errors.New("no staged changes")
+		return res, errors.New("no staged changes")
+	}
+
+	redactAll(deps.Redactor, deps.Progress, &diff)
+
+	if len(params.Issues) == 0 && len(params.IssuePatterns) > 0 {
+		if branchName, err := deps.VCS.CurrentBranch(ctx); err != nil {
+			deps.Progress.Warnf("could not read issues from the branch name: %v", err)
+		} else {
+			params.Issues = branchIssues(deps.Progress, branchName, params.IssuePatterns)
+			res.InferredIssues = params.Issues
+		}
+	}
+
+	genOpts := generateOptions{
+		Provider: deps.Provider,
+		Progress: deps.Progress,
+		Timeout:  deps.GenerateTimeout,
+		Stream:   deps.Stream,
 	}
 
 	system := prompt.CommitSystem(params.SystemPrompt, params.AdditionalContext)
-	user := prompt.CommitUser(prompt.CommitParams{
-		Diff:   diff,
-		Issues: params.Issues,
-	})
+	userParams := prompt.CommitParams{Issues: params.Issues}
 
-	deps.Progress.StartSpinner(" Generating commit message...")
+	fit := fitDiff(diff, params.MaxPromptTokens, params.GeneratedFiles, system+prompt.CommitUser(userParams))
+	res.Timings.Collect = time.Since(started)
+	started = time.Now()
 
-	timeout := deps.GenerateTimeout
-	if timeout == 0 {
-		timeout = generateTimeout
+	if fit.Summarized && params.MapReduce.Enabled {
+		deps.Progress.Warnf("diff exceeds the prompt budget, summarizing it in parts")
+		userParams.Summaries, err = summarizeDiff(ctx, genOpts, diff, params.MapReduce, params.MaxPromptTokens, params.GeneratedFiles)
+		if err != nil {
+			return res, fmt.Errorf("summarize diff: %w", err)
+		}
+	} else {
+		reportFit(deps.Progress, fit, params.MaxPromptTokens)
+		userParams.Diff = fit.Diff
 	}
 
-	genCtx, genCancel := context.WithTimeout(ctx, timeout)
-	defer genCancel()
+	user := prompt.CommitUser(userParams)
 
-	msg, err := deps.Provider.Generate(genCtx, system, user)
+	// fix lints a generated message and asks the provider to repair it.
+	// It runs on every message the user may end up with: the first one,
+	// each candidate returned for scripts to choose from, and those
+	// regenerated or refined during review.
+	var fix func(user, msg string) (string, error)
+	if params.Lint.Enabled {
+		lint := params.Lint
+		lint.Rules.Issues = append(lint.Rules.Issues, params.Issues...)
+		fix = func(user, msg string) (string, error) {
+			return repairMessage(ctx, genOpts, lint, system, user, msg)
+		}
+	}
 
-	deps.Progress.StopSpinner()
+	var msg string
+	var shown bool
+	if params.Candidates > 1 {
+		candidates, err := generateCandidates(ctx, genOpts,
+			fmt.Sprintf(" Generating %d commit messages...", params.Candidates), system, user, params.Candidates)
+		res.Timings.Generate = time.Since(started)
+		if err != nil {
+			return res, fmt.Errorf("generate git commit: %w", err)
+		}
 
-	if err != nil {
-		return fmt.Errorf("generate git commit: %w", err)
+		if deps.Prompter == nil {
+			if fix != nil {
+				started = time.Now()
+				for i, c := range candidates {
+					if candidates[i], err = fix(user, c); err != nil {
+						return res, fmt.Errorf("fix git commit: %w", err)
+					}
+				}
+				res.Timings.Generate += time.Since(started)
+			}
+			res.Candidates = candidates
+			return res, nil
+		}
+
+		msg, err = pickCandidate(deps.Prompter, "commit message", candidates)
+		if err != nil {
+			return res, err
+		}
+	} else {
+		msg, shown, err = generateShown(ctx, genOpts, " Generating commit message...", system, user)
+		res.Timings.Generate = time.Since(started)
+		if err != nil {
+			return res, fmt.Errorf("generate git commit: %w", err)
+		}
 	}
 
-	return deps.VCS.CommitWithMessage(ctx, msg, params.Amend, params.All, params.Edit)
+	if fix != nil {
+		started = time.Now()
+		fixed, err := fix(user, msg)
+		res.Timings.Generate += time.Since(started)
+		if err != nil {
+			return res, fmt.Errorf("fix git commit: %w", err)
+		}
+		shown = shown && fixed == msg
+		msg = fixed
+	}
+
+	edit := params.Edit
+	if params.Interactive {
+		msg, err = review(ctx, deps.Prompter, genOpts, "commit message", system, user, msg, shown, fix)
+		if err != nil {
+			return res, err
+		}
+		edit = false
+	}
+
+	res.Message = strings.TrimSpace(msg)
+	if params.DryRun {
+		return res, nil
+	}
+
+	if err := deps.VCS.CommitWithMessage(ctx, msg, params.Amend, params.All, edit); err != nil {
+		return res, err
+	}
+	res.Committed = true
+	return res, nil
+}
+
+// fitDiff shrinks diff so that, together with the rest of the prompt, it
+// stays within maxTokens. A maxTokens of zero leaves the diff untouched.
+func fitDiff(diff string, maxTokens int, generated []string, rest string) budget.Result {
+	if maxTokens <= 0 {
+		return budget.Result{Diff: diff, Fits: true}
+	}
+
+	available := max(maxTokens-budget.EstimateTokens(rest), 1)
+	return budget.Fit(diff, budget.Options{MaxTokens: available, Generated: generated})
+}
+
+// reportFit tells the user what was left out of the diff.
+func reportFit(progress Progress, res budget.Result, maxTokens int) {
+	for _, step := range res.Steps {
+		progress.Warnf("diff exceeds the prompt budget, %s", step)
+	}
+	if !res.Fits {
+		progress.Warnf("diff is still larger than the prompt budget of ~%d tokens", maxTokens)
+	}
 }
@@ -0,0 +1,158 @@
+package workflow_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/workflow"
+)
+
+func TestCommitReviewLintsRegeneratedMessage(t *testing.T) {
+	provider := &scriptedProvider{replies: []string{
+		"feat: add widgets",
+		"Added widgets",
+		"fix: add widgets",
+	}}
+	vcs := &fakeVCS{diff: sampleDiff, branch: "main"}
+	prompter := &prompter{choices: []string{"regenerate", "accept"}}
+
+	res, err := workflow.Commit(context.Background(), workflow.CommitDeps{
+		VCS:      vcs,
+		Provider: provider,
+		Progress: &progress{},
+		Prompter: prompter,
+	}, workflow.CommitParams{
+		Interactive: true,
+		Lint:        workflow.LintParams{Enabled: true, Retries: 1},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "fix: add widgets", res.Message)
+	assert.Equal(t, "fix: add widgets", vcs.committed)
+	require.Len(t, provider.users, 3)
+	assert.Contains(t, provider.users[2], "Added widgets", "the repair request shows the regenerated draft")
+}
+
+func TestCommitCandidates(t *testing.T) {
+	provider := &scriptedProvider{replies: []string{"feat: add widgets", "feat: add widgets", "fix: handle widgets"}}
+	progress := &progress{}
+
+	res, err := workflow.Commit(context.Background(), workflow.CommitDeps{
+		VCS:      &fakeVCS{diff: sampleDiff, branch: "main"},
+		Provider: provider,
+		Progress: progress,
+	}, workflow.CommitParams{Candidates: 3})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"feat: add widgets", "fix: handle widgets"}, res.Candidates)
+	assert.Contains(t, progress.warnings, "got 2 distinct candidates out of 3 requested")
+}
+
+func TestCommitCandidatesAllBlank(t *testing.T) {
+	provider := &scriptedProvider{replies: []string{"```\n```", "  ", "\n"}}
+
+	_, err := workflow.Commit(context.Background(), workflow.CommitDeps{
+		VCS:      &fakeVCS{diff: sampleDiff, branch: "main"},
+		Provider: provider,
+		Progress: &progress{},
+		Prompter: &prompter{},
+	}, workflow.CommitParams{Candidates: 3})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no usable candidates")
+}
+
+func TestCommitCandidatesAreLinted(t *testing.T) {
+	provider := &scriptedProvider{replies: []string{"feat: add widgets", "Added widgets", "fix: add widgets"}}
+
+	res, err := workflow.Commit(context.Background(), workflow.CommitDeps{
+		VCS:      &fakeVCS{diff: sampleDiff, branch: "main"},
+		Provider: provider,
+		Progress: &progress{},
+	}, workflow.CommitParams{
+		Candidates: 2,
+		Lint:       workflow.LintParams{Enabled: true, Retries: 1},
+	})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"feat: add widgets", "fix: add widgets"}, res.Candidates)
+}
+
+func TestCommitInfersIssuesFromBranch(t *testing.T) {
+	patterns := []string{`[A-Z][A-Z0-9]+-\d+`}
+
+	tests := []struct {
+		name     string
+		branch   string
+		want     []string
+		warnings int
+	}{
+		{name: "key in the name", branch: "feature/PROJ-7-widgets", want: []string{"PROJ-7"}, warnings: 1},
+		{name: "no key", branch: "hotfix/2024-10-outage"},
+		{name: "detached HEAD", branch: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &scriptedProvider{replies: []string{"feat: add widgets"}}
+			prog := &progress{}
+
+			res, err := workflow.Commit(context.Background(), workflow.CommitDeps{
+				VCS:      &fakeVCS{diff: sampleDiff, branch: tt.branch},
+				Provider: provider,
+				Progress: prog,
+			}, workflow.CommitParams{IssuePatterns: patterns, DryRun: true})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, res.InferredIssues)
+			assert.Len(t, prog.warnings, tt.warnings)
+		})
+	}
+}
+
+// restartingProvider streams a partial draft, then streams reply in full
+// and returns it, like a fallback chain whose first entry failed partway.
+type restartingProvider struct{ chunkedProvider }
+
+func (p *restartingProvider) GenerateStream(_ context.Context, _, _ string, onChunk func(string)) (string, error) {
+	onChunk("feat: add wid")
+	return p.stream("GenerateStream", onChunk)
+}
+
+func TestCommitReviewShowsStreamedDraftOnce(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider workflow.Provider
+		shown    []string
+	}{
+		{
+			name:     "streamed as is",
+			provider: &streamingProvider{chunkedProvider{reply: "feat: add widgets", chunkSize: 4}},
+		},
+		{
+			name:     "partial draft discarded",
+			provider: &restartingProvider{chunkedProvider{reply: "feat: add widgets", chunkSize: 4}},
+			shown:    []string{"feat: add widgets"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompter := &prompter{choices: []string{"accept"}}
+
+			res, err := workflow.Commit(context.Background(), workflow.CommitDeps{
+				VCS:      &fakeVCS{diff: sampleDiff, branch: "main"},
+				Provider: tt.provider,
+				Progress: &progress{},
+				Prompter: prompter,
+				Stream:   true,
+			}, workflow.CommitParams{Interactive: true})
+			require.NoError(t, err)
+
+			assert.Equal(t, "feat: add widgets", res.Message)
+			assert.Equal(t, tt.shown, prompter.shown)
+		})
+	}
+}
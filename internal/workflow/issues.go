@@ -0,0 +1,26 @@
+package workflow
+
+import (
+	"strings"
+
+	"github.com/arthvm/ditto/internal/branch"
+)
+
+// branchIssues returns the issues patterns find in the name of a branch.
+// Problems are only reported since the issues are a convenience: the
+// generation goes on without them.
+func branchIssues(progress Progress, branchName string, patterns []string) []string {
+	if branchName == "" || len(patterns) == 0 {
+		return nil
+	}
+
+	issues, err := branch.Issues(branchName, patterns)
+	if err != nil {
+		progress.Warnf("could not read issues from branch %s: %v", branchName, err)
+		return nil
+	}
+	if len(issues) > 0 {
+		progress.Warnf("using issues %s from branch %s; pass --issues to override", strings.Join(issues, ", "), branchName)
+	}
+	return issues
+}
@@ -0,0 +1,57 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/arthvm/ditto/internal/conventional"
+	"github.com/arthvm/ditto/internal/prompt"
+)
+
+// LintParams configures the check of generated commit messages against
+// the Conventional Commits rules.
+type LintParams struct {
+	Enabled bool
+	// Retries is how many times the provider is asked to fix the violations
+	// before the message is used as is.
+	Retries int
+	Rules   conventional.Rules
+}
+
+// repairMessage lints msg and, while it breaks rules, asks the provider to
+// revise it with the list of violations. The last revision is returned
+// even when violations remain; they are reported as a warning.
+func repairMessage(ctx context.Context, opts generateOptions, params LintParams, system, user, msg string) (string, error) {
+	violations := conventional.Lint(msg, params.Rules)
+
+	var revisions []prompt.Revision
+	for attempt := 1; len(violations) > 0 && attempt <= params.Retries; attempt++ {
+		revisions = append(revisions, prompt.Revision{
+			Draft:       msg,
+			Instruction: "The message breaks these rules of the convention, fix all of them:\n" + formatViolations(violations),
+		})
+
+		label := fmt.Sprintf(" Fixing %d convention violations...", len(violations))
+		revised, err := generate(ctx, opts, label, system, prompt.Refine(user, revisions))
+		if err != nil {
+			return "", err
+		}
+
+		msg = revised
+		violations = conventional.Lint(msg, params.Rules)
+	}
+
+	if len(violations) > 0 {
+		opts.Progress.Warnf("commit message does not follow the convention:\n%s", formatViolations(violations))
+	}
+	return msg, nil
+}
+
+func formatViolations(violations []conventional.Violation) string {
+	lines := make([]string, len(violations))
+	for i, v := range violations {
+		lines[i] = "- " + v.Message
+	}
+	return strings.Join(lines, "\n")
+}
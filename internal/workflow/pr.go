@@ -2,11 +2,14 @@ package workflow
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/arthvm/ditto/internal/prompt"
+	"github.com/arthvm/ditto/internal/response"
 )
 
 type PRDeps struct {
@@ -15,6 +18,12 @@ type PRDeps struct {
 	Provider        Provider
 	Progress        Progress
 	GenerateTimeout time.Duration
+	Stream          bool
+	// Redactor masks secrets in the log and diff stats. Nil disables redaction.
+	Redactor Redactor
+	// Prompter is used to choose between candidates. Nil means the user
+	// cannot be asked.
+	Prompter Prompter
 }
 
 type PRParams struct {
@@ -27,42 +36,93 @@ type PRParams struct {
 	Issues            []string
 	IgnoreTemplate    bool
 	Draft             bool
+	// Candidates is the number of alternative PRs to generate. With a
+	// Prompter the user picks one; otherwise all of them are returned and
+	// no PR is opened.
+	Candidates int
+	// DryRun generates the title and body without opening the PR.
+	DryRun bool
+	// Labels lists the labels the model may apply to the PR. Labels it
+	// suggests outside of this list are dropped.
+	Labels []string
+	// IssuePatterns find the issues in the name of the head branch when
+	// Issues is empty, see branch.Issues.
+	IssuePatterns []string
 }
 
-func CreatePR(ctx context.Context, deps PRDeps, params PRParams) error {
+// PRMessage is a generated PR.
+type PRMessage struct {
+	Title  string
+	Body   string
+	Labels []string
+	// Breaking reports whether the model considers the changes breaking.
+	Breaking bool
+}
+
+// PRResult describes what CreatePR generated and did.
+type PRResult struct {
+	// PRMessage is the PR that was opened, or would have been in dry-run
+	// mode. It is empty when Candidates are returned instead.
+	PRMessage
+	// Candidates holds the generated alternatives when several were
+	// requested and none could be chosen interactively.
+	Candidates []PRMessage
+	Head       string
+	Base       string
+	// InferredIssues are the issues found in the name of the head branch
+	// because none were given.
+	InferredIssues []string
+	Opened         bool
+	Timings        Timings
+}
+
+func CreatePR(ctx context.Context, deps PRDeps, params PRParams) (PRResult, error) {
+	res := PRResult{Base: params.BaseBranch}
+	started := time.Now()
+
 	headBranch := params.HeadBranch
 	if headBranch == "" {
 		var err error
 		headBranch, err = deps.VCS.CurrentBranch(ctx)
 		if err != nil {
-			return fmt.Errorf("get current branch: %w", err)
+			return res, fmt.Errorf("get current branch: %w", err)
+		}
+		if headBranch == "" {
+			return res, errors.New("HEAD is detached; pass the branch to open the PR from with --head")
 		}
 	}
 
+	if len(params.Issues) == 0 {
+		params.Issues = branchIssues(deps.Progress, headBranch, params.IssuePatterns)
+		res.InferredIssues = params.Issues
+	}
+
 	log, err := deps.VCS.Log(ctx, params.BaseBranch, headBranch)
 	if err != nil {
-		return fmt.Errorf("get log: %w", err)
+		return res, fmt.Errorf("get log: %w", err)
 	}
 
 	diff, err := deps.VCS.DiffStats(ctx, params.BaseBranch, headBranch)
 	if err != nil {
-		return fmt.Errorf("diff stats: %w", err)
+		return res, fmt.Errorf("diff stats: %w", err)
 	}
 
+	redactAll(deps.Redactor, deps.Progress, &log, &diff)
+
 	root, err := deps.VCS.Root(ctx)
 	if err != nil {
-		return fmt.Errorf("get root dir: %w", err)
+		return res, fmt.Errorf("get root dir: %w", err)
 	}
 
 	var template string
 	if !params.IgnoreTemplate {
 		template, err = deps.Platform.FindPRTemplate(root, params.TemplatePath)
 		if err != nil {
-			return fmt.Errorf("get pr template: %w", err)
+			return res, fmt.Errorf("get pr template: %w", err)
 		}
 	}
 
-	system := prompt.PRSystem(params.SystemPrompt, template, params.AdditionalContext)
+	system := prompt.PRSystem(params.SystemPrompt, template, params.AdditionalContext, params.Labels)
 	user := prompt.PRUser(prompt.PRParams{
 		HeadBranch: headBranch,
 		BaseBranch: params.BaseBranch,
@@ -71,44 +131,143 @@ func CreatePR(ctx context.Context, deps PRDeps, params PRParams) error {
 		Issues:     params.Issues,
 	})
 
-	deps.Progress.StartSpinner(" Generating PR...")
+	res.Head = headBranch
+	res.Timings.Collect = time.Since(started)
+	started = time.Now()
 
-	timeout := deps.GenerateTimeout
-	if timeout == 0 {
-		timeout = generateTimeout
+	genOpts := generateOptions{
+		Provider: deps.Provider,
+		Progress: deps.Progress,
+		Timeout:  deps.GenerateTimeout,
+		Stream:   deps.Stream,
+		Schema:   prSchema(params.Labels),
 	}
 
-	genCtx, genCancel := context.WithTimeout(ctx, timeout)
-	defer genCancel()
+	var msg PRMessage
+	if params.Candidates > 1 {
+		candidates, err := generateCandidates(ctx, genOpts,
+			fmt.Sprintf(" Generating %d PRs...", params.Candidates), system, user, params.Candidates)
+		res.Timings.Generate = time.Since(started)
+		if err != nil {
+			return res, fmt.Errorf("generate pr: %w", err)
+		}
+
+		var messages []PRMessage
+		for _, c := range candidates {
+			m, err := parsePRMessage(c, params.Labels)
+			if err != nil {
+				deps.Progress.Warnf("skipping candidate: %v", err)
+				continue
+			}
+			messages = append(messages, m)
+		}
+		if len(messages) == 0 {
+			return res, errors.New("generate pr: no usable candidates")
+		}
 
-	msg, err := deps.Provider.Generate(genCtx, system, user)
+		if deps.Prompter == nil {
+			res.Candidates = messages
+			return res, nil
+		}
 
-	deps.Progress.StopSpinner()
+		msg, err = pickPRMessage(deps.Prompter, messages)
+		if err != nil {
+			return res, err
+		}
+	} else {
+		out, err := generate(ctx, genOpts, " Generating PR...", system, user)
+		res.Timings.Generate = time.Since(started)
+		if err != nil {
+			return res, fmt.Errorf("generate pr: %w", err)
+		}
 
-	if err != nil {
-		return fmt.Errorf("generate pr: %w", err)
+		msg, err = parsePRMessage(out, params.Labels)
+		if err != nil {
+			return res, err
+		}
 	}
 
-	title, body, err := parsePRMessage(msg)
-	if err != nil {
-		return err
+	res.PRMessage = msg
+	if params.DryRun {
+		return res, nil
 	}
 
-	return deps.Platform.OpenPR(ctx, OpenPRParams{
-		Title:     title,
-		Body:      body,
+	err = deps.Platform.OpenPR(ctx, OpenPRParams{
+		Title:     msg.Title,
+		Body:      msg.Body,
 		Head:      headBranch,
 		Base:      params.BaseBranch,
 		UseEditor: params.Edit,
 		Draft:     params.Draft,
+		Labels:    msg.Labels,
 	})
+	if err != nil {
+		return res, err
+	}
+	res.Opened = true
+	return res, nil
+}
+
+// parsePRMessage reads a generated PR and keeps only the suggested labels
+// found in allowed, spelled as configured.
+func parsePRMessage(res string, allowed []string) (PRMessage, error) {
+	pr, err := response.ParsePR(res)
+	if err != nil {
+		return PRMessage{}, fmt.Errorf("generate pr: %w", err)
+	}
+
+	var labels []string
+	for _, label := range pr.Labels {
+		i := slices.IndexFunc(allowed, func(a string) bool { return strings.EqualFold(a, strings.TrimSpace(label)) })
+		if i >= 0 && !slices.Contains(labels, allowed[i]) {
+			labels = append(labels, allowed[i])
+		}
+	}
+
+	return PRMessage{Title: pr.Title, Body: pr.Body, Labels: labels, Breaking: pr.Breaking}, nil
 }
 
-func parsePRMessage(msg string) (title string, body string, err error) {
-	before, after, ok := strings.Cut(msg, "\n")
-	if !ok {
-		return "", "", fmt.Errorf("generate pr: failed to generate body")
+// pickPRMessage asks the user to choose one of several generated PRs.
+func pickPRMessage(prompter Prompter, messages []PRMessage) (PRMessage, error) {
+	texts := make([]string, len(messages))
+	for i, m := range messages {
+		texts[i] = m.Title + "\n\n" + m.Body
 	}
 
-	return strings.TrimSpace(before), strings.TrimSpace(after), nil
+	text, err := pickCandidate(prompter, "PR", texts)
+	if err != nil {
+		return PRMessage{}, err
+	}
+	return messages[slices.Index(texts, text)], nil
+}
+
+// prSchema describes the JSON object requested from providers with
+// structured output. Every property is required and no others are allowed,
+// as strict schemas demand. labels is only present when some are allowed.
+func prSchema(labels []string) *Schema {
+	properties := map[string]any{
+		"title":    map[string]any{"type": "string"},
+		"body":     map[string]any{"type": "string"},
+		"breaking": map[string]any{"type": "boolean"},
+	}
+	required := []string{"title", "body", "breaking"}
+
+	if len(labels) > 0 {
+		properties["labels"] = map[string]any{
+			"type":  "array",
+			"items": map[string]any{"type": "string", "enum": labels},
+		}
+		required = append(required, "labels")
+	}
+
+	return &Schema{
+		Name:        "pull_request",
+		StreamField: "body",
+		Definition: map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		},
+	}
 }
@@ -0,0 +1,144 @@
+package workflow_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/llm"
+	"github.com/arthvm/ditto/internal/llm/fallback"
+	"github.com/arthvm/ditto/internal/llm/openai"
+	"github.com/arthvm/ditto/internal/llm/retry"
+	"github.com/arthvm/ditto/internal/workflow"
+)
+
+const prReply = `{"title":"feat: add widgets","body":"## What\n\nAdds \"widgets\".","breaking":false}`
+
+// TestPRStreamsBody runs CreatePR against providers wrapped the way the
+// CLI wraps them, in a retry layer inside a fallback chain.
+func TestPRStreamsBody(t *testing.T) {
+	tests := []struct {
+		name      string
+		provider  interface{ called() []string }
+		wantCalls []string
+		streamed  string
+	}{
+		{
+			name:      "structured streaming",
+			provider:  &fullProvider{structuredProvider{chunkedProvider{reply: prReply, chunkSize: 7}}},
+			wantCalls: []string{"GenerateJSONStream"},
+			streamed:  "## What\n\nAdds \"widgets\".",
+		},
+		{
+			name:      "streaming only",
+			provider:  &streamingProvider{chunkedProvider{reply: prReply, chunkSize: 7}},
+			wantCalls: []string{"GenerateStream"},
+			streamed:  "## What\n\nAdds \"widgets\".",
+		},
+		{
+			name:      "structured only",
+			provider:  &structuredProvider{chunkedProvider{reply: prReply, chunkSize: 7}},
+			wantCalls: []string{"GenerateJSON"},
+			streamed:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &fallback.Entry{Name: "test", New: func() (llm.Provider, error) {
+				return retry.New(tt.provider.(llm.Provider), retry.Policy{}), nil
+			}}
+			prog := &progress{}
+
+			res, err := workflow.CreatePR(context.Background(), workflow.PRDeps{
+				VCS:      &fakeVCS{branch: "feature"},
+				Platform: &fakePlatform{},
+				Provider: fallback.New([]*fallback.Entry{entry}, 0, nil),
+				Progress: prog,
+				Stream:   true,
+			}, workflow.PRParams{BaseBranch: "main", DryRun: true})
+			require.NoError(t, err)
+
+			assert.Equal(t, "feat: add widgets", res.Title)
+			assert.Equal(t, "## What\n\nAdds \"widgets\".", res.Body)
+			assert.Equal(t, tt.wantCalls, tt.provider.called())
+			assert.Equal(t, tt.streamed, prog.streamed.String())
+		})
+	}
+}
+
+// rejectingProvider fails every structured request with err and answers
+// plain ones with reply.
+type rejectingProvider struct {
+	chunkedProvider
+	err error
+}
+
+func (p *rejectingProvider) GenerateJSON(context.Context, string, string, string, map[string]any) (string, error) {
+	p.calls = append(p.calls, "GenerateJSON")
+	return "", p.err
+}
+
+func TestPRStructuredFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls []string
+		wantErr   string
+	}{
+		{
+			name:      "schema rejected",
+			err:       &openai.APIError{Provider: "openai", StatusCode: 400, Body: `{"error":"response_format json_schema is not supported"}`},
+			wantCalls: []string{"GenerateJSON", "Generate"},
+		},
+		{
+			name:      "server error",
+			err:       &openai.APIError{Provider: "openai", StatusCode: 500, Body: "internal error"},
+			wantCalls: []string{"GenerateJSON"},
+			wantErr:   "status 500",
+		},
+		{
+			name:      "unauthorized",
+			err:       &openai.APIError{Provider: "openai", StatusCode: 401, Body: "invalid api key"},
+			wantCalls: []string{"GenerateJSON"},
+			wantErr:   "status 401",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &rejectingProvider{chunkedProvider: chunkedProvider{reply: "feat: add widgets\n\nAdds widgets."}, err: tt.err}
+			prog := &progress{}
+
+			res, err := workflow.CreatePR(context.Background(), workflow.PRDeps{
+				VCS:      &fakeVCS{branch: "feature"},
+				Platform: &fakePlatform{},
+				Provider: provider,
+				Progress: prog,
+			}, workflow.PRParams{BaseBranch: "main", DryRun: true})
+
+			assert.Equal(t, tt.wantCalls, provider.called())
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Empty(t, prog.warnings)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "feat: add widgets", res.Title)
+			require.Len(t, prog.warnings, 1)
+			assert.Contains(t, prog.warnings[0], "retrying without a schema")
+		})
+	}
+}
+
+func TestPRRefusesDetachedHead(t *testing.T) {
+	_, err := workflow.CreatePR(context.Background(), workflow.PRDeps{
+		VCS:      &fakeVCS{},
+		Platform: &fakePlatform{},
+		Provider: &scriptedProvider{},
+		Progress: &progress{},
+	}, workflow.PRParams{BaseBranch: "main", DryRun: true})
+	assert.ErrorContains(t, err, "HEAD is detached")
+}
@@ -0,0 +1,117 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/arthvm/ditto/internal/prompt"
+)
+
+// ErrAborted is returned when the user discards the generated result.
+var ErrAborted = errors.New("aborted by user")
+
+// Prompter asks the user to make decisions during a workflow.
+type Prompter interface {
+	// Show displays a titled block of text, such as a generated message.
+	Show(title, text string)
+
+	// Select asks the user to pick one of options and returns its index.
+	Select(label string, options []string) (int, error)
+
+	// Input asks the user for a line of free text.
+	Input(label string) (string, error)
+
+	// Edit opens text in the user's editor and returns the edited result.
+	Edit(text string) (string, error)
+}
+
+const (
+	reviewAccept = iota
+	reviewRegenerate
+	reviewEdit
+	reviewRefine
+	reviewAbort
+)
+
+var reviewOptions = []string{"accept", "regenerate", "edit", "refine", "abort"}
+
+// review lets the user accept, regenerate, edit or refine draft until they
+// accept it or give up. Refinements keep every previous draft and piece of
+// feedback in the prompt so the model builds on the conversation so far.
+// Regenerated and refined drafts go through fix, when set, like the first
+// draft did; it is given the user prompt the draft was generated from.
+// shown reports whether draft was just streamed as is, so it needs no
+// second showing.
+func review(ctx context.Context, prompter Prompter, opts generateOptions, label, system, user, draft string, shown bool, fix func(user, draft string) (string, error)) (string, error) {
+	var revisions []prompt.Revision
+
+	for {
+		if !shown {
+			prompter.Show("Proposed "+label+":", draft)
+		}
+		shown = false
+
+		choice, err := prompter.Select("What do you want to do?", reviewOptions)
+		if err != nil {
+			return "", err
+		}
+
+		switch choice {
+		case reviewAccept:
+			return draft, nil
+
+		case reviewAbort:
+			return "", ErrAborted
+
+		case reviewEdit:
+			edited, err := prompter.Edit(draft)
+			if err != nil {
+				return "", err
+			}
+			if strings.TrimSpace(edited) != "" {
+				draft = edited
+			}
+
+		case reviewRegenerate:
+			revisions = nil
+			draft, shown, err = regenerate(ctx, opts, " Regenerating "+label+"...", system, user, fix)
+			if err != nil {
+				return "", err
+			}
+
+		case reviewRefine:
+			instruction, err := prompter.Input(`How should it change? (e.g. "shorter", "mention the migration")`)
+			if err != nil {
+				return "", err
+			}
+			if strings.TrimSpace(instruction) == "" {
+				continue
+			}
+
+			revisions = append(revisions, prompt.Revision{Draft: draft, Instruction: instruction})
+			draft, shown, err = regenerate(ctx, opts, " Refining "+label+"...", system, prompt.Refine(user, revisions), fix)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+}
+
+// regenerate generates a new draft and passes it through fix. It reports
+// whether the resulting draft was already shown while it streamed.
+func regenerate(ctx context.Context, opts generateOptions, label, system, user string, fix func(user, draft string) (string, error)) (string, bool, error) {
+	draft, shown, err := generateShown(ctx, opts, label, system, user)
+	if err != nil {
+		return "", false, err
+	}
+	if fix == nil {
+		return draft, shown, nil
+	}
+
+	fixed, err := fix(user, draft)
+	if err != nil {
+		return "", false, err
+	}
+	return fixed, shown && fixed == draft, nil
+}
@@ -0,0 +1,245 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/arthvm/ditto/internal/conventional"
+	"github.com/arthvm/ditto/internal/prompt"
+)
+
+// HistoryCommit is an existing commit.
+type HistoryCommit struct {
+	Hash    string
+	Parents []string
+	Message string
+}
+
+// RewordVCS reads and rewrites existing commits.
+type RewordVCS interface {
+	// RangeCommits returns the commits of revRange, oldest first.
+	RangeCommits(ctx context.Context, revRange string) ([]HistoryCommit, error)
+
+	// Reachable returns the commits of revRange that can also be reached
+	// from rev.
+	Reachable(ctx context.Context, revRange, rev string) ([]string, error)
+
+	// CommitPatch returns the changes c made to its parent.
+	CommitPatch(ctx context.Context, c HistoryCommit) (string, error)
+
+	// Reword recreates the commits after base up to HEAD with the messages
+	// of those in messages replaced, and moves HEAD to the result. base is
+	// empty to start from the root commit. The new HEAD is returned.
+	Reword(ctx context.Context, base string, messages map[string]string) (string, error)
+}
+
+type RewordDeps struct {
+	VCS             RewordVCS
+	Provider        Provider
+	Progress        Progress
+	GenerateTimeout time.Duration
+	// Redactor masks secrets in the diffs. Nil disables redaction.
+	Redactor Redactor
+	// Prompter is used to confirm the new messages. Nil means the user
+	// cannot be asked, and Yes must be set to rewrite the commits.
+	Prompter Prompter
+}
+
+type RewordParams struct {
+	Range string
+	// BaseBranch is the branch whose commits must never be rewritten.
+	BaseBranch        string
+	SystemPrompt      string
+	AdditionalContext string
+	// MaxPromptTokens bounds the prompt size; oversized diffs are shrunk to
+	// fit. Zero disables the limit.
+	MaxPromptTokens int
+	GeneratedFiles  []string
+	Lint            LintParams
+	// OnlyInvalid rewords only the commits whose message breaks the
+	// convention.
+	OnlyInvalid bool
+	// Yes rewrites the commits without asking for confirmation.
+	Yes bool
+	// DryRun generates the messages without rewriting anything.
+	DryRun bool
+}
+
+// RewordedCommit is a commit and its new message.
+type RewordedCommit struct {
+	Hash   string
+	Before string
+	After  string
+}
+
+// RewordResult describes what Reword generated and did.
+type RewordResult struct {
+	Commits []RewordedCommit
+	// Head is the new HEAD once the commits were rewritten.
+	Head      string
+	Rewritten bool
+	Timings   Timings
+}
+
+// Table renders the subjects of the commits before and after rewording.
+func (r RewordResult) Table() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "COMMIT\tBEFORE\tAFTER")
+	for _, c := range r.Commits {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", shortHash(c.Hash), subject(c.Before), subject(c.After))
+	}
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Reword regenerates the messages of the commits in a range from their own
+// diffs and rewrites the history with them. Merge commits and commits that
+// are already on the base branch are refused.
+func Reword(ctx context.Context, deps RewordDeps, params RewordParams) (RewordResult, error) {
+	var res RewordResult
+	started := time.Now()
+
+	if deps.Prompter == nil && !params.Yes && !params.DryRun {
+		return res, errors.New("cannot confirm the new messages without an interactive terminal")
+	}
+
+	commits, err := rewordCandidates(ctx, deps.VCS, params)
+	if err != nil {
+		return res, err
+	}
+	if len(commits) == 0 {
+		deps.Progress.Warnf("every commit in %s follows the convention, nothing to reword", params.Range)
+		return res, nil
+	}
+
+	genOpts := generateOptions{
+		Provider: deps.Provider,
+		Progress: deps.Progress,
+		Timeout:  deps.GenerateTimeout,
+	}
+	system := prompt.CommitSystem(params.SystemPrompt, params.AdditionalContext)
+
+	res.Timings.Collect = time.Since(started)
+	messages := make(map[string]string, len(commits))
+	for i, c := range commits {
+		collected := time.Now()
+		diff, err := deps.VCS.CommitPatch(ctx, c)
+		if err != nil {
+			return res, fmt.Errorf("diff of %s: %w", shortHash(c.Hash), err)
+		}
+		redactAll(deps.Redactor, deps.Progress, &diff)
+
+		userParams := prompt.CommitParams{}
+		fit := fitDiff(diff, params.MaxPromptTokens, params.GeneratedFiles, system+prompt.CommitUser(userParams))
+		reportFit(deps.Progress, fit, params.MaxPromptTokens)
+		userParams.Diff = fit.Diff
+		user := prompt.CommitUser(userParams)
+		res.Timings.Collect += time.Since(collected)
+
+		generated := time.Now()
+		label := fmt.Sprintf(" Rewording commit %d of %d...", i+1, len(commits))
+		msg, err := generate(ctx, genOpts, label, system, user)
+		if err == nil && params.Lint.Enabled {
+			msg, err = repairMessage(ctx, genOpts, params.Lint, system, user, msg)
+		}
+		res.Timings.Generate += time.Since(generated)
+		if err != nil {
+			return res, fmt.Errorf("generate message for %s: %w", shortHash(c.Hash), err)
+		}
+
+		msg = strings.TrimSpace(msg)
+		messages[c.Hash] = msg
+		res.Commits = append(res.Commits, RewordedCommit{Hash: c.Hash, Before: c.Message, After: msg})
+	}
+
+	if params.DryRun {
+		return res, nil
+	}
+
+	if deps.Prompter != nil && !params.Yes {
+		deps.Prompter.Show("Proposed messages:", res.Table())
+		choice, err := deps.Prompter.Select(fmt.Sprintf("Reword these %d commits?", len(commits)), []string{"yes", "abort"})
+		if err != nil {
+			return res, err
+		}
+		if choice != 0 {
+			return res, ErrAborted
+		}
+	}
+
+	var base string
+	if parents := commits[0].Parents; len(parents) > 0 {
+		base = parents[0]
+	}
+	res.Head, err = deps.VCS.Reword(ctx, base, messages)
+	if err != nil {
+		return res, fmt.Errorf("rewrite commits: %w", err)
+	}
+	res.Rewritten = true
+	return res, nil
+}
+
+// rewordCandidates returns the commits of the range to reword, oldest
+// first, after checking that they can be rewritten.
+func rewordCandidates(ctx context.Context, vcs RewordVCS, params RewordParams) ([]HistoryCommit, error) {
+	commits, err := vcs.RangeCommits(ctx, params.Range)
+	if err != nil {
+		return nil, fmt.Errorf("get commits: %w", err)
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits in %s", params.Range)
+	}
+
+	for _, c := range commits {
+		if len(c.Parents) > 1 {
+			return nil, fmt.Errorf("%s is a merge commit; merges cannot be reworded", shortHash(c.Hash))
+		}
+	}
+
+	if params.BaseBranch != "" {
+		onBase, err := vcs.Reachable(ctx, params.Range, params.BaseBranch)
+		if err != nil {
+			return nil, fmt.Errorf("check base branch: %w", err)
+		}
+		if len(onBase) > 0 {
+			return nil, fmt.Errorf("%d commits of %s are already on %s; refusing to rewrite them", len(onBase), params.Range, params.BaseBranch)
+		}
+	}
+
+	onHead, err := vcs.Reachable(ctx, params.Range, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("check current branch: %w", err)
+	}
+	if len(onHead) != len(commits) {
+		return nil, fmt.Errorf("%d commits of %s are not on the current branch", len(commits)-len(onHead), params.Range)
+	}
+
+	if !params.OnlyInvalid {
+		return commits, nil
+	}
+
+	var invalid []HistoryCommit
+	for _, c := range commits {
+		if len(conventional.Lint(c.Message, params.Lint.Rules)) > 0 {
+			invalid = append(invalid, c)
+		}
+	}
+	return invalid, nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+func subject(msg string) string {
+	first, _, _ := strings.Cut(strings.TrimSpace(msg), "\n")
+	return first
+}
@@ -0,0 +1,71 @@
+package workflow_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/vcs"
+	"github.com/arthvm/ditto/internal/workflow"
+)
+
+func reword(provider workflow.Provider, params workflow.RewordParams) (workflow.RewordResult, error) {
+	params.Yes = true
+	return workflow.Reword(context.Background(), workflow.RewordDeps{
+		VCS:      vcs.Git{},
+		Provider: provider,
+		Progress: &progress{},
+	}, params)
+}
+
+func TestReword(t *testing.T) {
+	git := tempRepo(t)
+	commitFile(t, git, "README", "readme\n", "chore: init")
+	git("switch", "--quiet", "-c", "feature")
+	commitFile(t, git, "a.txt", "a\n", "wip")
+
+	res, err := reword(&scriptedProvider{replies: []string{"feat: add a"}}, workflow.RewordParams{
+		Range:      "main..HEAD",
+		BaseBranch: "main",
+	})
+	require.NoError(t, err)
+	assert.True(t, res.Rewritten)
+	assert.Equal(t, res.Head, git("rev-parse", "HEAD"))
+	assert.Equal(t, "feat: add a\nchore: init", git("log", "--format=%s"))
+}
+
+func TestRewordRefusesMergeCommits(t *testing.T) {
+	git := tempRepo(t)
+	commitFile(t, git, "README", "readme\n", "chore: init")
+	git("switch", "--quiet", "-c", "feature")
+	commitFile(t, git, "a.txt", "a\n", "wip")
+	git("switch", "--quiet", "main")
+	commitFile(t, git, "b.txt", "b\n", "feat: add b")
+	git("switch", "--quiet", "feature")
+	git("merge", "--quiet", "--no-edit", "main")
+	head := git("rev-parse", "HEAD")
+
+	_, err := reword(&scriptedProvider{}, workflow.RewordParams{Range: "HEAD~1..HEAD"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is a merge commit")
+	assert.Equal(t, head, git("rev-parse", "HEAD"))
+}
+
+func TestRewordRefusesCommitsOnBaseBranch(t *testing.T) {
+	git := tempRepo(t)
+	commitFile(t, git, "README", "readme\n", "chore: init")
+	commitFile(t, git, "a.txt", "a\n", "wip")
+	git("switch", "--quiet", "-c", "feature")
+	commitFile(t, git, "b.txt", "b\n", "asdf")
+	head := git("rev-parse", "HEAD")
+
+	_, err := reword(&scriptedProvider{}, workflow.RewordParams{
+		Range:      "HEAD~2..HEAD",
+		BaseBranch: "main",
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 commits of HEAD~2..HEAD are already on main")
+	assert.Equal(t, head, git("rev-parse", "HEAD"))
+}
@@ -0,0 +1,462 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arthvm/ditto/internal/budget"
+	"github.com/arthvm/ditto/internal/conventional"
+	"github.com/arthvm/ditto/internal/git"
+	"github.com/arthvm/ditto/internal/prompt"
+	"github.com/arthvm/ditto/internal/response"
+)
+
+// splitHunkLines is how many lines of each hunk are shown to the model when
+// the whole diff does not fit the prompt budget.
+const splitHunkLines = 20
+
+// IndexState is a snapshot of HEAD and the index.
+type IndexState struct {
+	// Head is empty on a branch with no commits yet.
+	Head string
+	Tree string
+}
+
+// SplitVCS is the part of version control Split needs to build commits
+// one group of hunks at a time.
+type SplitVCS interface {
+	VCS
+
+	// SplitDiff returns the staged changes, or the unstaged changes to
+	// tracked files when worktree is true, as a patch that can be applied
+	// to the index.
+	SplitDiff(ctx context.Context, worktree bool) (string, error)
+
+	// HiddenPaths returns the paths changed in the same diff whose content
+	// must not be sent to the model.
+	HiddenPaths(ctx context.Context, worktree bool) ([]string, error)
+
+	// SaveIndex records HEAD and the index for RestoreIndex.
+	SaveIndex(ctx context.Context) (IndexState, error)
+
+	// RestoreIndex moves HEAD back and replaces the index with the saved
+	// one. The working tree is not touched.
+	RestoreIndex(ctx context.Context, state IndexState) error
+
+	// ResetIndex makes the index match HEAD without touching the working
+	// tree. It empties the index on a branch with no commits yet.
+	ResetIndex(ctx context.Context) error
+
+	// StagePatch applies patch to the index.
+	StagePatch(ctx context.Context, patch string) error
+}
+
+type SplitDeps struct {
+	VCS             SplitVCS
+	Provider        Provider
+	Progress        Progress
+	GenerateTimeout time.Duration
+	// Redactor masks secrets in the hunks. Nil disables redaction.
+	Redactor Redactor
+	// Prompter is used to confirm the plan. Nil means the user cannot be
+	// asked, and Yes must be set to create the commits.
+	Prompter Prompter
+}
+
+type SplitParams struct {
+	SystemPrompt      string
+	AdditionalContext string
+	Issues            []string
+	// MaxPromptTokens bounds the prompt size; hunks are shortened when the
+	// diff does not fit. Zero disables the limit.
+	MaxPromptTokens int
+	// Lint reports generated messages that break the convention.
+	Lint LintParams
+	// Yes creates the commits without asking for confirmation.
+	Yes bool
+	// DryRun generates the plan without committing anything.
+	DryRun bool
+}
+
+// SplitHunk is a part of the diff assigned to a commit: a single hunk, or
+// a whole file when its hunks cannot be applied separately.
+type SplitHunk struct {
+	Path string
+	// Header is the "@@ ... @@" line, or empty for a whole file.
+	Header  string
+	Added   int
+	Removed int
+}
+
+// SplitCommit is a commit of the plan.
+type SplitCommit struct {
+	Message string
+	Hunks   []SplitHunk
+
+	patch string
+}
+
+// String renders the commit for review: the message followed by the list
+// of hunks it contains.
+func (c SplitCommit) String() string {
+	var b strings.Builder
+	b.WriteString(c.Message)
+	b.WriteString("\n")
+	for _, h := range c.Hunks {
+		fmt.Fprintf(&b, "\n  %s", h.Path)
+		if h.Header != "" {
+			fmt.Fprintf(&b, " %s", h.Header)
+		}
+		fmt.Fprintf(&b, " (+%d -%d)", h.Added, h.Removed)
+	}
+	return b.String()
+}
+
+// SplitResult describes what Split planned and did.
+type SplitResult struct {
+	Commits []SplitCommit
+	// Worktree reports whether the unstaged changes were split because
+	// nothing was staged.
+	Worktree  bool
+	Committed bool
+	Timings   Timings
+}
+
+// splitUnit is the smallest part of a diff that can be committed on its
+// own. hunk is -1 for a whole file.
+type splitUnit struct {
+	file int
+	hunk int
+}
+
+// Split asks the provider to group the hunks of the staged changes into
+// commits, shows the plan, and creates the commits in order by staging each
+// group on top of HEAD. When nothing is staged, the unstaged changes to
+// tracked files are split instead. If anything fails once the index was
+// reset, HEAD and the index are restored as they were.
+func Split(ctx context.Context, deps SplitDeps, params SplitParams) (SplitResult, error) {
+	var res SplitResult
+	started := time.Now()
+
+	if deps.Prompter == nil && !params.Yes && !params.DryRun {
+		return res, errors.New("cannot confirm the plan without an interactive terminal")
+	}
+
+	state, err := deps.VCS.SaveIndex(ctx)
+	if err != nil {
+		return res, fmt.Errorf("save index: %w", err)
+	}
+
+	diff, err := deps.VCS.SplitDiff(ctx, false)
+	if err != nil {
+		return res, fmt.Errorf("staged changes: %w", err)
+	}
+	if strings.TrimSpace(diff) == "" {
+		res.Worktree = true
+		diff, err = deps.VCS.SplitDiff(ctx, true)
+		if err != nil {
+			return res, fmt.Errorf("unstaged changes: %w", err)
+		}
+	}
+	if strings.TrimSpace(diff) == "" {
+		return res, errors.New("no changes to split")
+	}
+
+	hidden, err := deps.VCS.HiddenPaths(ctx, res.Worktree)
+	if err != nil {
+		return res, fmt.Errorf("ignored paths: %w", err)
+	}
+
+	files := git.ParsePatch(diff)
+	units := splitUnits(files)
+
+	system := prompt.SplitSystem(params.SystemPrompt, params.AdditionalContext)
+	hunks := renderUnits(files, units, hidden, 0)
+	if params.MaxPromptTokens > 0 && budget.EstimateTokens(system+prompt.SplitUser(hunks, params.Issues)) > params.MaxPromptTokens {
+		deps.Progress.Warnf("diff exceeds the prompt budget, showing the first %d lines of each hunk", splitHunkLines)
+		hunks = renderUnits(files, units, hidden, splitHunkLines)
+	}
+	redactAll(deps.Redactor, deps.Progress, &hunks)
+	user := prompt.SplitUser(hunks, params.Issues)
+
+	res.Timings.Collect = time.Since(started)
+	started = time.Now()
+
+	genOpts := generateOptions{
+		Provider: deps.Provider,
+		Progress: deps.Progress,
+		Timeout:  deps.GenerateTimeout,
+		Schema:   splitSchema(),
+	}
+	out, err := generate(ctx, genOpts, fmt.Sprintf(" Grouping %d hunks into commits...", len(units)), system, user)
+	res.Timings.Generate = time.Since(started)
+	if err != nil {
+		return res, fmt.Errorf("generate split: %w", err)
+	}
+
+	res.Commits, err = planSplit(deps.Progress, out, files, units)
+	if err != nil {
+		return res, fmt.Errorf("generate split: %w", err)
+	}
+
+	if params.Lint.Enabled {
+		rules := params.Lint.Rules
+		rules.Issues = append(rules.Issues, params.Issues...)
+		for i, c := range res.Commits {
+			if violations := conventional.Lint(c.Message, rules); len(violations) > 0 {
+				deps.Progress.Warnf("commit %d does not follow the convention:\n%s", i+1, formatViolations(violations))
+			}
+		}
+	}
+
+	if params.DryRun {
+		return res, nil
+	}
+
+	if deps.Prompter != nil && !params.Yes {
+		for i, c := range res.Commits {
+			deps.Prompter.Show(fmt.Sprintf("Commit %d of %d:", i+1, len(res.Commits)), c.String())
+		}
+		choice, err := deps.Prompter.Select(fmt.Sprintf("Create these %d commits?", len(res.Commits)), []string{"yes", "abort"})
+		if err != nil {
+			return res, err
+		}
+		if choice != 0 {
+			return res, ErrAborted
+		}
+	}
+
+	// The index must not have changed while the plan was generated, or
+	// restoring it on failure would lose those changes.
+	current, err := deps.VCS.SaveIndex(ctx)
+	if err != nil {
+		return res, fmt.Errorf("save index: %w", err)
+	}
+	if current != state {
+		return res, errors.New("the index changed while the plan was generated; nothing was committed")
+	}
+
+	if err := commitSplit(ctx, deps.VCS, res.Commits); err != nil {
+		if restoreErr := deps.VCS.RestoreIndex(ctx, state); restoreErr != nil {
+			return res, fmt.Errorf("%w; restoring HEAD to %s and the index to tree %s also failed: %w",
+				err, state.Head, state.Tree, restoreErr)
+		}
+		return res, fmt.Errorf("%w; HEAD and the index were restored", err)
+	}
+	res.Committed = true
+	return res, nil
+}
+
+// commitSplit resets the index to HEAD and commits each group in turn.
+func commitSplit(ctx context.Context, vcs SplitVCS, commits []SplitCommit) error {
+	if err := vcs.ResetIndex(ctx); err != nil {
+		return fmt.Errorf("reset index: %w", err)
+	}
+
+	for i, c := range commits {
+		if err := vcs.StagePatch(ctx, c.patch); err != nil {
+			return fmt.Errorf("stage commit %d: %w", i+1, err)
+		}
+		if err := vcs.CommitWithMessage(ctx, c.Message, false, false, false); err != nil {
+			return fmt.Errorf("create commit %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// splitUnits lists the parts of files that can be committed separately.
+func splitUnits(files []git.FileDiff) []splitUnit {
+	var units []splitUnit
+	for fi, f := range files {
+		if !f.Splittable() {
+			units = append(units, splitUnit{file: fi, hunk: -1})
+			continue
+		}
+		for hi := range f.Hunks {
+			units = append(units, splitUnit{file: fi, hunk: hi})
+		}
+	}
+	return units
+}
+
+// renderUnits numbers units from 1 for the prompt. The content of hidden
+// and binary files is left out, and hunks are cut after maxLines lines
+// unless maxLines is zero.
+func renderUnits(files []git.FileDiff, units []splitUnit, hidden []string, maxLines int) string {
+	var b strings.Builder
+	for i, u := range units {
+		f := files[u.file]
+		fmt.Fprintf(&b, "### HUNK %d: %s\n", i+1, f.Path())
+
+		var lines []string
+		switch {
+		case slices.Contains(hidden, f.Path()):
+			lines = []string{"(content omitted)"}
+		case f.Binary:
+			lines = append(describeFile(f), "(binary content)")
+		case u.hunk < 0:
+			lines = describeFile(f)
+			for _, h := range f.Hunks {
+				lines = append(lines, h.Header)
+				lines = append(lines, h.Lines...)
+			}
+		default:
+			h := f.Hunks[u.hunk]
+			lines = append([]string{h.Header}, h.Lines...)
+		}
+
+		if maxLines > 0 && len(lines) > maxLines {
+			lines = append(lines[:maxLines:maxLines], fmt.Sprintf("... (%d more lines)", len(lines)-maxLines))
+		}
+		b.WriteString(strings.Join(lines, "\n"))
+		b.WriteString("\n\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// describeFile returns the header lines that say what happens to a file as
+// a whole, such as its creation or renaming.
+func describeFile(f git.FileDiff) []string {
+	var lines []string
+	for _, line := range f.Header {
+		for _, prefix := range []string{"new file", "deleted file", "rename ", "copy ", "old mode", "new mode"} {
+			if strings.HasPrefix(line, prefix) {
+				lines = append(lines, line)
+				break
+			}
+		}
+	}
+	return lines
+}
+
+// planSplit turns the response into commits. Unknown hunk numbers are
+// ignored, a hunk assigned twice stays in its first commit, and hunks left
+// out by the model are added to the last commit.
+func planSplit(progress Progress, out string, files []git.FileDiff, units []splitUnit) ([]SplitCommit, error) {
+	var plan struct {
+		Commits []struct {
+			Message string `json:"message"`
+			Hunks   []int  `json:"hunks"`
+		} `json:"commits"`
+	}
+	if err := response.DecodeJSON(out, &plan); err != nil {
+		return nil, fmt.Errorf("decode plan: %w", err)
+	}
+
+	assigned := make([]bool, len(units))
+	var groups [][]int
+	var messages []string
+	for _, c := range plan.Commits {
+		var group []int
+		for _, n := range c.Hunks {
+			if n < 1 || n > len(units) || assigned[n-1] {
+				continue
+			}
+			assigned[n-1] = true
+			group = append(group, n-1)
+		}
+
+		msg := strings.TrimSpace(c.Message)
+		if len(group) == 0 || msg == "" {
+			continue
+		}
+		groups = append(groups, group)
+		messages = append(messages, msg)
+	}
+	if len(groups) == 0 {
+		return nil, errors.New("the plan has no commits")
+	}
+
+	var missing []int
+	for i, ok := range assigned {
+		if !ok {
+			missing = append(missing, i)
+		}
+	}
+	if len(missing) > 0 {
+		var list strings.Builder
+		for _, i := range missing {
+			f := files[units[i].file]
+			fmt.Fprintf(&list, "\n  %s", f.Path())
+			if units[i].hunk >= 0 {
+				fmt.Fprintf(&list, " %s", f.Hunks[units[i].hunk].Header)
+			}
+		}
+		progress.Warnf("%d hunks were not assigned to a commit; adding them to the last one:%s", len(missing), list.String())
+		groups[len(groups)-1] = append(groups[len(groups)-1], missing...)
+	}
+
+	commits := make([]SplitCommit, len(groups))
+	for i, group := range groups {
+		commits[i] = buildSplitCommit(messages[i], files, units, group)
+	}
+	return commits, nil
+}
+
+// buildSplitCommit assembles the patch of a group, keeping the files and
+// their hunks in diff order so that it applies cleanly.
+func buildSplitCommit(msg string, files []git.FileDiff, units []splitUnit, group []int) SplitCommit {
+	slices.Sort(group)
+
+	c := SplitCommit{Message: msg}
+	var patch strings.Builder
+	for i := 0; i < len(group); {
+		file := units[group[i]].file
+		f := files[file]
+
+		var hunks []int
+		for ; i < len(group) && units[group[i]].file == file; i++ {
+			hunks = append(hunks, units[group[i]].hunk)
+		}
+
+		if hunks[0] < 0 {
+			added, removed := f.Stats()
+			c.Hunks = append(c.Hunks, SplitHunk{Path: f.Path(), Added: added, Removed: removed})
+			patch.WriteString(f.String())
+			continue
+		}
+
+		for _, hi := range hunks {
+			added, removed := f.Hunks[hi].Stats()
+			c.Hunks = append(c.Hunks, SplitHunk{Path: f.Path(), Header: f.Hunks[hi].Header, Added: added, Removed: removed})
+		}
+		patch.WriteString(f.WithHunks(hunks).String())
+	}
+
+	c.patch = patch.String()
+	return c
+}
+
+// splitSchema describes the plan requested from providers with structured
+// output.
+func splitSchema() *Schema {
+	return &Schema{
+		Name: "commit_plan",
+		Definition: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"commits": map[string]any{
+					"type": "array",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"message": map[string]any{"type": "string"},
+							"hunks": map[string]any{
+								"type":  "array",
+								"items": map[string]any{"type": "integer"},
+							},
+						},
+						"required":             []string{"message", "hunks"},
+						"additionalProperties": false,
+					},
+				},
+			},
+			"required":             []string{"commits"},
+			"additionalProperties": false,
+		},
+	}
+}
@@ -0,0 +1,82 @@
+package workflow_test
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/vcs"
+	"github.com/arthvm/ditto/internal/workflow"
+)
+
+// splitPlan groups the hunk of a.txt and the hunk of b.txt into two
+// commits. The second one is rejected by rejectFailHook.
+const splitPlan = `{"commits":[{"message":"feat: add a","hunks":[1]},{"message":"fix: fail on b","hunks":[2]}]}`
+
+func split(provider workflow.Provider, prog *progress) (workflow.SplitResult, error) {
+	return workflow.Split(context.Background(), workflow.SplitDeps{
+		VCS:      vcs.Git{Out: io.Discard},
+		Provider: provider,
+		Progress: prog,
+	}, workflow.SplitParams{Yes: true})
+}
+
+func TestSplitRestoresOnFailure(t *testing.T) {
+	git := tempRepo(t)
+	writeFile(t, "README", "readme\n")
+	git("add", "README")
+	git("commit", "--quiet", "-m", "chore: init")
+
+	writeFile(t, "a.txt", "a\n")
+	writeFile(t, "b.txt", "b\n")
+	writeFile(t, "c.txt", "c\n")
+	git("add", "a.txt", "b.txt")
+	rejectFailHook(t)
+
+	head := git("rev-parse", "HEAD")
+	tree := git("write-tree")
+
+	_, err := split(&scriptedProvider{replies: []string{splitPlan}}, &progress{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "create commit 2")
+	assert.Contains(t, err.Error(), "HEAD and the index were restored")
+
+	assert.Equal(t, head, git("rev-parse", "HEAD"))
+	assert.Equal(t, tree, git("write-tree"))
+	assert.Equal(t, "?? c.txt", git("status", "--porcelain", "--", "c.txt"), "the working tree is not touched")
+}
+
+func TestSplitRestoresFirstCommitOnFailure(t *testing.T) {
+	git := tempRepo(t)
+	writeFile(t, "a.txt", "a\n")
+	writeFile(t, "b.txt", "b\n")
+	git("add", "a.txt", "b.txt")
+	rejectFailHook(t)
+
+	_, err := split(&scriptedProvider{replies: []string{splitPlan}}, &progress{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HEAD and the index were restored")
+
+	assert.Error(t, exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD").Run(), "the branch has no commits again")
+	assert.Equal(t, "a.txt\nb.txt", git("diff", "--cached", "--name-only"))
+}
+
+func TestSplitListsUnassignedHunks(t *testing.T) {
+	git := tempRepo(t)
+	writeFile(t, "a.txt", "a\n")
+	writeFile(t, "b.txt", "b\n")
+	git("add", "a.txt", "b.txt")
+
+	prog := &progress{}
+	res, err := split(&scriptedProvider{replies: []string{`{"commits":[{"message":"feat: add a","hunks":[1]}]}`}}, prog)
+	require.NoError(t, err)
+	assert.True(t, res.Committed)
+
+	require.Len(t, prog.warnings, 1)
+	assert.Equal(t, "1 hunks were not assigned to a commit; adding them to the last one:\n  b.txt", prog.warnings[0])
+	assert.Equal(t, "a.txt\nb.txt", git("show", "--format=", "--name-only", "HEAD"))
+}
@@ -0,0 +1,172 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/arthvm/ditto/internal/prompt"
+)
+
+// SquashVCS is the part of version control Squash needs to read a branch
+// and replace its commits with one.
+type SquashVCS interface {
+	VCS
+
+	// RevParse resolves rev to a commit hash.
+	RevParse(ctx context.Context, rev string) (string, error)
+
+	// MergeBase returns the best common ancestor of a and b.
+	MergeBase(ctx context.Context, a, b string) (string, error)
+
+	// RangeDiff returns the changes between two revisions.
+	RangeDiff(ctx context.Context, from, to string) (string, error)
+
+	// CountCommits returns the number of commits in revRange.
+	CountCommits(ctx context.Context, revRange string) (int, error)
+
+	// SoftReset moves HEAD to rev and keeps the index and working tree.
+	SoftReset(ctx context.Context, rev string) error
+}
+
+type SquashDeps struct {
+	VCS             SquashVCS
+	Provider        Provider
+	Progress        Progress
+	GenerateTimeout time.Duration
+	Stream          bool
+	// Redactor masks secrets in the log and diff. Nil disables redaction.
+	Redactor Redactor
+}
+
+type SquashParams struct {
+	BaseBranch        string
+	Edit              bool
+	SystemPrompt      string
+	AdditionalContext string
+	Issues            []string
+	MaxPromptTokens   int
+	GeneratedFiles    []string
+	MapReduce         MapReduceParams
+	Lint              LintParams
+	// Apply soft-resets the branch to where it forked from the base branch
+	// and commits everything with the generated message.
+	Apply bool
+}
+
+// SquashResult describes what Squash generated and did.
+type SquashResult struct {
+	Message string
+	// Base is the commit the branch forked from.
+	Base    string
+	Commits int
+	Applied bool
+	Timings Timings
+}
+
+// Squash generates one message for all the commits between the base branch
+// and HEAD, from their messages and combined diff, following the commit
+// convention. With Apply, the commits are replaced by a single one.
+func Squash(ctx context.Context, deps SquashDeps, params SquashParams) (SquashResult, error) {
+	var res SquashResult
+	started := time.Now()
+
+	base, err := deps.VCS.MergeBase(ctx, params.BaseBranch, "HEAD")
+	if err != nil {
+		return res, fmt.Errorf("find merge base: %w", err)
+	}
+	res.Base = base
+
+	res.Commits, err = deps.VCS.CountCommits(ctx, base+"..HEAD")
+	if err != nil {
+		return res, fmt.Errorf("count commits: %w", err)
+	}
+	if res.Commits == 0 {
+		return res, fmt.Errorf("no commits between %s and HEAD", params.BaseBranch)
+	}
+
+	if params.Apply {
+		// Staged changes would end up in the squashed commit.
+		staged, err := deps.VCS.CommitDiff(ctx, false, false)
+		if err != nil {
+			return res, fmt.Errorf("staged changes: %w", err)
+		}
+		if strings.TrimSpace(staged) != "" {
+			return res, errors.New("the index has staged changes; commit or stash them before squashing")
+		}
+	}
+
+	log, err := deps.VCS.Log(ctx, base, "HEAD")
+	if err != nil {
+		return res, fmt.Errorf("get log: %w", err)
+	}
+
+	diff, err := deps.VCS.RangeDiff(ctx, base, "HEAD")
+	if err != nil {
+		return res, fmt.Errorf("get diff: %w", err)
+	}
+
+	redactAll(deps.Redactor, deps.Progress, &log, &diff)
+
+	genOpts := generateOptions{
+		Provider: deps.Provider,
+		Progress: deps.Progress,
+		Timeout:  deps.GenerateTimeout,
+		Stream:   deps.Stream,
+	}
+
+	system := prompt.CommitSystem(params.SystemPrompt, params.AdditionalContext)
+	userParams := prompt.CommitParams{Log: log, Issues: params.Issues}
+
+	fit := fitDiff(diff, params.MaxPromptTokens, params.GeneratedFiles, system+prompt.CommitUser(userParams))
+	res.Timings.Collect = time.Since(started)
+	started = time.Now()
+
+	if fit.Summarized && params.MapReduce.Enabled {
+		deps.Progress.Warnf("diff exceeds the prompt budget, summarizing it in parts")
+		userParams.Summaries, err = summarizeDiff(ctx, genOpts, diff, params.MapReduce, params.MaxPromptTokens, params.GeneratedFiles)
+		if err != nil {
+			return res, fmt.Errorf("summarize diff: %w", err)
+		}
+	} else {
+		reportFit(deps.Progress, fit, params.MaxPromptTokens)
+		userParams.Diff = fit.Diff
+	}
+
+	user := prompt.CommitUser(userParams)
+
+	label := fmt.Sprintf(" Generating squash message for %d commits...", res.Commits)
+	msg, err := generate(ctx, genOpts, label, system, user)
+	if err == nil && params.Lint.Enabled {
+		lint := params.Lint
+		lint.Rules.Issues = append(lint.Rules.Issues, params.Issues...)
+		msg, err = repairMessage(ctx, genOpts, lint, system, user, msg)
+	}
+	res.Timings.Generate = time.Since(started)
+	if err != nil {
+		return res, fmt.Errorf("generate squash message: %w", err)
+	}
+
+	res.Message = strings.TrimSpace(msg)
+	if !params.Apply {
+		return res, nil
+	}
+
+	head, err := deps.VCS.RevParse(ctx, "HEAD")
+	if err != nil {
+		return res, fmt.Errorf("resolve HEAD: %w", err)
+	}
+	if err := deps.VCS.SoftReset(ctx, base); err != nil {
+		return res, fmt.Errorf("reset to %s: %w", shortHash(base), err)
+	}
+	if err := deps.VCS.CommitWithMessage(ctx, res.Message, false, false, params.Edit); err != nil {
+		if resetErr := deps.VCS.SoftReset(ctx, head); resetErr != nil {
+			return res, fmt.Errorf("%w; moving HEAD back to %s also failed: %w", err, head, resetErr)
+		}
+		return res, fmt.Errorf("%w; HEAD was moved back", err)
+	}
+	res.Applied = true
+	return res, nil
+}
@@ -0,0 +1,69 @@
+package workflow_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/vcs"
+	"github.com/arthvm/ditto/internal/workflow"
+)
+
+func squash(reply string) (workflow.SquashResult, error) {
+	return workflow.Squash(context.Background(), workflow.SquashDeps{
+		VCS:      vcs.Git{Out: io.Discard},
+		Provider: &scriptedProvider{replies: []string{reply}},
+		Progress: &progress{},
+	}, workflow.SquashParams{BaseBranch: "main", Apply: true})
+}
+
+// featureBranch creates a repository with two commits on a feature branch
+// forked from main.
+func featureBranch(t *testing.T) func(...string) string {
+	t.Helper()
+	git := tempRepo(t)
+	commitFile(t, git, "README", "readme\n", "chore: init")
+	git("switch", "--quiet", "-c", "feature")
+	commitFile(t, git, "a.txt", "a\n", "wip")
+	commitFile(t, git, "b.txt", "b\n", "asdf")
+	return git
+}
+
+func TestSquash(t *testing.T) {
+	git := featureBranch(t)
+
+	res, err := squash("feat: add a and b")
+	require.NoError(t, err)
+	assert.True(t, res.Applied)
+	assert.Equal(t, 2, res.Commits)
+	assert.Equal(t, "feat: add a and b\nchore: init", git("log", "--format=%s"))
+	assert.Equal(t, "a.txt\nb.txt", git("show", "--format=", "--name-only", "HEAD"))
+}
+
+func TestSquashRestoresHeadOnFailure(t *testing.T) {
+	git := featureBranch(t)
+	rejectFailHook(t)
+	head := git("rev-parse", "HEAD")
+
+	_, err := squash("fix: fail on purpose")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HEAD was moved back")
+
+	assert.Equal(t, head, git("rev-parse", "HEAD"))
+	assert.Empty(t, git("status", "--porcelain"), "the index matches HEAD again")
+}
+
+func TestSquashRefusesStagedChanges(t *testing.T) {
+	git := featureBranch(t)
+	writeFile(t, "c.txt", "c\n")
+	git("add", "c.txt")
+	head := git("rev-parse", "HEAD")
+
+	_, err := squash("feat: add a and b")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the index has staged changes")
+	assert.Equal(t, head, git("rev-parse", "HEAD"))
+}
@@ -0,0 +1,88 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/arthvm/ditto/internal/budget"
+	"github.com/arthvm/ditto/internal/prompt"
+)
+
+// MapReduceParams configures how diffs that do not fit the prompt budget,
+// even after shrinking, are summarized in parts before the final prompt.
+type MapReduceParams struct {
+	Enabled bool
+	// Concurrency is the maximum number of summaries requested at once.
+	Concurrency int
+	// ChunkTokens is the maximum size of each part. It is capped by the
+	// prompt budget.
+	ChunkTokens int
+}
+
+// summarizeDiff splits diff into parts and asks the provider to summarize
+// each of them using a bounded pool of workers. Summaries are returned in
+// diff order. The first failure cancels the remaining requests.
+func summarizeDiff(ctx context.Context, opts generateOptions, diff string, params MapReduceParams, maxTokens int, generated []string) ([]string, error) {
+	chunkTokens := maxTokens - budget.EstimateTokens(prompt.ChunkSummarySystem+prompt.ChunkSummaryUser("", 0, 0))
+	if params.ChunkTokens > 0 && (chunkTokens <= 0 || params.ChunkTokens < chunkTokens) {
+		chunkTokens = params.ChunkTokens
+	}
+
+	chunks := budget.Split(diff, budget.Options{MaxTokens: chunkTokens, Generated: generated})
+
+	concurrency := params.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	opts.Progress.StartSpinner(fmt.Sprintf(" Summarizing %d parts of the diff...", len(chunks)))
+	defer opts.Progress.StopSpinner()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	summaries := make([]string, len(chunks))
+	sem := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+
+			genCtx, genCancel := withGenerateTimeout(ctx, opts.Timeout)
+			defer genCancel()
+
+			summary, err := opts.Provider.Generate(genCtx, prompt.ChunkSummarySystem, prompt.ChunkSummaryUser(chunk, i+1, len(chunks)))
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("part %d of %d: %w", i+1, len(chunks), err)
+					cancel()
+				})
+				return
+			}
+			summaries[i] = summary
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return summaries, nil
+}
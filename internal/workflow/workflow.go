@@ -2,15 +2,28 @@ package workflow
 
 import (
 	"context"
+	"strings"
 	"time"
+
+	"github.com/arthvm/ditto/internal/llm"
+	"github.com/arthvm/ditto/internal/response"
 )
 
 // generateTimeout is the fallback used when no timeout is configured.
 const generateTimeout = 2 * time.Minute
 
-// Provider generates text from a system prompt and user prompt.
-type Provider interface {
-	Generate(ctx context.Context, system, user string) (string, error)
+// Provider generates text from a system prompt and user prompt. Providers
+// may also implement the optional interfaces of package llm, such as
+// llm.StreamingProvider and llm.StructuredProvider.
+type Provider = llm.Provider
+
+// Schema describes the JSON document a generation should produce.
+type Schema struct {
+	Name       string
+	Definition map[string]any
+	// StreamField names the string property shown while the document is
+	// streamed. The rest of the document is not shown.
+	StreamField string
 }
 
 // Progress reports long-running operation status to the user.
@@ -19,6 +32,142 @@ type Provider interface {
 type Progress interface {
 	StartSpinner(label string)
 	StopSpinner()
+
+	// Stream renders a chunk of text while it is being generated. It is
+	// called between StartSpinner and StopSpinner.
+	Stream(chunk string)
+
+	// Warnf reports a non-fatal problem, such as a diff that had to be
+	// shortened to fit the prompt budget.
+	Warnf(format string, args ...any)
+}
+
+// Timings records how long the phases of a workflow took.
+type Timings struct {
+	// Collect covers reading the repository and preparing the prompt.
+	Collect time.Duration
+	// Generate covers every request made to the provider before the
+	// result was chosen, excluding time spent in interactive review.
+	Generate time.Duration
+}
+
+// generateOptions holds what is needed to run a single generation.
+type generateOptions struct {
+	Provider Provider
+	Progress Progress
+	Timeout  time.Duration
+	// Stream renders the response as it arrives when the provider supports it.
+	Stream bool
+	// Schema requests a JSON response from providers that support
+	// structured output. Such responses are not cleaned, so callers must
+	// also accept plain text from providers that do not. Only the
+	// Schema.StreamField of such responses is streamed.
+	Schema *Schema
+}
+
+// structured reports whether generate asks for a JSON response.
+func (o generateOptions) structured() bool {
+	return o.Schema != nil && llm.SupportsStructured(o.Provider)
+}
+
+// streams reports whether generate renders the response as it arrives.
+func (o generateOptions) streams() bool {
+	return o.Stream && llm.SupportsStreaming(o.Provider)
+}
+
+// generate runs the provider under the configured timeout while showing
+// progress under label.
+func generate(ctx context.Context, opts generateOptions, label, system, user string) (string, error) {
+	res, _, err := generateShown(ctx, opts, label, system, user)
+	return res, err
+}
+
+// generateShown is generate that also reports whether the response was
+// streamed to the user exactly as returned. It was not when nothing was
+// streamed, or when a provider failed partway through its response and
+// the text shown so far was discarded.
+func generateShown(ctx context.Context, opts generateOptions, label, system, user string) (string, bool, error) {
+	genCtx, genCancel := withGenerateTimeout(ctx, opts.Timeout)
+	defer genCancel()
+
+	opts.Progress.StartSpinner(label)
+	defer opts.Progress.StopSpinner()
+
+	return opts.call(genCtx, system, user, opts.streams())
+}
+
+// call makes a single request and reports whether the response was
+// streamed as returned. Plain text responses are cleaned of model
+// chatter such as preambles and code fences. When the server rejects the
+// schema, for instance because an OpenAI-compatible server does not
+// implement structured output, the request is repeated without one.
+func (o generateOptions) call(ctx context.Context, system, user string, stream bool) (string, bool, error) {
+	var streamed strings.Builder
+	show := func(chunk string) {
+		streamed.WriteString(chunk)
+		o.Progress.Stream(chunk)
+	}
+
+	onChunk := show
+	if o.Schema != nil && o.Schema.StreamField != "" {
+		onChunk = response.StreamField(o.Schema.StreamField, show)
+	}
+
+	if o.structured() {
+		var res string
+		var err error
+		if sp, ok := o.Provider.(llm.StructuredStreamingProvider); ok && stream {
+			res, err = sp.GenerateJSONStream(ctx, system, user, o.Schema.Name, o.Schema.Definition, onChunk)
+		} else {
+			res, err = o.Provider.(llm.StructuredProvider).GenerateJSON(ctx, system, user, o.Schema.Name, o.Schema.Definition)
+		}
+		if err == nil || ctx.Err() != nil || !llm.IsSchemaUnsupported(err) {
+			return res, false, err
+		}
+		o.Progress.Warnf("structured output failed: %v; retrying without a schema", err)
+		streamed.Reset()
+		if o.Schema.StreamField != "" {
+			onChunk = response.StreamField(o.Schema.StreamField, show)
+		}
+	}
+
+	var res string
+	var err error
+	if stream {
+		res, err = o.Provider.(llm.StreamingProvider).GenerateStream(ctx, system, user, onChunk)
+	} else {
+		res, err = o.Provider.Generate(ctx, system, user)
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return response.Clean(res), stream && streamed.String() == res, nil
+}
+
+// Redactor masks secrets in repository content before it is sent to a
+// provider. It returns the redacted text and the number of replacements.
+type Redactor interface {
+	Redact(s string) (string, int)
+}
+
+// redactAll redacts every string pointed to by fields in place and reports
+// the total number of replacements to the user. A nil redactor is a no-op.
+func redactAll(r Redactor, progress Progress, fields ...*string) {
+	if r == nil {
+		return
+	}
+
+	total := 0
+	for _, f := range fields {
+		var n int
+		*f, n = r.Redact(*f)
+		total += n
+	}
+
+	if total > 0 {
+		progress.Warnf("redacted %d potential secrets before sending them to the model", total)
+	}
 }
 
 // VCS abstracts version control operations (git, jj, etc.) for
@@ -34,7 +183,8 @@ type VCS interface {
 	// Log returns the commit log between two branches.
 	Log(ctx context.Context, base, head string) (string, error)
 
-	// CurrentBranch returns the name of the currently checked-out branch.
+	// CurrentBranch returns the name of the currently checked-out branch,
+	// or an empty string when HEAD is detached.
 	CurrentBranch(ctx context.Context) (string, error)
 
 	// Root returns the absolute path to the repository root.
@@ -53,6 +203,7 @@ type OpenPRParams struct {
 	Base      string
 	UseEditor bool
 	Draft     bool
+	Labels    []string
 }
 
 // Platform abstracts hosting platform operations (GitHub, GitLab, etc.)
@@ -67,3 +218,12 @@ type Platform interface {
 	// OpenPR creates a pull request via the platform CLI (e.g. gh, glab).
 	OpenPR(ctx context.Context, params OpenPRParams) error
 }
+
+// withGenerateTimeout bounds a single generation, falling back to
+// generateTimeout when no timeout is configured.
+func withGenerateTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		timeout = generateTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
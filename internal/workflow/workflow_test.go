@@ -0,0 +1,229 @@
+package workflow_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/arthvm/ditto/internal/workflow"
+)
+
+// scriptedProvider returns its replies in order, one per request, and
+// records the user prompts it was given.
+type scriptedProvider struct {
+	mu      sync.Mutex
+	replies []string
+	users   []string
+}
+
+func (p *scriptedProvider) Generate(_ context.Context, _, user string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.users = append(p.users, user)
+	if len(p.replies) == 0 {
+		return "", fmt.Errorf("unexpected request %d", len(p.users))
+	}
+	reply := p.replies[0]
+	p.replies = p.replies[1:]
+	return reply, nil
+}
+
+// progress records the streamed text and the warnings.
+type progress struct {
+	mu       sync.Mutex
+	streamed strings.Builder
+	warnings []string
+}
+
+func (p *progress) StartSpinner(string) {}
+func (p *progress) StopSpinner()        {}
+
+func (p *progress) Stream(chunk string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.streamed.WriteString(chunk)
+}
+
+func (p *progress) Warnf(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.warnings = append(p.warnings, fmt.Sprintf(format, args...))
+}
+
+// prompter answers Select with the scripted choices, by option name, and
+// records what it was shown.
+type prompter struct {
+	choices []string
+	shown   []string
+}
+
+func (p *prompter) Show(_, text string) {
+	p.shown = append(p.shown, text)
+}
+
+func (p *prompter) Select(label string, options []string) (int, error) {
+	if len(p.choices) == 0 {
+		return 0, fmt.Errorf("unexpected question %q", label)
+	}
+	choice := p.choices[0]
+	p.choices = p.choices[1:]
+	for i, opt := range options {
+		if opt == choice {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no option %q in %v", choice, options)
+}
+
+func (p *prompter) Input(string) (string, error) {
+	return "", fmt.Errorf("unexpected input")
+}
+
+func (p *prompter) Edit(text string) (string, error) {
+	return text, nil
+}
+
+// fakeVCS serves a fixed diff and records the committed message.
+type fakeVCS struct {
+	diff      string
+	branch    string
+	branchErr error
+	committed string
+}
+
+func (v *fakeVCS) CommitDiff(context.Context, bool, bool) (string, error) { return v.diff, nil }
+func (v *fakeVCS) DiffStats(context.Context, string, string) (string, error) {
+	return " main.go | 2 +-\n", nil
+}
+func (v *fakeVCS) Log(context.Context, string, string) (string, error) {
+	return "feat: add widgets\n", nil
+}
+func (v *fakeVCS) CurrentBranch(context.Context) (string, error) { return v.branch, v.branchErr }
+func (v *fakeVCS) Root(context.Context) (string, error)          { return "/repo", nil }
+
+func (v *fakeVCS) CommitWithMessage(_ context.Context, msg string, _, _, _ bool) error {
+	v.committed = msg
+	return nil
+}
+
+var _ workflow.VCS = (*fakeVCS)(nil)
+
+const sampleDiff = `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1 +1 @@
+-package old
++package main
+`
+
+// fakePlatform has no PR template and records the opened PR.
+type fakePlatform struct {
+	opened *workflow.OpenPRParams
+}
+
+func (p *fakePlatform) FindPRTemplate(string, string) (string, error) { return "", nil }
+
+func (p *fakePlatform) OpenPR(_ context.Context, params workflow.OpenPRParams) error {
+	p.opened = &params
+	return nil
+}
+
+// chunkedProvider answers every request with reply, streamed in chunks of
+// chunkSize bytes, and records which method was called.
+type chunkedProvider struct {
+	reply     string
+	chunkSize int
+	calls     []string
+}
+
+func (p *chunkedProvider) Generate(context.Context, string, string) (string, error) {
+	p.calls = append(p.calls, "Generate")
+	return p.reply, nil
+}
+
+func (p *chunkedProvider) called() []string { return p.calls }
+
+func (p *chunkedProvider) stream(method string, onChunk func(string)) (string, error) {
+	p.calls = append(p.calls, method)
+	for s := p.reply; s != ""; {
+		n := min(p.chunkSize, len(s))
+		onChunk(s[:n])
+		s = s[n:]
+	}
+	return p.reply, nil
+}
+
+// streamingProvider streams but has no structured output, like Anthropic.
+type streamingProvider struct{ chunkedProvider }
+
+func (p *streamingProvider) GenerateStream(_ context.Context, _, _ string, onChunk func(string)) (string, error) {
+	return p.stream("GenerateStream", onChunk)
+}
+
+// structuredProvider has structured output but does not stream.
+type structuredProvider struct{ chunkedProvider }
+
+func (p *structuredProvider) GenerateJSON(context.Context, string, string, string, map[string]any) (string, error) {
+	p.calls = append(p.calls, "GenerateJSON")
+	return p.reply, nil
+}
+
+// fullProvider streams, with or without structured output, like OpenAI.
+type fullProvider struct{ structuredProvider }
+
+func (p *fullProvider) GenerateStream(_ context.Context, _, _ string, onChunk func(string)) (string, error) {
+	return p.stream("GenerateStream", onChunk)
+}
+
+func (p *fullProvider) GenerateJSONStream(_ context.Context, _, _, _ string, _ map[string]any, onChunk func(string)) (string, error) {
+	return p.stream("GenerateJSONStream", onChunk)
+}
+
+// tempRepo creates a repository in a temporary directory, makes it the
+// working directory, and returns a function that runs git in it and
+// returns the trimmed output.
+func tempRepo(t *testing.T) func(args ...string) string {
+	t.Helper()
+	t.Chdir(t.TempDir())
+
+	git := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", args...).Output()
+		require.NoError(t, err, "git %v", args)
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "--quiet", "--initial-branch=main")
+	git("config", "user.name", "Test")
+	git("config", "user.email", "test@example.com")
+	return git
+}
+
+// writeFile writes content to path in the working directory.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+// commitFile writes content to path and commits it with msg.
+func commitFile(t *testing.T, git func(...string) string, path, content, msg string) {
+	t.Helper()
+	writeFile(t, path, content)
+	git("add", path)
+	git("commit", "--quiet", "-m", msg)
+}
+
+// rejectFailHook makes git commit fail for messages that contain "fail".
+func rejectFailHook(t *testing.T) {
+	t.Helper()
+	hook := filepath.Join(".git", "hooks", "commit-msg")
+	require.NoError(t, os.MkdirAll(filepath.Dir(hook), 0o755))
+	require.NoError(t, os.WriteFile(hook, []byte("#!/bin/sh\n! grep -q fail \"$1\"\n"), 0o755))
+}